@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
 )
 
 var accountsCmd = &cobra.Command{
@@ -11,6 +13,8 @@ var accountsCmd = &cobra.Command{
 	Short: "List connected accounts",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		connFilter, _ := cmd.Flags().GetString("connection")
+		showRetired, _ := cmd.Flags().GetBool("retired")
+		selector, _ := cmd.Flags().GetString("account")
 
 		if app.Config == nil {
 			return ExitWithError(ExitAuthError, "no config loaded. Run: ebcli config --init")
@@ -20,22 +24,39 @@ var accountsCmd = &cobra.Command{
 			return ExitWithError(ExitAuthError, "no connections configured. Run: ebcli connect")
 		}
 
+		if selector != "" && showRetired {
+			return ExitWithError(ExitUserError, "--account and --retired cannot be combined")
+		}
+		var selected map[string]bool // connection/UID of the accounts to list
+		if selector != "" {
+			results, err := resolver.Select(app.Config, selector)
+			if err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			selected = map[string]bool{}
+			for _, r := range results {
+				selected[r.Connection.Name+"/"+r.Account.UID] = true
+			}
+		}
+
 		var output []api.AccountOutput
 		for _, conn := range app.Config.Connections {
 			if connFilter != "" && conn.Name != connFilter {
 				continue
 			}
+			if showRetired {
+				for _, acct := range conn.RetiredAccounts {
+					out := accountOutput(conn, acct.Account)
+					retiredAt := acct.RetiredAt
+					out.RetiredAt = &retiredAt
+					output = append(output, out)
+				}
+				continue
+			}
 			for _, acct := range conn.Accounts {
-				output = append(output, api.AccountOutput{
-					UID:                acct.UID,
-					IBAN:               acct.IBAN,
-					Alias:              acct.Alias,
-					Connection:         conn.Name,
-					Currency:           acct.Currency,
-					CashAccountType:    acct.CashAccountType,
-					IdentificationHash: acct.IdentificationHash,
-					ValidUntil:         conn.ValidUntil,
-				})
+				if selected == nil || selected[conn.Name+"/"+acct.UID] {
+					output = append(output, accountOutput(conn, acct))
+				}
 			}
 		}
 
@@ -43,11 +64,142 @@ var accountsCmd = &cobra.Command{
 			output = []api.AccountOutput{}
 		}
 
+		return printAccounts(output)
+	},
+}
+
+func accountOutput(conn config.Connection, acct config.Account) api.AccountOutput {
+	return api.AccountOutput{
+		ID:                 acct.ID,
+		UID:                acct.UID,
+		IBAN:               acct.IBAN,
+		Alias:              acct.Alias,
+		Connection:         conn.Name,
+		Currency:           acct.Currency,
+		CashAccountType:    acct.CashAccountType,
+		Usage:              acct.Usage,
+		PSUType:            conn.PSU(),
+		Tags:               acct.Tags,
+		IdentificationHash: acct.IdentificationHash,
+		PreviousUIDs:       acct.PreviousUIDs,
+		ValidUntil:         conn.ValidUntil,
+	}
+}
+
+var accountsRenameCmd = &cobra.Command{
+	Use:   "rename <account> <new-alias>",
+	Short: "Change an account's alias",
+	Long:  "Change the alias of an account, identified by alias, UID or IBAN.\nAliases are unique across all connections (case-insensitive).",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		result, err := resolver.Resolve(app.Config, args[0])
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		if err := app.Config.RenameAccount(result.Account.UID, args[1]); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		if err := config.Save(app.ConfigPath, app.Config); err != nil {
+			return ExitWithError(ExitAuthError, "saving config: %v", err)
+		}
+
+		app.Printer.Info("Renamed %s to %s", result.Account.Alias, args[1])
+
+		output := struct {
+			UID      string `json:"uid"`
+			OldAlias string `json:"old_alias"`
+			Alias    string `json:"alias"`
+		}{
+			UID:      result.Account.UID,
+			OldAlias: result.Account.Alias,
+			Alias:    args[1],
+		}
+		return app.Printer.JSON(output)
+	},
+}
+
+var accountsLinkCmd = &cobra.Command{
+	Use:   "link <old-account> <account>",
+	Short: "Merge a retired account into its replacement",
+	Long: "When a bank changes how it identifies an account, reconnect cannot match it and\n" +
+		"the old account is kept as retired (see: ebcli accounts --retired). link makes the\n" +
+		"new account continue the old one: it takes over its ID, alias and UID history, so\n" +
+		"data stored under the old UID or ID still resolves to it.\n\n" +
+		"With --uid, <old-account> is a bare UID from before ebcli kept account history.",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bareUID, _ := cmd.Flags().GetBool("uid")
+
+		target, err := resolver.Resolve(app.Config, args[1])
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		var merged *config.Account
+		if bareUID {
+			merged, err = app.Config.LinkUID(args[0], target.Account.ID)
+		} else {
+			old, rerr := resolver.ResolveRetired(app.Config, args[0])
+			if rerr != nil {
+				return ExitWithError(ExitUserError, "%v (pass --uid to link a bare UID)", rerr)
+			}
+			merged, err = app.Config.LinkAccount(old.ID, target.Account.ID)
+		}
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		if err := config.Save(app.ConfigPath, app.Config); err != nil {
+			return ExitWithError(ExitAuthError, "saving config: %v", err)
+		}
+		app.Printer.Info("Linked %s to %s (now %s, ID %s)", args[0], target.Account.UID, merged.Alias, merged.ID)
+		return app.Printer.JSON(accountOutput(target.Connection, *merged))
+	},
+}
+
+var accountsTagCmd = &cobra.Command{
+	Use:   "tag <accounts> <tag>...",
+	Short: "Add or remove account tags",
+	Long: "Tags group accounts for --account tag:<name>. <accounts> is any account\n" +
+		"selector, so one command can tag several accounts, e.g.:\n\n" +
+		"  ebcli accounts tag connection:ing,revolut-eur household",
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		results, err := resolver.Select(app.Config, args[0])
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		for _, r := range results {
+			if err := app.Config.TagAccount(r.Account.UID, args[1:], remove); err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+		}
+
+		if err := config.Save(app.ConfigPath, app.Config); err != nil {
+			return ExitWithError(ExitAuthError, "saving config: %v", err)
+		}
+
+		output := []api.AccountOutput{}
+		for _, r := range results {
+			if updated, err := resolver.Resolve(app.Config, r.Account.UID); err == nil {
+				output = append(output, accountOutput(updated.Connection, updated.Account))
+			}
+		}
 		return app.Printer.JSON(output)
 	},
 }
 
 func init() {
 	accountsCmd.Flags().String("connection", "", "filter by connection name")
+	accountsCmd.Flags().StringP("account", "a", "", "only accounts matching this selector ("+accountSelectorHelp+")")
+	accountsCmd.Flags().Bool("retired", false, "list accounts retired by reconnects instead")
+	accountsLinkCmd.Flags().Bool("uid", false, "<old-account> is a bare UID, not a retired account")
+	accountsCmd.AddCommand(accountsRenameCmd)
+	accountsTagCmd.Flags().Bool("remove", false, "remove the tags instead of adding them")
+	accountsCmd.AddCommand(accountsLinkCmd)
+	accountsCmd.AddCommand(accountsTagCmd)
 	rootCmd.AddCommand(accountsCmd)
 }
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/audit"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Show the log of API requests",
+	Long: "Every request ebcli makes to the Enable Banking API is appended to " + audit.FileName + " in the config\n" +
+		"directory: when, by which user and command, for which account and connection, the HTTP status,\n" +
+		"rate limit headers and the size of the response. What the bank returned is never recorded.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		accountFlag, _ := cmd.Flags().GetString("account")
+		connectionFlag, _ := cmd.Flags().GetString("connection")
+		outcomeFlag, _ := cmd.Flags().GetString("outcome")
+		fromFlag, _ := cmd.Flags().GetString("from")
+		toFlag, _ := cmd.Flags().GetString("to")
+		daysFlag, _ := cmd.Flags().GetString("days")
+		periodFlag, _ := cmd.Flags().GetString("period")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		var q audit.Query
+		if status, err := strconv.Atoi(outcomeFlag); err == nil {
+			q.Status = status
+		} else if q.Outcome, err = audit.ParseOutcome(outcomeFlag); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		if fromFlag != "" || toFlag != "" || daysFlag != "" || periodFlag != "" {
+			from, to, err := parseDateRange(fromFlag, toFlag, daysFlag, periodFlag)
+			if err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			q.From, q.To = from, to.AddDate(0, 0, 1)
+		}
+		if accountFlag != "" {
+			q.Accounts = auditAccounts(app.Config, accountFlag)
+		}
+		if connectionFlag != "" {
+			q.Connections = map[string]bool{}
+			for _, name := range strings.Split(connectionFlag, ",") {
+				name = strings.TrimSpace(name)
+				q.Connections[name] = true
+				if conn, err := app.Config.FindConnection(name); err == nil {
+					// Requests made before a rename
+					q.Connections[conn.Name] = true
+					for _, former := range conn.FormerNames {
+						q.Connections[former] = true
+					}
+				}
+			}
+		}
+
+		configDir, _, err := config.Paths(flagConfig)
+		if err != nil {
+			return ExitWithError(ExitUserError, "config path: %v", err)
+		}
+		records, bad, err := audit.Read(filepath.Join(configDir, audit.FileName), q.Match)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		if bad > 0 {
+			app.Printer.Warn("skipped %d unreadable lines of the audit log", bad)
+		}
+		if limit > 0 && len(records) > limit {
+			records = records[len(records)-limit:] // the most recent
+		}
+		if records == nil {
+			records = []audit.Record{}
+		}
+		return printResult(records, auditTable)
+	},
+}
+
+func init() {
+	auditCmd.Flags().StringP("account", "a", "", "only requests for these accounts: "+accountSelectorHelp+", or a UID")
+	auditCmd.Flags().String("connection", "", "only requests for these connections (comma-separated)")
+	auditCmd.Flags().String("outcome", "", "only requests that ended so: ok, error (anything else), http_error, rate_limited, network_error, or an HTTP status")
+	auditCmd.Flags().String("from", "", "start date ("+dateHelp+")")
+	auditCmd.Flags().String("to", "", "end date")
+	auditCmd.Flags().String("days", "", "days back from today")
+	auditCmd.Flags().String("period", "", "date range, e.g. last-month, 2026-Q1 or 2026-W14 (instead of --from/--to)")
+	auditCmd.Flags().Int("limit", 0, "show only the most recent requests (0=all)")
+	rootCmd.AddCommand(auditCmd)
+}
+
+// openAuditLog returns app.Audit, opening it on first use. It fails if the
+// log cannot be written, so that no request is made without a record.
+func openAuditLog() (*audit.Log, error) {
+	if app.Audit != nil {
+		return app.Audit, nil
+	}
+	configDir, _, err := config.Paths(flagConfig)
+	if err != nil {
+		return nil, fmt.Errorf("config path: %w", err)
+	}
+	l := audit.Open(filepath.Join(configDir, audit.FileName), commandLine(os.Args))
+	if err := l.Check(); err != nil {
+		return nil, err
+	}
+	app.Audit = l
+	return l, nil
+}
+
+// auditAccounts returns the UIDs, current and previous, of the accounts
+// selected, retired ones included. A selector that matches no account is
+// taken as a UID, as the log outlives connections.
+func auditAccounts(cfg *config.Config, selector string) map[string]bool {
+	uids := map[string]bool{}
+	add := func(acct config.Account) {
+		uids[acct.UID] = true
+		for _, uid := range acct.PreviousUIDs {
+			uids[uid] = true
+		}
+	}
+	if results, err := resolver.Select(cfg, selector); err == nil {
+		for _, ra := range results {
+			add(ra.Account)
+		}
+	}
+	if retired, err := resolver.ResolveRetired(cfg, selector); err == nil {
+		add(retired.Account)
+	}
+	if len(uids) == 0 {
+		uids[strings.TrimSpace(selector)] = true
+	}
+	return uids
+}
+
+// connectionFor returns the name of the connection with an account or a
+// session, for the audit log.
+func connectionFor(accountUID, sessionID string) string {
+	if app.Config == nil {
+		return ""
+	}
+	for _, conn := range app.Config.Connections {
+		if sessionID != "" && conn.SessionID == sessionID {
+			return conn.Name
+		}
+		if accountUID == "" {
+			continue
+		}
+		for _, acct := range conn.Accounts {
+			if hasUID(acct, accountUID) {
+				return conn.Name
+			}
+		}
+		for _, acct := range conn.RetiredAccounts {
+			if hasUID(acct.Account, accountUID) {
+				return conn.Name
+			}
+		}
+	}
+	return ""
+}
+
+// commandLine is args as it would be typed, for the audit log.
+func commandLine(args []string) string {
+	parts := []string{"ebcli"}
+	for _, arg := range args[1:] {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+func hasUID(acct config.Account, uid string) bool {
+	if acct.UID == uid {
+		return true
+	}
+	for _, prev := range acct.PreviousUIDs {
+		if prev == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func auditTable(records []audit.Record) *output.Table {
+	aliases := map[string]string{}
+	if app.Config != nil {
+		for _, conn := range app.Config.Connections {
+			for _, acct := range conn.Accounts {
+				aliases[acct.UID] = acct.Alias
+			}
+		}
+	}
+	t := &output.Table{Columns: []output.Column{
+		{Header: "TIME"}, {Header: "USER"}, {Header: "ACCOUNT"}, {Header: "CONNECTION"}, {Header: "METHOD"},
+		{Header: "ENDPOINT", Shrink: true}, {Header: "STATUS", Align: output.AlignRight}, {Header: "BYTES", Align: output.AlignRight},
+	}}
+	for _, r := range records {
+		account := aliases[r.Account]
+		if account == "" {
+			account = r.Account
+		}
+		status := "-"
+		if r.Status != 0 {
+			status = strconv.Itoa(r.Status)
+		}
+		t.AddRow(r.Time.Local().Format("2006-01-02 15:04:05"), r.User, account, r.Connection, r.Method, r.Endpoint, status, strconv.Itoa(r.Bytes))
+	}
+	return t
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+func TestCommandLine(t *testing.T) {
+	got := commandLine([]string{"/usr/local/bin/ebcli", "transactions", "--filter", `creditor =~ "acme"`, "--days", "7"})
+	want := `ebcli transactions --filter "creditor =~ \"acme\"" --days 7`
+	if got != want {
+		t.Errorf("commandLine = %s, want %s", got, want)
+	}
+}
+
+func TestAuditAccounts(t *testing.T) {
+	cfg := &config.Config{Connections: []config.Connection{{
+		Name:     "ing",
+		Accounts: []config.Account{{UID: "u2", Alias: "main", PreviousUIDs: []string{"u1"}}},
+		RetiredAccounts: []config.RetiredAccount{
+			{Account: config.Account{UID: "u9", Alias: "old"}},
+		},
+	}}}
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{"main", []string{"u1", "u2"}},
+		{"old", []string{"u9"}},
+		{"gone-uid", []string{"gone-uid"}},
+	}
+	for _, tt := range tests {
+		got := auditAccounts(cfg, tt.selector)
+		if len(got) != len(tt.want) {
+			t.Errorf("auditAccounts(%q) = %v, want %v", tt.selector, got, tt.want)
+			continue
+		}
+		for _, uid := range tt.want {
+			if !got[uid] {
+				t.Errorf("auditAccounts(%q) = %v, want %v", tt.selector, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/auth"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/qr"
+)
+
+// authFlow is how the user completes bank authorization for connect and
+// reconnect: in a browser on this machine, or headless with the URL opened
+// anywhere and the result coming back via the callback server or stdin.
+type authFlow struct {
+	server   auth.CallbackServer
+	headless bool // don't open a browser
+	qr       bool // print the authorization URL as a QR code
+	paste    bool // read the redirected URL from stdin instead of listening
+}
+
+func addAuthFlowFlags(cmd *cobra.Command) {
+	cmd.Flags().Int("port", auth.DefaultCallbackPort, "local callback server port")
+	cmd.Flags().String("bind", "", "interface address for the callback server (default: callback_bind, or all interfaces)")
+	cmd.Flags().Bool("tls", false, "serve the callback over HTTPS (default: callback_tls)")
+	cmd.Flags().String("tls-cert", "", "certificate for --tls (default: callback_cert, or a generated self-signed one)")
+	cmd.Flags().String("tls-key", "", "private key for --tls-cert (default: callback_key)")
+	cmd.Flags().Bool("headless", false, "don't open a browser, only print the authorization URL")
+	cmd.Flags().Bool("qr", false, "also print the authorization URL as a QR code (implies --headless)")
+	cmd.Flags().Bool("paste", false, "read the redirected URL from stdin instead of running the callback server (implies --headless)")
+}
+
+func authFlowFromFlags(cmd *cobra.Command) (authFlow, error) {
+	var f authFlow
+	f.server.Port, _ = cmd.Flags().GetInt("port")
+	f.headless, _ = cmd.Flags().GetBool("headless")
+	f.qr, _ = cmd.Flags().GetBool("qr")
+	f.paste, _ = cmd.Flags().GetBool("paste")
+	f.headless = f.headless || f.qr || f.paste
+
+	cfg := app.Config
+	f.server.Host = cfg.CallbackBind
+	if cmd.Flags().Changed("bind") {
+		f.server.Host, _ = cmd.Flags().GetString("bind")
+	}
+
+	useTLS := cfg.CallbackTLS
+	if cmd.Flags().Changed("tls") {
+		useTLS, _ = cmd.Flags().GetBool("tls")
+	}
+	certFile, keyFile := cfg.CallbackCert, cfg.CallbackKey
+	if cmd.Flags().Changed("tls-cert") {
+		certFile, _ = cmd.Flags().GetString("tls-cert")
+		useTLS = true
+	}
+	if cmd.Flags().Changed("tls-key") {
+		keyFile, _ = cmd.Flags().GetString("tls-key")
+	}
+	if useTLS {
+		var err error
+		switch {
+		case certFile != "" && keyFile == "":
+			return f, ExitWithError(ExitUserError, "--tls-cert needs a matching --tls-key (or callback_key)")
+		case certFile != "":
+			if certFile, err = config.ExpandTilde(certFile); err == nil {
+				keyFile, err = config.ExpandTilde(keyFile)
+			}
+		default:
+			var dir string
+			if dir, err = config.EnsureDir(flagConfig); err == nil {
+				certFile, keyFile, err = auth.EnsureSelfSignedCert(dir, f.server.Host)
+			}
+		}
+		if err != nil {
+			return f, ExitWithError(ExitUserError, "callback certificate: %v", err)
+		}
+		f.server.CertFile, f.server.KeyFile = certFile, keyFile
+	}
+
+	if cfg.CallbackPage != "" {
+		path, err := config.ExpandTilde(cfg.CallbackPage)
+		if err == nil {
+			f.server.Page, err = auth.LoadCallbackPage(path)
+		}
+		if err != nil {
+			return f, ExitWithError(ExitUserError, "%v", err)
+		}
+	}
+	return f, nil
+}
+
+// redirectURL is the redirect URL sent with the authorization request:
+// callback_url if set, otherwise the local callback server.
+func (f authFlow) redirectURL() string {
+	if app.Config.CallbackURL != "" {
+		return app.Config.CallbackURL
+	}
+	return f.server.URL()
+}
+
+// authorize sends the authorization request and waits until the user has
+// approved access at the bank. Returns the authorization code.
+func (f authFlow) authorize(ctx context.Context, authReq *api.AuthRequest, approach string) (string, error) {
+	authResp, err := app.Client.Authorize(ctx, authReq)
+	if err != nil {
+		return "", ExitWithError(ExitAPIError, "authorization request failed: %v", err)
+	}
+
+	if approach == "DECOUPLED" {
+		app.Printer.Info("Please complete authorization in your banking app...")
+		app.Printer.Info("Waiting for confirmation (timeout: 5 minutes)...")
+	} else if app.Printer.Events() {
+		// The URL goes in the event instead of on a line of its own
+		app.Printer.Event("authorization_url", map[string]interface{}{"url": authResp.URL, "redirect_url": authReq.RedirectURL})
+		if !f.headless {
+			_ = openBrowser(authResp.URL)
+		}
+	} else {
+		app.Printer.Info("Open this URL to authorize:")
+		fmt.Fprintln(os.Stderr, authResp.URL)
+		if f.qr {
+			if code, err := qr.Encode(authResp.URL); err != nil {
+				app.Printer.Warn("cannot show QR code: %v", err)
+			} else {
+				code.WriteTerminal(os.Stderr)
+			}
+		}
+		if !f.headless {
+			_ = openBrowser(authResp.URL)
+		}
+	}
+
+	app.Printer.Event("waiting", map[string]interface{}{"approach": approach, "paste": f.paste, "timeout_seconds": int(auth.CallbackTimeout.Seconds())})
+
+	var result *auth.CallbackResult
+	if f.paste {
+		result, err = readCallbackURL(authReq.State)
+	} else {
+		if f.headless && app.Config.CallbackURL == "" {
+			app.Printer.Info("Waiting for the redirect to %s. From another machine, forward the port", authReq.RedirectURL)
+			app.Printer.Info("(ssh -L %d:localhost:%d), set callback_url, or use --paste", f.server.Port, f.server.Port)
+		}
+		result, err = f.server.Listen(ctx, authReq.State)
+	}
+	if err != nil {
+		if result != nil && result.Error != "" {
+			return "", ExitWithError(ExitAPIError, "bank authorization failed: %s — %s", result.Error, result.ErrorDescription)
+		}
+		return "", ExitWithError(ExitAPIError, "authorization callback: %v", err)
+	}
+	app.Printer.Event("authorized", nil)
+	return result.Code, nil
+}
+
+// readCallbackURL reads the URL the bank redirected to from stdin. The page
+// itself may fail to load on the machine with the browser; only the URL matters.
+func readCallbackURL(expectedState string) (*auth.CallbackResult, error) {
+	app.Printer.Info("After approving, paste the full URL you were redirected to")
+	app.Printer.Info("(it is fine if the page itself did not load):")
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("reading redirected URL: %w", err)
+	}
+	return auth.ParseCallbackURL(line, expectedState)
+}
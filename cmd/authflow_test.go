@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+func TestAuthFlowFromFlags_TLS(t *testing.T) {
+	saved := app.Config
+	defer func() { app.Config = saved }()
+
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		flags map[string]string
+		cert  string
+		key   string
+	}{
+		{"cert flag, config key", config.Config{CallbackKey: "/etc/ebcli/key.pem"},
+			map[string]string{"tls-cert": "/tmp/cert.pem"}, "/tmp/cert.pem", "/etc/ebcli/key.pem"},
+		{"key flag, config cert", config.Config{CallbackTLS: true, CallbackCert: "/etc/ebcli/cert.pem", CallbackKey: "/etc/ebcli/old.pem"},
+			map[string]string{"tls-key": "/tmp/key.pem"}, "/etc/ebcli/cert.pem", "/tmp/key.pem"},
+		{"both flags", config.Config{CallbackKey: "/etc/ebcli/key.pem"},
+			map[string]string{"tls-cert": "/tmp/cert.pem", "tls-key": "/tmp/key.pem"}, "/tmp/cert.pem", "/tmp/key.pem"},
+	}
+	for _, tt := range tests {
+		cfg := tt.cfg
+		app.Config = &cfg
+		cmd := &cobra.Command{}
+		addAuthFlowFlags(cmd)
+		for name, value := range tt.flags {
+			cmd.Flags().Set(name, value)
+		}
+		f, err := authFlowFromFlags(cmd)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if f.server.CertFile != tt.cert || f.server.KeyFile != tt.key {
+			t.Errorf("%s: cert %q, key %q; want %q, %q", tt.name, f.server.CertFile, f.server.KeyFile, tt.cert, tt.key)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/filter"
 )
 
 var balancesCmd = &cobra.Command{
@@ -15,27 +16,63 @@ var balancesCmd = &cobra.Command{
 		ctx := context.Background()
 		accountFlag, _ := cmd.Flags().GetString("account")
 
+		q, err := parseQuery(cmd, api.Balance{}, balanceAliases)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
 		accounts, err := resolveAccounts(accountFlag)
 		if err != nil {
 			return err
 		}
 
-		accounts = checkDailyLimits(accounts)
+		accounts, limitErr := checkDailyLimits(accounts)
 		if len(accounts) == 0 {
-			return ExitWithError(ExitAPIError, "all accounts skipped due to daily limits")
+			return allDailyLimited(limitErr)
 		}
 
 		var output []api.BalanceOutput
+		var report fetchReport
+		rows := []filter.Row{}
 		for _, ra := range accounts {
 			resp, err := app.Client.GetBalances(ctx, ra.Account.UID, ra.RequiredPSUHeaders)
 			if err != nil {
-				app.Printer.Warn("failed to fetch balances for %s: %v", ra.Account.Alias, err)
+				warnAccount(ra, err, "failed to fetch balances for %s: %v", ra.Account.Alias, err)
+				// Kept in the output, so a failed account is not mistaken for
+				// one without balances
+				output = append(output, api.BalanceOutput{
+					Account:  ra.Account.Alias,
+					IBAN:     ra.Account.IBAN,
+					Balances: []api.Balance{},
+					Errors:   []api.AccountError{report.accountError(ra, "balances", err)},
+				})
+				continue
+			}
+
+			// With a filter, accounts show only their matching balances, and
+			// accounts without any are left out
+			var balances []api.Balance
+			for _, b := range resp.Balances {
+				r := balanceRecord(ra.Account.Alias, ra.Account.IBAN, b)
+				if !q.match(r) {
+					continue
+				}
+				balances = append(balances, b)
+				if q.fields != nil {
+					rows = append(rows, filter.Project(r, q.fields))
+				}
+			}
+			if balances == nil && q.filter != nil {
 				continue
 			}
+			if balances == nil {
+				balances = []api.Balance{}
+			}
 			output = append(output, api.BalanceOutput{
-				Account:  ra.Account.Alias,
-				IBAN:     ra.Account.IBAN,
-				Balances: resp.Balances,
+				Account:       ra.Account.Alias,
+				IBAN:          ra.Account.IBAN,
+				Balances:      balances,
+				FetchComplete: true,
 			})
 		}
 
@@ -44,12 +81,23 @@ var balancesCmd = &cobra.Command{
 		}
 
 		recordDailyAccess(accounts)
-		return app.Printer.JSON(output)
+		if q.fields != nil {
+			// One row per balance, as the fields mix account and balance
+			err = printRows(q.fields, rows)
+		} else {
+			err = printBalances(output)
+		}
+		if err != nil {
+			return err
+		}
+		return report.strictError(cmd)
 	},
 }
 
 func init() {
-	balancesCmd.Flags().StringP("account", "a", "", "account alias, UID, or IBAN")
+	balancesCmd.Flags().StringP("account", "a", "", "accounts: "+accountSelectorHelp)
 	balancesCmd.Flags().Bool("all", false, "fetch all accounts (default when --account not specified)")
+	addStrictFlag(balancesCmd)
+	addQueryFlags(balancesCmd, balanceAliases, false)
 	rootCmd.AddCommand(balancesCmd)
 }
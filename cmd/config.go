@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -41,14 +42,31 @@ func runConfigInit(cmd *cobra.Command) error {
 	}
 	app.Printer.Info("Config directory: %s", dir)
 
-	cfg := &config.Config{}
+	_, cfgPath, err := config.Paths(flagConfig)
+	if err != nil {
+		return ExitWithError(ExitAuthError, "resolving config path: %v", err)
+	}
+	file, err := config.LoadFile(cfgPath)
+	if err != nil {
+		return ExitWithError(ExitAuthError, "loading config: %v", err)
+	}
+	profile := file.ProfileName(flagProfile)
+	app.Printer.Info("Profile: %s", profile)
+
+	cfg := &config.Config{Profile: profile}
+	passphrase := &auth.Passphrase{}
 
 	// Key handling
-	app.Printer.Info("Do you already have a private key from Enable Banking? [y/N]")
+	app.Printer.Info("Where should the private key come from?")
+	app.Printer.Info("  [1] Generate a new keypair (default)")
+	app.Printer.Info("  [2] An existing .pem file (e.g. downloaded from Enable Banking)")
+	app.Printer.Info("  [3] A command that prints the key (secret manager, keychain, ...)")
+	app.Printer.Info("  [4] Supplied at runtime via EBCLI_PRIVATE_KEY_PEM or EBCLI_PRIVATE_KEY_FD")
 	answer, _ := reader.ReadString('\n')
 	answer = strings.TrimSpace(strings.ToLower(answer))
 
-	if answer == "y" || answer == "yes" {
+	switch answer {
+	case "2", "y", "yes":
 		app.Printer.Info("Enter path to your .pem private key file:")
 		keyPath, _ := reader.ReadString('\n')
 		keyPath = strings.TrimSpace(keyPath)
@@ -58,7 +76,7 @@ func runConfigInit(cmd *cobra.Command) error {
 			return ExitWithError(ExitAuthError, "expanding path: %v", err)
 		}
 
-		if _, err := auth.LoadPrivateKey(expanded); err != nil {
+		if _, err := auth.LoadPrivateKeyWithPassphrase(expanded, passphrase.Get); err != nil {
 			return ExitWithError(ExitAuthError, "loading private key: %v", err)
 		}
 		app.Printer.Info("Private key loaded successfully")
@@ -73,29 +91,60 @@ func runConfigInit(cmd *cobra.Command) error {
 				cfg.AppID = candidate
 			}
 		}
-	} else {
-		privPath := filepath.Join(dir, "private.pem")
-		pubPath := filepath.Join(dir, "public.pem")
-
-		privFile, err := os.OpenFile(privPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
-		if err != nil {
-			return ExitWithError(ExitAuthError, "creating private key file: %v", err)
+	case "3":
+		app.Printer.Info("Enter the command that prints the PEM private key (run with sh -c):")
+		keyCommand, _ := reader.ReadString('\n')
+		keyCommand = strings.TrimSpace(keyCommand)
+		if keyCommand == "" {
+			return ExitWithError(ExitAuthError, "key command is required")
 		}
 
-		pubFile, err := os.OpenFile(pubPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-		if err != nil {
-			privFile.Close()
-			return ExitWithError(ExitAuthError, "creating public key file: %v", err)
+		if _, err := auth.LoadKey(auth.CommandKeySource{Command: keyCommand}, passphrase.Get); err != nil {
+			return ExitWithError(ExitAuthError, "loading private key: %v", err)
 		}
+		app.Printer.Info("Private key loaded successfully")
+		cfg.KeyCommand = keyCommand
+	case "4":
+		// Nothing is stored; pick up the key for validation if it is already set
+		cfg.PrivateKeyPEM = os.Getenv("EBCLI_PRIVATE_KEY_PEM")
+		app.Printer.Info("No key will be stored. Provide it on every run with EBCLI_PRIVATE_KEY_PEM")
+		app.Printer.Info("or EBCLI_PRIVATE_KEY_FD (e.g. EBCLI_PRIVATE_KEY_FD=3 ebcli ... 3<key.pem)")
+	default:
+		// Keep keys of non-default profiles apart from the default keypair
+		keyPrefix := ""
+		if profile != config.DefaultProfile {
+			keyPrefix = profile + "-"
+		}
+		privPath := filepath.Join(dir, keyPrefix+"private.pem")
+		pubPath := filepath.Join(dir, keyPrefix+"public.pem")
+
+		app.Printer.Info("Protect the private key with a passphrase? [y/N]")
+		encAnswer, _ := reader.ReadString('\n')
+		encAnswer = strings.TrimSpace(strings.ToLower(encAnswer))
 
+		var privBuf, pubBuf bytes.Buffer
 		app.Printer.Info("Generating 4096-bit RSA keypair (this may take a moment)...")
-		if err := auth.GenerateKeyPair(privFile, pubFile); err != nil {
-			privFile.Close()
-			pubFile.Close()
+		if err := auth.GenerateKeyPair(&privBuf, &pubBuf); err != nil {
 			return ExitWithError(ExitAuthError, "generating keypair: %v", err)
 		}
-		privFile.Close()
-		pubFile.Close()
+
+		privPEM := privBuf.Bytes()
+		if encAnswer == "y" || encAnswer == "yes" {
+			pass, err := passphrase.New("New key passphrase")
+			if err != nil {
+				return ExitWithError(ExitAuthError, "reading passphrase: %v", err)
+			}
+			if privPEM, err = auth.EncryptPrivateKeyPEM(privPEM, pass); err != nil {
+				return ExitWithError(ExitAuthError, "encrypting private key: %v", err)
+			}
+		}
+
+		if err := os.WriteFile(privPath, privPEM, 0600); err != nil {
+			return ExitWithError(ExitAuthError, "writing private key file: %v", err)
+		}
+		if err := os.WriteFile(pubPath, pubBuf.Bytes(), 0644); err != nil {
+			return ExitWithError(ExitAuthError, "writing public key file: %v", err)
+		}
 
 		app.Printer.Info("Private key saved to: %s", privPath)
 		app.Printer.Info("Public key saved to: %s", pubPath)
@@ -144,29 +193,30 @@ func runConfigInit(cmd *cobra.Command) error {
 
 	// Save config
 	cfg.Connections = []config.Connection{}
-	_, cfgPath, err := config.Paths(flagConfig)
-	if err != nil {
-		return ExitWithError(ExitAuthError, "resolving config path: %v", err)
-	}
 	if err := config.Save(cfgPath, cfg); err != nil {
 		return ExitWithError(ExitAuthError, "saving config: %v", err)
 	}
-	app.Printer.Info("Configuration saved to: %s", cfgPath)
+	app.Printer.Info("Configuration saved to: %s (profile %s)", cfgPath, profile)
 
 	// Validate
 	app.Printer.Info("Validating connection to Enable Banking...")
-	keyPath, err := config.ExpandTilde(cfg.PrivateKeyPath)
+	keySource, err := keySourceFor(cfg)
 	if err != nil {
-		app.Printer.Warn("Could not expand key path: %v", err)
+		app.Printer.Warn("Config saved. Validate later with: ebcli status")
 		return nil
 	}
-	privateKey, err := auth.LoadPrivateKey(keyPath)
+	privateKey, err := auth.LoadKey(keySource, passphrase.Get)
 	if err != nil {
 		app.Printer.Warn("Could not load key for validation: %v", err)
 		return nil
 	}
 
-	client := api.NewClient(cfg.AppID, privateKey)
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		app.Printer.Warn("Could not configure client: %v", err)
+		return nil
+	}
+	client := api.NewClient(cfg.AppID, privateKey, opts...)
 	appInfo, err := client.GetApplication(context.Background())
 	if err != nil {
 		app.Printer.Warn("Could not validate app: %v", err)
@@ -189,23 +239,43 @@ func runConfigShow(cmd *cobra.Command) error {
 		return ExitWithError(ExitAuthError, "resolving config path: %v", err)
 	}
 
-	cfg, err := config.Load(cfgPath)
+	cfg, err := config.LoadProfile(cfgPath, flagProfile)
 	if err != nil {
 		return ExitWithError(ExitAuthError, "loading config: %v", err)
 	}
 
+	baseURL := cfg.APIBaseURL()
+	if baseURL == "" {
+		baseURL = api.BaseURL
+	}
+
+	keySource := "none"
+	if src, err := keySourceFor(cfg); err == nil {
+		keySource = src.String()
+	}
+
 	output := struct {
+		Profile        string `json:"profile"`
 		AppID          string `json:"app_id"`
 		PrivateKeyPath string `json:"private_key_path"`
+		KeySource      string `json:"key_source"`
 		Environment    string `json:"environment"`
 		CallbackURL    string `json:"callback_url,omitempty"`
+		BaseURL        string `json:"base_url"`
+		Proxy          string `json:"proxy,omitempty"`
+		CABundle       string `json:"ca_bundle,omitempty"`
 		ConfigPath     string `json:"config_path"`
 		Connections    int    `json:"connections"`
 	}{
+		Profile:        cfg.Profile,
 		AppID:          cfg.AppID,
-		PrivateKeyPath: cfg.PrivateKeyPath,
+		PrivateKeyPath: cfg.KeyPath(),
+		KeySource:      keySource,
 		Environment:    cfg.Environment,
 		CallbackURL:    cfg.CallbackURL,
+		BaseURL:        baseURL,
+		Proxy:          cfg.Proxy,
+		CABundle:       cfg.CABundle,
 		ConfigPath:     cfgPath,
 		Connections:    len(cfg.Connections),
 	}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+const configPathHelp = "\n\nPaths are dot-separated field names in the active profile, e.g. callback_url,\n" +
+	"connections.ing.max_access_per_day or connections.ing.accounts.main.alias.\n" +
+	"Connections are addressed by name, accounts by alias or UID, list elements by index."
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <path>",
+	Short: "Print a config value",
+	Long:  "Print the value at a path in the active profile as JSON." + configPathHelp,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		value, err := app.Config.Get(args[0])
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		return app.Printer.JSON(value)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <path> <value>",
+	Short: "Change a config value",
+	Long: "Set the value at a path in the active profile. The value is parsed as JSON\n" +
+		"when that fits the field (numbers, booleans, lists), otherwise used as a string." + configPathHelp,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := app.Config.Set(args[0], args[1]); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		return saveConfigValue(args[0])
+	},
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <path>",
+	Short: "Remove a config value",
+	Long:  "Reset a field in the active profile to its default, or remove a list element." + configPathHelp,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := app.Config.Unset(args[0]); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		return saveConfigValue(args[0])
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+}
+
+// saveConfigValue writes the edited profile and prints the new value at path.
+func saveConfigValue(path string) error {
+	if err := config.Save(app.ConfigPath, app.Config); err != nil {
+		return ExitWithError(ExitAuthError, "saving config: %v", err)
+	}
+
+	value, err := app.Config.Get(path)
+	if err != nil {
+		// The element itself was removed
+		value = nil
+	}
+	output := struct {
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{
+		Path:  path,
+		Value: value,
+	}
+	return app.Printer.JSON(output)
+}
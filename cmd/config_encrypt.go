@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/auth"
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Protect the private key and/or connections with a passphrase",
+	Long: "Encrypt the profile's private key (PKCS#8, PBKDF2 + AES-256-CBC) and/or the\n" +
+		"connections section of the config (AES-256-GCM). With neither flag, both are encrypted.\n" +
+		"The passphrase comes from EBCLI_PASSPHRASE, passphrase_command, or /dev/tty.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		doKey, doConns, explicit := encryptTargets(cmd)
+
+		var keyPath string
+		var keyData []byte
+		if doKey {
+			var err error
+			if keyPath, keyData, err = readConfiguredKey(); err != nil {
+				return err
+			}
+			if auth.IsEncryptedPEM(keyData) {
+				if explicit {
+					return ExitWithError(ExitUserError, "private key %s is already encrypted", keyPath)
+				}
+				app.Printer.Info("Private key already encrypted: %s", keyPath)
+				doKey = false
+			}
+		}
+
+		pass, err := app.Passphrase.New("New passphrase")
+		if err != nil {
+			return ExitWithError(ExitAuthError, "reading passphrase: %v", err)
+		}
+
+		if doKey {
+			encrypted, err := auth.EncryptPrivateKeyPEM(keyData, pass)
+			if err != nil {
+				return ExitWithError(ExitAuthError, "encrypting private key: %v", err)
+			}
+			if err := config.WriteFileAtomic(keyPath, encrypted, config.FilePermissions); err != nil {
+				return ExitWithError(ExitAuthError, "writing private key: %v", err)
+			}
+			app.Printer.Info("Private key encrypted: %s", keyPath)
+		}
+
+		if doConns {
+			if app.Config.ConnectionsEncrypted() {
+				app.Printer.Info("Re-encrypting connections with the new passphrase")
+			}
+			if err := app.Config.EncryptConnections(pass); err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			if err := config.Save(app.ConfigPath, app.Config); err != nil {
+				return ExitWithError(ExitAuthError, "saving config: %v", err)
+			}
+			app.Printer.Info("Connections encrypted in %s", app.ConfigPath)
+		}
+
+		return app.Printer.JSON(encryptionStatus{Key: doKey, Connections: doConns})
+	},
+}
+
+var configDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Remove passphrase protection from the private key and/or connections",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		doKey, doConns, explicit := encryptTargets(cmd)
+
+		if doKey {
+			keyPath, keyData, err := readConfiguredKey()
+			if err != nil {
+				return err
+			}
+			if auth.IsEncryptedPEM(keyData) {
+				var decrypted []byte
+				err := app.Passphrase.Unlock("Private key passphrase", func(passphrase auth.PassphraseFunc) error {
+					pass, err := passphrase("Private key passphrase")
+					if err != nil {
+						return fmt.Errorf("reading passphrase: %w", err)
+					}
+					decrypted, err = auth.DecryptPrivateKeyPEM(keyData, pass)
+					return err
+				})
+				if err != nil {
+					return ExitWithError(ExitAuthError, "%v", err)
+				}
+				if err := config.WriteFileAtomic(keyPath, decrypted, config.FilePermissions); err != nil {
+					return ExitWithError(ExitAuthError, "writing private key: %v", err)
+				}
+				app.Printer.Info("Private key decrypted: %s", keyPath)
+			} else if explicit {
+				return ExitWithError(ExitUserError, "private key %s is not encrypted", keyPath)
+			} else {
+				doKey = false
+			}
+		}
+
+		if doConns {
+			if app.Config.ConnectionsEncrypted() {
+				if err := app.Config.DecryptConnections(); err != nil {
+					return ExitWithError(ExitUserError, "%v", err)
+				}
+				if err := config.Save(app.ConfigPath, app.Config); err != nil {
+					return ExitWithError(ExitAuthError, "saving config: %v", err)
+				}
+				app.Printer.Info("Connections stored in plaintext in %s", app.ConfigPath)
+			} else if explicit {
+				return ExitWithError(ExitUserError, "connections are not encrypted")
+			} else {
+				doConns = false
+			}
+		}
+
+		return app.Printer.JSON(encryptionStatus{Key: doKey, Connections: doConns})
+	},
+}
+
+type encryptionStatus struct {
+	Key         bool `json:"key"`
+	Connections bool `json:"connections"`
+}
+
+func init() {
+	for _, c := range []*cobra.Command{configEncryptCmd, configDecryptCmd} {
+		c.Flags().Bool("key", false, "only the private key file")
+		c.Flags().Bool("connections", false, "only the connections section of the config")
+		configCmd.AddCommand(c)
+	}
+}
+
+// encryptTargets returns which parts --key/--connections select. Neither flag
+// means both, skipping whichever is already in the requested state.
+func encryptTargets(cmd *cobra.Command) (key, conns, explicit bool) {
+	key, _ = cmd.Flags().GetBool("key")
+	conns, _ = cmd.Flags().GetBool("connections")
+	if !key && !conns {
+		// Keys from key_command or the environment are not ours to rewrite
+		return app.Config.PrivateKeyPath != "", true, false
+	}
+	return key, conns, true
+}
+
+// readConfiguredKey reads the current profile's private key file.
+func readConfiguredKey() (string, []byte, error) {
+	if app.Config.PrivateKeyPath == "" {
+		return "", nil, ExitWithError(ExitAuthError, "no private key file configured; keys from key_command or the environment must be encrypted at their source")
+	}
+	keyPath, err := config.ExpandTilde(app.Config.PrivateKeyPath)
+	if err != nil {
+		return "", nil, ExitWithError(ExitAuthError, "expanding key path: %v", err)
+	}
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return "", nil, ExitWithError(ExitAuthError, "reading private key: %v", err)
+	}
+	return keyPath, data, nil
+}
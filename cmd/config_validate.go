@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for problems",
+	Long: "Report unknown fields, duplicate account aliases, missing key files and\n" +
+		"expired connections across all profiles. Exits 1 if any error is found.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		issues, err := config.Validate(cfgPath, time.Now())
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		errCount := 0
+		for _, issue := range issues {
+			switch issue.Severity {
+			case config.SeverityError:
+				errCount++
+				app.Printer.Warn("%s: %s", issue.Path, issue.Message)
+			case config.SeverityWarning:
+				app.Printer.Warn("%s: %s", issue.Path, issue.Message)
+			default:
+				app.Printer.Info("%s", issue.Message)
+			}
+		}
+
+		output := struct {
+			ConfigPath string         `json:"config_path"`
+			Valid      bool           `json:"valid"`
+			Issues     []config.Issue `json:"issues"`
+		}{
+			ConfigPath: cfgPath,
+			Valid:      errCount == 0,
+			Issues:     issues,
+		}
+		if output.Issues == nil {
+			output.Issues = []config.Issue{}
+		}
+		if err := app.Printer.JSON(output); err != nil {
+			return err
+		}
+
+		if errCount > 0 {
+			return ExitWithError(ExitUserError, "config has %d error(s)", errCount)
+		}
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -7,13 +7,14 @@ import (
 	"os/exec"
 	"runtime"
 	"strings"
+	"text/template"
 	"time"
+	"unicode"
 
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
-	"github.com/nicolasacchi/ebcli/internal/auth"
 	"github.com/nicolasacchi/ebcli/internal/config"
 )
 
@@ -30,29 +31,79 @@ func init() {
 	connectCmd.MarkFlagRequired("bank")
 	connectCmd.Flags().StringP("name", "n", "", "connection alias")
 	connectCmd.Flags().Int("valid-days", 0, "consent validity in days (default: bank's maximum)")
-	connectCmd.Flags().Int("port", auth.DefaultCallbackPort, "local callback server port")
 	connectCmd.Flags().String("auth-method", "", "specific auth method name")
+	connectCmd.Flags().String("psu-type", config.PSUPersonal, "account holder type: personal or business")
 	connectCmd.Flags().Int("max-access-per-day", 0, "daily data refresh limit (0=unlimited)")
+	connectCmd.Flags().StringSlice("accounts", nil, "keep only these IBANs (comma-separated; default: all accounts)")
+	connectCmd.Flags().String("alias-template", "", "Go template for account aliases, e.g. '{{.Bank}}-{{.Last4}}'")
+	connectCmd.Flags().Bool("dry-run", false, "print the authorization request instead of sending it")
+	addAuthFlowFlags(connectCmd)
 	rootCmd.AddCommand(connectCmd)
 }
 
+// connectOptions are the settings for a new connection.
+type connectOptions struct {
+	country         string
+	bank            string
+	name            string // connection name; generated from the bank if empty
+	validDays       int
+	authMethod      string
+	psuType         string
+	maxAccessPerDay int
+	accounts        []string           // IBANs to keep; empty keeps all
+	aliasTemplate   *template.Template // nil names accounts <bank>-<currency>
+	dryRun          bool
+	flow            authFlow
+}
+
 func runConnect(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	country, _ := cmd.Flags().GetString("country")
-	bankName, _ := cmd.Flags().GetString("bank")
-	connName, _ := cmd.Flags().GetString("name")
-	validDays, _ := cmd.Flags().GetInt("valid-days")
-	port, _ := cmd.Flags().GetInt("port")
-	authMethodFlag, _ := cmd.Flags().GetString("auth-method")
-	maxAccessPerDay, _ := cmd.Flags().GetInt("max-access-per-day")
-
-	return doConnect(ctx, country, bankName, connName, validDays, port, authMethodFlag, maxAccessPerDay)
+	var opts connectOptions
+	opts.country, _ = cmd.Flags().GetString("country")
+	opts.bank, _ = cmd.Flags().GetString("bank")
+	opts.name, _ = cmd.Flags().GetString("name")
+	opts.validDays, _ = cmd.Flags().GetInt("valid-days")
+	opts.authMethod, _ = cmd.Flags().GetString("auth-method")
+	opts.maxAccessPerDay, _ = cmd.Flags().GetInt("max-access-per-day")
+	opts.accounts, _ = cmd.Flags().GetStringSlice("accounts")
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	psuType, _ := cmd.Flags().GetString("psu-type")
+	var err error
+	if opts.psuType, err = parsePSUType(psuType); err != nil {
+		return err
+	}
+
+	if text, _ := cmd.Flags().GetString("alias-template"); text != "" {
+		opts.aliasTemplate, err = template.New("alias").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return ExitWithError(ExitUserError, "invalid --alias-template: %v", err)
+		}
+	}
+
+	if opts.flow, err = authFlowFromFlags(cmd); err != nil {
+		return err
+	}
+
+	return doConnect(ctx, opts)
+}
+
+// parsePSUType validates --psu-type.
+func parsePSUType(s string) (string, error) {
+	switch t := strings.ToLower(s); t {
+	case config.PSUPersonal, config.PSUBusiness:
+		return t, nil
+	default:
+		return "", ExitWithError(ExitUserError, "invalid --psu-type %q: must be personal or business", s)
+	}
 }
 
-func doConnect(ctx context.Context, country, bankName, connName string, validDays, port int, authMethodFlag string, maxAccessPerDay int) error {
+func doConnect(ctx context.Context, opts connectOptions) error {
+	country, bankName := opts.country, opts.bank
+
 	// Step 1: Fetch ASPSP info
 	app.Printer.Info("Fetching bank information for %s in %s...", bankName, country)
-	aspsps, err := app.Client.ListASPSPs(ctx, country, "personal")
+	aspsps, err := app.Client.ListASPSPs(ctx, country, opts.psuType)
 	if err != nil {
 		return ExitWithError(ExitAPIError, "fetching banks: %v", err)
 	}
@@ -77,12 +128,17 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 		}
 		return ExitWithError(ExitUserError, "bank %q not found in %s", bankName, country)
 	}
+	if len(aspsp.PSUTypes) > 0 && !containsFold(aspsp.PSUTypes, opts.psuType) {
+		return ExitWithError(ExitUserError, "%s does not support %s connections (supports: %s)", aspsp.Name, opts.psuType, strings.Join(aspsp.PSUTypes, ", "))
+	}
+	app.Printer.Event("bank", map[string]interface{}{"name": aspsp.Name, "country": aspsp.Country, "psu_type": opts.psuType})
 
 	// Step 2: Select auth method
-	selectedMethod, approach, err := selectAuthMethod(aspsp, authMethodFlag)
+	selectedMethod, approach, err := selectAuthMethod(aspsp, opts.authMethod, opts.psuType)
 	if err != nil {
 		return err
 	}
+	app.Printer.Event("auth_method", map[string]interface{}{"name": selectedMethod, "approach": approach})
 
 	if approach == "EMBEDDED" {
 		return ExitWithError(ExitUserError, "EMBEDDED auth is not supported. Use a bank with REDIRECT or DECOUPLED auth, or specify --auth-method")
@@ -94,6 +150,7 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 		maxSeconds = 90 * 86400 // default 90 days
 	}
 
+	validDays := opts.validDays
 	var validUntil time.Time
 	if validDays > 0 {
 		requested := validDays * 86400
@@ -111,7 +168,7 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 	state := uuid.New().String()
 
 	// Step 5: POST /auth
-	callbackURL := auth.CallbackURL(port, app.Config.CallbackURL)
+	callbackURL := opts.flow.redirectURL()
 	authReq := &api.AuthRequest{
 		Access: api.AccessScope{
 			ValidUntil:   validUntil.Format(time.RFC3339),
@@ -121,49 +178,54 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 		ASPSP:       api.ASPSPRef{Name: aspsp.Name, Country: aspsp.Country},
 		State:       state,
 		RedirectURL: callbackURL,
-		PSUType:     "personal",
+		PSUType:     opts.psuType,
 		AuthMethod:  selectedMethod,
 		Language:    "en",
 	}
 
-	app.Printer.Info("Starting authorization...")
-	authResp, err := app.Client.Authorize(ctx, authReq)
-	if err != nil {
-		return ExitWithError(ExitAPIError, "authorization request failed: %v", err)
-	}
-
-	// Step 6: Handle auth approach
-	if approach == "DECOUPLED" {
-		app.Printer.Info("Please complete authorization in your banking app...")
-		app.Printer.Info("Waiting for confirmation (timeout: 5 minutes)...")
-	} else {
-		app.Printer.Info("Open this URL to authorize:")
-		fmt.Fprintln(os.Stderr, authResp.URL)
-		_ = openBrowser(authResp.URL)
+	if opts.dryRun {
+		app.Printer.Info("Dry run: not sending the authorization request")
+		return app.Printer.JSON(authReq)
 	}
 
-	// Wait for callback
-	result, err := auth.ListenForCallback(ctx, port, state)
+	app.Printer.Info("Starting authorization...")
+	code, err := opts.flow.authorize(ctx, authReq, approach)
 	if err != nil {
-		if result != nil && result.Error != "" {
-			return ExitWithError(ExitAPIError, "bank authorization failed: %s — %s", result.Error, result.ErrorDescription)
-		}
-		return ExitWithError(ExitAPIError, "authorization callback: %v", err)
+		return err
 	}
 
-	// Step 7: POST /sessions
+	// Step 6: POST /sessions
 	app.Printer.Info("Creating session...")
-	session, err := app.Client.CreateSession(ctx, result.Code)
+	session, err := app.Client.CreateSession(ctx, code)
 	if err != nil {
 		return ExitWithError(ExitAPIError, "creating session: %v", err)
 	}
 
-	// Step 8: Build connection
+	// Step 7: Build connection
+	connName := opts.name
 	if connName == "" {
 		connName = generateConnectionName(aspsp.Name, app.Config)
 	}
+	app.Printer.Event("session", map[string]interface{}{"connection": connName, "accounts": len(session.Accounts)})
+
+	apiAccounts := session.Accounts
+	if len(opts.accounts) > 0 {
+		var missing []string
+		apiAccounts, missing = filterAccounts(session.Accounts, opts.accounts)
+		for _, iban := range missing {
+			app.Printer.Warn("Account %s was not returned by the bank", iban)
+		}
+		if len(apiAccounts) == 0 {
+			return ExitWithError(ExitUserError, "none of the --accounts were returned by the bank (available: %s)", strings.Join(accountIBANs(session.Accounts), ", "))
+		}
+	}
 
-	accounts := mapAccounts(session.Accounts, aspsp.Name, app.Config)
+	namer := aliasNamer{tmpl: opts.aliasTemplate, bank: aspsp.Name, country: aspsp.Country, connection: connName}
+	accounts, err := mapAccounts(apiAccounts, namer, app.Config)
+	if err != nil {
+		return ExitWithError(ExitUserError, "%v", err)
+	}
+	warnAccountUsage(accounts, opts.psuType)
 
 	conn := config.Connection{
 		Name:                      connName,
@@ -175,7 +237,9 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 		ValidUntil:                validUntil,
 		MaxConsentValiditySeconds: aspsp.MaximumConsentValidity,
 		RequiredPSUHeaders:        aspsp.RequiredPSUHeaders,
-		MaxAccessPerDay:           maxAccessPerDay,
+		MaxAccessPerDay:           opts.maxAccessPerDay,
+		PSUType:                   opts.psuType,
+		AuthMethod:                selectedMethod,
 	}
 
 	if err := app.Config.AddConnection(conn); err != nil {
@@ -190,11 +254,12 @@ func doConnect(ctx context.Context, country, bankName, connName string, validDay
 	for _, acct := range accounts {
 		app.Printer.Info("  %s: %s (%s)", acct.Alias, acct.IBAN, acct.Currency)
 	}
+	app.Printer.Event("connected", map[string]interface{}{"connection": conn.Name, "accounts": len(accounts)})
 
 	return app.Printer.JSON(conn)
 }
 
-func selectAuthMethod(aspsp *api.ASPSPData, preferred string) (methodName string, approach string, err error) {
+func selectAuthMethod(aspsp *api.ASPSPData, preferred, psuType string) (methodName string, approach string, err error) {
 	methods := aspsp.AuthMethods
 	if len(methods) == 0 {
 		return "", "REDIRECT", nil // default
@@ -204,28 +269,29 @@ func selectAuthMethod(aspsp *api.ASPSPData, preferred string) (methodName string
 	if preferred != "" {
 		for _, m := range methods {
 			if strings.EqualFold(m.Name, preferred) {
+				if !authMethodForPSU(m, psuType) {
+					return "", "", ExitWithError(ExitUserError, "auth method %q is for %s users; use --psu-type %s", m.Name, m.PSUType, m.PSUType)
+				}
 				return m.Name, m.Approach, nil
 			}
 		}
 		return "", "", ExitWithError(ExitUserError, "auth method %q not found for %s", preferred, aspsp.Name)
 	}
 
-	// Filter out EMBEDDED methods
-	var usable []api.AuthMethod
-	for _, m := range methods {
-		if m.Approach != "EMBEDDED" {
-			usable = append(usable, m)
-		}
-	}
-
+	usable := usableAuthMethods(methods, psuType)
 	if len(usable) == 0 {
-		return "", "", ExitWithError(ExitUserError, "no supported auth methods for %s (only EMBEDDED available)", aspsp.Name)
+		return "", "", ExitWithError(ExitUserError, "no supported %s auth methods for %s (EMBEDDED is not supported)", psuType, aspsp.Name)
 	}
 
 	if len(usable) == 1 {
 		return usable[0].Name, usable[0].Approach, nil
 	}
 
+	if app.Printer.Events() {
+		app.Printer.Warn("Multiple auth methods available, using first method: %s (choose with --auth-method)", usable[0].Name)
+		return usable[0].Name, usable[0].Approach, nil
+	}
+
 	// Multiple methods — show on stderr and read from /dev/tty
 	app.Printer.Info("Multiple auth methods available:")
 	for i, m := range usable {
@@ -250,6 +316,46 @@ func selectAuthMethod(aspsp *api.ASPSPData, preferred string) (methodName string
 	return usable[choice-1].Name, usable[choice-1].Approach, nil
 }
 
+// usableAuthMethods returns the non-EMBEDDED methods for psuType.
+func usableAuthMethods(methods []api.AuthMethod, psuType string) []api.AuthMethod {
+	var usable []api.AuthMethod
+	for _, m := range methods {
+		if m.Approach != "EMBEDDED" && authMethodForPSU(m, psuType) {
+			usable = append(usable, m)
+		}
+	}
+	return usable
+}
+
+// authMethodForPSU reports whether m can be used by psuType. Methods without
+// a PSU type work for both.
+func authMethodForPSU(m api.AuthMethod, psuType string) bool {
+	return m.PSUType == "" || strings.EqualFold(m.PSUType, psuType)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnAccountUsage warns about private accounts in a business connection and
+// business accounts in a personal one.
+func warnAccountUsage(accounts []config.Account, psuType string) {
+	want := "PRIV"
+	if psuType == config.PSUBusiness {
+		want = "ORGA"
+	}
+	for _, acct := range accounts {
+		if acct.Usage != "" && acct.Usage != want {
+			app.Printer.Warn("Account %s (%s) has usage %s in a %s connection", acct.Alias, acct.IBAN, acct.Usage, psuType)
+		}
+	}
+}
+
 func generateConnectionName(bankName string, cfg *config.Config) string {
 	base := strings.ToLower(strings.ReplaceAll(bankName, " ", "-"))
 	name := base
@@ -270,17 +376,16 @@ func generateConnectionName(bankName string, cfg *config.Config) string {
 	}
 }
 
-func mapAccounts(apiAccounts []api.AccountResource, bankName string, cfg *config.Config) []config.Account {
-	existingAliases := make(map[string]bool)
-	for _, conn := range cfg.Connections {
-		for _, acct := range conn.Accounts {
-			existingAliases[strings.ToLower(acct.Alias)] = true
-		}
-	}
+func mapAccounts(apiAccounts []api.AccountResource, namer aliasNamer, cfg *config.Config) ([]config.Account, error) {
+	existingAliases := cfg.Aliases()
 
 	var accounts []config.Account
-	for _, a := range apiAccounts {
-		alias := generateAlias(bankName, a.Currency, existingAliases)
+	for i, a := range apiAccounts {
+		base, err := namer.base(i, a)
+		if err != nil {
+			return nil, err
+		}
+		alias := uniqueAlias(base, existingAliases)
 		existingAliases[strings.ToLower(alias)] = true
 
 		accounts = append(accounts, config.Account{
@@ -290,13 +395,127 @@ func mapAccounts(apiAccounts []api.AccountResource, bankName string, cfg *config
 			Currency:           a.Currency,
 			IdentificationHash: a.IdentificationHash,
 			CashAccountType:    a.CashAccountType,
+			Usage:              strings.ToUpper(a.Usage),
+
+			IdentificationHashes: a.IdentificationHashes,
 		})
 	}
-	return accounts
+	cfg.AssignAccountIDs(accounts)
+	return accounts, nil
+}
+
+// aliasNamer names new accounts from --alias-template, or <bank>-<currency>.
+type aliasNamer struct {
+	tmpl       *template.Template
+	bank       string
+	country    string
+	connection string
+}
+
+// aliasFields is the data passed to --alias-template.
+type aliasFields struct {
+	Bank       string
+	Country    string
+	Connection string
+	Name       string // account name at the bank
+	Currency   string
+	IBAN       string
+	Last4      string // last four characters of the IBAN or account identification
+	Type       string // cash account type, e.g. CACC
+	Usage      string // PRIV or ORGA
+	Index      int    // 1-based position in the bank's account list
+}
+
+// base returns the alias for the i-th account before making it unique.
+func (n aliasNamer) base(i int, a api.AccountResource) (string, error) {
+	if n.tmpl == nil {
+		return defaultAliasBase(n.bank, a.Currency), nil
+	}
+
+	id := a.AccountID.IBAN
+	if id == "" {
+		id = a.AccountID.Identification
+	}
+	last4 := id
+	if len(last4) > 4 {
+		last4 = last4[len(last4)-4:]
+	}
+
+	var buf strings.Builder
+	err := n.tmpl.Execute(&buf, aliasFields{
+		Bank:       n.bank,
+		Country:    n.country,
+		Connection: n.connection,
+		Name:       a.Name,
+		Currency:   a.Currency,
+		IBAN:       a.AccountID.IBAN,
+		Last4:      last4,
+		Type:       a.CashAccountType,
+		Usage:      a.Usage,
+		Index:      i + 1,
+	})
+	if err != nil {
+		return "", fmt.Errorf("--alias-template: %v", err)
+	}
+
+	// Aliases are single words that can't be read as an account selector
+	words := strings.FieldsFunc(buf.String(), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(config.AliasReserved, r)
+	})
+	alias := strings.ToLower(strings.Join(words, "-"))
+	if alias == "" {
+		return defaultAliasBase(n.bank, a.Currency), nil
+	}
+	return alias, nil
+}
+
+// filterAccounts keeps the accounts whose IBAN is in ibans, ignoring case and
+// spaces. It also returns the requested IBANs that were not found.
+func filterAccounts(accounts []api.AccountResource, ibans []string) (kept []api.AccountResource, missing []string) {
+	want := make(map[string]bool, len(ibans))
+	for _, iban := range ibans {
+		want[normalizeIBAN(iban)] = true
+	}
+	found := make(map[string]bool)
+	for _, a := range accounts {
+		key := normalizeIBAN(a.AccountID.IBAN)
+		if key != "" && want[key] {
+			kept = append(kept, a)
+			found[key] = true
+		}
+	}
+	for _, iban := range ibans {
+		if !found[normalizeIBAN(iban)] {
+			missing = append(missing, iban)
+		}
+	}
+	return kept, missing
+}
+
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+func accountIBANs(accounts []api.AccountResource) []string {
+	var ibans []string
+	for _, a := range accounts {
+		if a.AccountID.IBAN != "" {
+			ibans = append(ibans, a.AccountID.IBAN)
+		}
+	}
+	return ibans
 }
 
 func generateAlias(bankName, currency string, existing map[string]bool) string {
-	base := strings.ToLower(strings.ReplaceAll(bankName, " ", "-")) + "-" + strings.ToLower(currency)
+	return uniqueAlias(defaultAliasBase(bankName, currency), existing)
+}
+
+func defaultAliasBase(bankName, currency string) string {
+	return strings.ToLower(strings.ReplaceAll(bankName, " ", "-")) + "-" + strings.ToLower(currency)
+}
+
+// uniqueAlias appends -2, -3, ... to base until it is not in existing.
+func uniqueAlias(base string, existing map[string]bool) string {
 	alias := base
 	suffix := 1
 	for existing[strings.ToLower(alias)] {
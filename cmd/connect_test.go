@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+	"text/template"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+func testAccount(uid, iban, currency string) api.AccountResource {
+	return api.AccountResource{UID: uid, AccountID: api.AccountID{IBAN: iban}, Currency: currency, CashAccountType: "CACC"}
+}
+
+func TestFilterAccounts(t *testing.T) {
+	accounts := []api.AccountResource{
+		testAccount("1", "FI2112345600000785", "EUR"),
+		testAccount("2", "FI5542345670000081", "EUR"),
+	}
+
+	kept, missing := filterAccounts(accounts, []string{"fi55 4234 5670 0000 81", "DE00"})
+	if len(kept) != 1 || kept[0].UID != "2" {
+		t.Errorf("kept = %+v, want account 2", kept)
+	}
+	if !reflect.DeepEqual(missing, []string{"DE00"}) {
+		t.Errorf("missing = %v, want [DE00]", missing)
+	}
+}
+
+func TestMapAccounts_AliasTemplate(t *testing.T) {
+	cfg := &config.Config{Connections: []config.Connection{
+		{Name: "old", Accounts: []config.Account{{UID: "x", Alias: "nordea-0785"}}},
+	}}
+	accounts := []api.AccountResource{
+		testAccount("1", "FI2112345600000785", "EUR"),
+		testAccount("2", "FI5542345670000081", "EUR"),
+	}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want []string
+	}{
+		{"default", "", []string{"nordea-eur", "nordea-eur-2"}},
+		{"last4 made unique", "{{.Bank}}-{{.Last4}}", []string{"nordea-0785-2", "nordea-0081"}},
+		{"spaces and commas", "{{.Connection}} {{.Type}}, {{.Index}}", []string{"my-nordea-cacc-1", "my-nordea-cacc-2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namer := aliasNamer{bank: "Nordea", country: "FI", connection: "My Nordea"}
+			if tt.tmpl != "" {
+				namer.tmpl = template.Must(template.New("alias").Parse(tt.tmpl))
+			}
+			got, err := mapAccounts(accounts, namer, cfg)
+			if err != nil {
+				t.Fatalf("mapAccounts: %v", err)
+			}
+			var aliases []string
+			for _, a := range got {
+				aliases = append(aliases, a.Alias)
+			}
+			if !reflect.DeepEqual(aliases, tt.want) {
+				t.Errorf("aliases = %v, want %v", aliases, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsableAuthMethods(t *testing.T) {
+	methods := []api.AuthMethod{
+		{Name: "app", Approach: "DECOUPLED", PSUType: "personal"},
+		{Name: "corporate", Approach: "REDIRECT", PSUType: "business"},
+		{Name: "embedded", Approach: "EMBEDDED"},
+		{Name: "any", Approach: "REDIRECT"},
+	}
+
+	names := func(ms []api.AuthMethod) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.Name)
+		}
+		return out
+	}
+	if got := names(usableAuthMethods(methods, "personal")); !reflect.DeepEqual(got, []string{"app", "any"}) {
+		t.Errorf("personal = %v, want [app any]", got)
+	}
+	if got := names(usableAuthMethods(methods, "business")); !reflect.DeepEqual(got, []string{"corporate", "any"}) {
+		t.Errorf("business = %v, want [corporate any]", got)
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+)
+
+var connectionsCmd = &cobra.Command{
+	Use:   "connections",
+	Short: "Manage bank connections",
+}
+
+var connectionsEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Change a connection's settings",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := app.Config.FindConnection(args[0])
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		changed := false
+		if cmd.Flags().Changed("max-access-per-day") {
+			maxAccessPerDay, _ := cmd.Flags().GetInt("max-access-per-day")
+			if maxAccessPerDay < 0 {
+				return ExitWithError(ExitUserError, "--max-access-per-day must be 0 (unlimited) or more")
+			}
+			conn.MaxAccessPerDay = maxAccessPerDay
+			changed = true
+		}
+		oldName := conn.Name
+		if cmd.Flags().Changed("rename") {
+			newName, _ := cmd.Flags().GetString("rename")
+			if err := app.Config.RenameConnection(conn.Name, newName); err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			changed = true
+		}
+		if !changed {
+			return ExitWithError(ExitUserError, "nothing to change; see: ebcli connections edit --help")
+		}
+
+		// Today's accesses follow the connection, or a rename would reset
+		// its daily limit
+		var tracker *ratelimit.Tracker
+		if conn.Name != oldName {
+			configDir, _, err := config.Paths(flagConfig)
+			if err != nil {
+				return ExitWithError(ExitUserError, "config path: %v", err)
+			}
+			if tracker, err = ratelimit.NewTracker(configDir, os.Stderr); err != nil {
+				return ExitWithError(ExitUserError, "rate limit cache: %v", err)
+			}
+			tracker.RenameDaily(usageKey(oldName), usageKey(conn.Name))
+			if err := tracker.Persist(); err != nil {
+				return ExitWithError(ExitUserError, "moving daily usage: %v", err)
+			}
+		}
+
+		if err := config.Save(app.ConfigPath, app.Config); err != nil {
+			if tracker != nil {
+				tracker.RenameDaily(usageKey(conn.Name), usageKey(oldName))
+				tracker.Persist()
+			}
+			return ExitWithError(ExitAuthError, "saving config: %v", err)
+		}
+		app.Printer.Info("Updated connection %s", conn.Name)
+
+		output := struct {
+			Name            string `json:"name"`
+			ASPSPName       string `json:"aspsp_name"`
+			ASPSPCountry    string `json:"aspsp_country"`
+			MaxAccessPerDay int    `json:"max_access_per_day"`
+		}{
+			Name:            conn.Name,
+			ASPSPName:       conn.ASPSPName,
+			ASPSPCountry:    conn.ASPSPCountry,
+			MaxAccessPerDay: conn.MaxAccessPerDay,
+		}
+		return app.Printer.JSON(output)
+	},
+}
+
+func init() {
+	connectionsEditCmd.Flags().String("rename", "", "new connection name")
+	connectionsEditCmd.Flags().Int("max-access-per-day", 0, "daily data refresh limit (0=unlimited)")
+	connectionsCmd.AddCommand(connectionsEditCmd)
+	rootCmd.AddCommand(connectionsCmd)
+}
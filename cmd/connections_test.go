@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+)
+
+func TestConnectionsEdit_RenameKeepsDailyUsage(t *testing.T) {
+	savedConfig, savedPath, savedPrinter := app.Config, app.ConfigPath, app.Printer
+	dir := t.TempDir()
+	flagConfig = filepath.Join(dir, "config.json")
+	rename := connectionsEditCmd.Flags().Lookup("rename")
+	defer func() {
+		app.Config, app.ConfigPath, app.Printer = savedConfig, savedPath, savedPrinter
+		flagConfig = ""
+		rename.Value.Set("")
+		rename.Changed = false
+	}()
+	app.Printer = output.NewPrinter(&bytes.Buffer{}, &bytes.Buffer{}, output.ModeCompact, false)
+	app.ConfigPath = flagConfig
+	app.Config = &config.Config{Profile: config.DefaultProfile, Connections: []config.Connection{{Name: "ing", MaxAccessPerDay: 4}}}
+
+	tracker, _ := ratelimit.NewTracker(dir, &bytes.Buffer{})
+	tracker.RecordDaily("default/ing", 4)
+	if err := tracker.Persist(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := connectionsEditCmd.Flags().Set("rename", "home"); err != nil {
+		t.Fatal(err)
+	}
+	if err := connectionsEditCmd.RunE(connectionsEditCmd, []string{"ing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker, _ = ratelimit.NewTracker(dir, &bytes.Buffer{})
+	if used, _ := tracker.DailyUsageFor("default/home"); used != 1 {
+		t.Errorf("default/home used = %d, want 1", used)
+	}
+	if used, _ := tracker.DailyUsageFor("default/ing"); used != 0 {
+		t.Errorf("default/ing used = %d, want 0 after the rename", used)
+	}
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/ical"
+	"github.com/nicolasacchi/ebcli/internal/output"
+)
+
+var consentsCmd = &cobra.Command{
+	Use:   "consents",
+	Short: "List consents nearing expiry, renew them, or export reminders",
+	Long: "List connections whose consent expires within --within days (or already has).\n" +
+		"With --reconnect, run reconnect for each of them in turn. With --ics, write an\n" +
+		"iCalendar file with an expiry reminder for every connection, to import or\n" +
+		"subscribe to in a calendar app. It is written after any reconnects; with\n" +
+		"--ics - it is written to stdout instead of the listing.",
+	RunE: runConsents,
+}
+
+func init() {
+	consentsCmd.Flags().Int("within", 14, "days ahead to look for expiring consents")
+	consentsCmd.Flags().Bool("reconnect", false, "reconnect each expiring connection in turn")
+	consentsCmd.Flags().String("ics", "", "write expiry reminders for all connections to this .ics file ('-' for stdout)")
+	addAuthFlowFlags(consentsCmd)
+	rootCmd.AddCommand(consentsCmd)
+}
+
+func runConsents(cmd *cobra.Command, args []string) error {
+	within, _ := cmd.Flags().GetInt("within")
+	reconnect, _ := cmd.Flags().GetBool("reconnect")
+	icsPath, _ := cmd.Flags().GetString("ics")
+	if within < 0 {
+		return ExitWithError(ExitUserError, "--within must be 0 or more days")
+	}
+
+	now := time.Now()
+	expiring := expiringConsents(app.Config.Connections, within, now)
+	if len(expiring) == 0 {
+		app.Printer.Info("No consents expire in the next %d days", within)
+	} else {
+		app.Printer.Info("%d consent(s) expire in the next %d days or have expired", len(expiring), within)
+	}
+
+	failed := 0
+	if reconnect && len(expiring) > 0 {
+		flow, err := authFlowFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		for i := range expiring {
+			c := &expiring[i]
+			app.Printer.Info("[%d/%d] Reconnecting %s...", i+1, len(expiring), c.Connection)
+			conn, err := doReconnect(ctx, reconnectOptions{name: c.Connection, maxAccessPerDay: -1, flow: flow})
+			if err != nil {
+				c.Error = err.Error()
+				failed++
+				continue
+			}
+			c.Reconnected = true
+			c.ValidUntil = conn.ValidUntil
+			c.DaysLeft = daysLeft(conn.ValidUntil, time.Now())
+			c.Expired = false
+		}
+	}
+
+	// After reconnecting, so the reminders have the renewed expiry dates.
+	// On stdout the calendar replaces the listing.
+	if icsPath != "" {
+		var buf bytes.Buffer
+		if err := consentCalendar(app.Config, within).WriteTo(&buf, time.Now()); err != nil {
+			return ExitWithError(ExitUserError, "writing calendar: %v", err)
+		}
+		if icsPath == "-" {
+			if _, err := os.Stdout.Write(buf.Bytes()); err != nil {
+				return err
+			}
+		} else {
+			if err := os.WriteFile(icsPath, buf.Bytes(), 0644); err != nil {
+				return ExitWithError(ExitUserError, "writing calendar: %v", err)
+			}
+			app.Printer.Info("Wrote expiry reminders for %d connection(s) to %s", len(app.Config.Connections), icsPath)
+		}
+	}
+	if icsPath != "-" {
+		if err := printResult(expiring, consentsTable); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return ExitWithError(ExitAPIError, "%d of %d reconnects failed", failed, len(expiring))
+	}
+	return nil
+}
+
+func consentsTable(consents []api.ConsentOutput) *output.Table {
+	reconnect := false
+	for _, c := range consents {
+		reconnect = reconnect || c.Reconnected || c.Error != ""
+	}
+	t := &output.Table{Columns: []output.Column{
+		{Header: "CONNECTION"}, {Header: "BANK"}, {Header: "VALID UNTIL"}, {Header: "DAYS LEFT", Align: output.AlignRight},
+	}}
+	if reconnect {
+		t.Columns = append(t.Columns, output.Column{Header: "RECONNECT", Shrink: true})
+	}
+	for _, c := range consents {
+		days := output.Cell{Text: strconv.Itoa(c.DaysLeft)}
+		if c.Expired {
+			days = output.Cell{Text: "expired", Style: output.StyleDebit}
+		}
+		row := []output.Cell{{Text: c.Connection}, {Text: c.Bank + " " + c.Country}, {Text: c.ValidUntil.Format("2006-01-02")}, days}
+		switch {
+		case c.Error != "":
+			row = append(row, output.Cell{Text: c.Error, Style: output.StyleDebit})
+		case c.Reconnected:
+			row = append(row, output.Cell{Text: "done"})
+		case reconnect:
+			row = append(row, output.Cell{})
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// expiringConsents returns the connections whose consent ends within the
+// given number of days, soonest first. Connections without a known expiry
+// are skipped.
+func expiringConsents(conns []config.Connection, within int, now time.Time) []api.ConsentOutput {
+	limit := now.AddDate(0, 0, within)
+	out := []api.ConsentOutput{}
+	for _, conn := range conns {
+		if conn.ValidUntil.IsZero() || conn.ValidUntil.After(limit) {
+			continue
+		}
+		out = append(out, api.ConsentOutput{
+			Connection: conn.Name,
+			Bank:       conn.ASPSPName,
+			Country:    conn.ASPSPCountry,
+			PSUType:    conn.PSU(),
+			ValidUntil: conn.ValidUntil,
+			DaysLeft:   daysLeft(conn.ValidUntil, now),
+			Expired:    !now.Before(conn.ValidUntil),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].ValidUntil.Before(out[j].ValidUntil) })
+	return out
+}
+
+// daysLeft counts the days until t, rounded up, and 0 once t has passed.
+func daysLeft(t, now time.Time) int {
+	days := int(math.Ceil(t.Sub(now).Hours() / 24))
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// consentCalendar builds an all-day event on each connection's expiry date,
+// with reminders remindDays and one day before. Event UIDs depend only on
+// the profile and connection name, so a subscribed calendar moves the event
+// after a reconnect instead of adding another.
+func consentCalendar(cfg *config.Config, remindDays int) *ical.Calendar {
+	reconnect := "ebcli reconnect --name "
+	if cfg.Profile != "" && cfg.Profile != config.DefaultProfile {
+		reconnect = "ebcli --profile " + cfg.Profile + " reconnect --name "
+	}
+
+	var alarms []time.Duration
+	if remindDays > 1 {
+		alarms = append(alarms, time.Duration(remindDays)*24*time.Hour)
+	}
+	alarms = append(alarms, 24*time.Hour)
+
+	cal := &ical.Calendar{Name: "ebcli consents"}
+	for _, conn := range cfg.Connections {
+		if conn.ValidUntil.IsZero() {
+			continue
+		}
+		profile := cfg.Profile
+		if profile == "" {
+			profile = config.DefaultProfile
+		}
+		cal.Events = append(cal.Events, ical.Event{
+			UID:     fmt.Sprintf("consent-%s-%s@ebcli", profile, strings.ToLower(conn.Name)),
+			Date:    conn.ValidUntil.Local(),
+			Summary: fmt.Sprintf("%s bank consent expires (%s)", conn.ASPSPName, conn.Name),
+			Description: fmt.Sprintf("The %s consent for the ebcli connection %s expires on %s.\nRenew it with: %s%s",
+				conn.ASPSPName, conn.Name, conn.ValidUntil.Local().Format("2006-01-02 15:04"), reconnect, shellQuote(conn.Name)),
+			Alarms: alarms,
+		})
+	}
+	return cal
+}
+
+// shellQuote returns s quoted for a POSIX shell if it needs to be.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/@+=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+func TestExpiringConsents(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	conns := []config.Connection{
+		{Name: "later", ValidUntil: now.AddDate(0, 0, 30)},
+		{Name: "soon", ValidUntil: now.AddDate(0, 0, 5)},
+		{Name: "expired", ValidUntil: now.AddDate(0, 0, -2)},
+		{Name: "unknown"},
+	}
+
+	got := expiringConsents(conns, 14, now)
+	if len(got) != 2 {
+		t.Fatalf("got %d consents, want 2: %+v", len(got), got)
+	}
+	if got[0].Connection != "expired" || !got[0].Expired || got[0].DaysLeft != 0 {
+		t.Errorf("got[0] = %+v, want expired first", got[0])
+	}
+	if got[1].Connection != "soon" || got[1].Expired || got[1].DaysLeft != 5 || got[1].PSUType != config.PSUPersonal {
+		t.Errorf("got[1] = %+v, want soon with 5 days left", got[1])
+	}
+}
+
+func TestConsentsTable(t *testing.T) {
+	valid := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	consents := []api.ConsentOutput{
+		{Connection: "ing", Bank: "ING", Country: "NL", ValidUntil: valid, Expired: true},
+		{Connection: "bnp", Bank: "BNP", Country: "FR", ValidUntil: valid, DaysLeft: 5},
+	}
+	table := consentsTable(consents)
+	if len(table.Columns) != 4 || table.Rows[0][3].Text != "expired" || table.Rows[1][3].Text != "5" {
+		t.Errorf("table = %+v", table)
+	}
+
+	consents[0].Reconnected = true
+	consents[1].Error = "creating session: denied"
+	table = consentsTable(consents)
+	if len(table.Columns) != 5 || table.Rows[0][4].Text != "done" || table.Rows[1][4].Text != "creating session: denied" {
+		t.Errorf("table after reconnect = %+v", table)
+	}
+}
+
+func TestConsentCalendar(t *testing.T) {
+	cfg := &config.Config{Profile: "work", Connections: []config.Connection{
+		{Name: "ING", ASPSPName: "ING", ValidUntil: time.Date(2025, 3, 31, 10, 0, 0, 0, time.Local)},
+		{Name: "new"},
+		{Name: "Bob's ING", ASPSPName: "ING", ValidUntil: time.Date(2025, 4, 30, 10, 0, 0, 0, time.Local)},
+	}}
+
+	cal := consentCalendar(cfg, 7)
+	if len(cal.Events) != 2 {
+		t.Fatalf("got %d events, want 2", len(cal.Events))
+	}
+	ev := cal.Events[0]
+	if ev.UID != "consent-work-ing@ebcli" {
+		t.Errorf("UID = %q", ev.UID)
+	}
+	if want := "ebcli --profile work reconnect --name ING"; !strings.Contains(ev.Description, want) {
+		t.Errorf("Description = %q, want it to mention %q", ev.Description, want)
+	}
+	if want := `reconnect --name 'Bob'\''s ING'`; !strings.Contains(cal.Events[1].Description, want) {
+		t.Errorf("Description = %q, want the name quoted: %q", cal.Events[1].Description, want)
+	}
+	if len(ev.Alarms) != 2 || ev.Alarms[0] != 7*24*time.Hour || ev.Alarms[1] != 24*time.Hour {
+		t.Errorf("Alarms = %v, want 7 days and 1 day", ev.Alarms)
+	}
+}
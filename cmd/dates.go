@@ -3,48 +3,67 @@ package cmd
 import (
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/dates"
 )
 
-// parseDate handles: "2024-01-15", "today", "yesterday", "-7d", "-30d"
-func parseDate(input string) (time.Time, error) {
-	input = strings.TrimSpace(strings.ToLower(input))
-	if input == "" {
-		return time.Time{}, fmt.Errorf("empty date")
+// dateHelp lists the date expressions for flag help.
+const dateHelp = "YYYY-MM-DD, YYYY-MM, YYYY-Www, YYYY-Qn, today, -Nd/w/m/y, last-month, ytd, ..."
+
+// calendar returns the date conventions of the active profile.
+func calendar() (dates.Calendar, error) {
+	if app.Config == nil {
+		return dates.Default, nil
 	}
+	return app.Config.Calendar()
+}
 
-	today := truncateToDay(time.Now())
+// calendarToday returns the current day in the profile's timezone.
+func calendarToday() time.Time {
+	cal, _ := calendar()
+	return cal.Today(time.Now())
+}
 
-	switch input {
-	case "today":
-		return today, nil
-	case "yesterday":
-		return today.AddDate(0, 0, -1), nil
-	}
+// parseDate evaluates a date expression (see dates.Calendar.Parse) and
+// returns the first day it covers.
+func parseDate(input string) (time.Time, error) {
+	p, err := parsePeriod(input)
+	return p.From, err
+}
 
-	// Relative: -7d, -30d
-	if strings.HasPrefix(input, "-") && strings.HasSuffix(input, "d") {
-		daysStr := input[1 : len(input)-1]
-		days, err := strconv.Atoi(daysStr)
-		if err != nil {
-			return time.Time{}, fmt.Errorf("invalid relative date %q: %w", input, err)
-		}
-		return today.AddDate(0, 0, -days), nil
+func parsePeriod(input string) (dates.Period, error) {
+	cal, err := calendar()
+	if err != nil {
+		return dates.Period{}, err
 	}
+	return cal.Parse(input, time.Now())
+}
 
-	// Absolute: YYYY-MM-DD
-	t, err := time.Parse("2006-01-02", input)
+// parseDateRange resolves --from/--to/--days/--period into (from, to) dates.
+// --from takes the first day of its expression and --to the last, so
+// "--from 2026-01 --to 2026-03" is the whole first quarter. Periods that are
+// not over yet end today. Default: last 30 days if nothing specified.
+func parseDateRange(from, to, days, period string) (time.Time, time.Time, error) {
+	cal, err := calendar()
 	if err != nil {
-		return time.Time{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD, today, yesterday, or -Nd): %w", input, err)
+		return time.Time{}, time.Time{}, err
 	}
-	return t, nil
-}
+	today := cal.Today(time.Now())
 
-// parseDateRange resolves --from/--to/--days into (from, to) dates.
-// Default: last 30 days if nothing specified.
-func parseDateRange(from, to, days string) (time.Time, time.Time, error) {
-	today := truncateToDay(time.Now())
+	if period != "" {
+		if from != "" || to != "" || days != "" {
+			return time.Time{}, time.Time{}, fmt.Errorf("--period cannot be combined with --from, --to or --days")
+		}
+		p, err := parsePeriod(period)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid --period: %w", err)
+		}
+		from, to = period, period
+		if p.From.After(today) {
+			return time.Time{}, time.Time{}, fmt.Errorf("--period %s starts after today", period)
+		}
+	}
 
 	// --days takes precedence as a shortcut
 	if days != "" {
@@ -56,20 +75,24 @@ func parseDateRange(from, to, days string) (time.Time, time.Time, error) {
 	}
 
 	var fromDate, toDate time.Time
-	var err error
 
 	if from != "" {
-		fromDate, err = parseDate(from)
+		p, err := parsePeriod(from)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("invalid --from: %w", err)
 		}
+		fromDate = p.From
 	}
 
 	if to != "" {
-		toDate, err = parseDate(to)
+		p, err := parsePeriod(to)
 		if err != nil {
 			return time.Time{}, time.Time{}, fmt.Errorf("invalid --to: %w", err)
 		}
+		toDate = p.To
+		if p.To.After(p.From) && toDate.After(today) {
+			toDate = today
+		}
 	}
 
 	// Defaults
@@ -90,7 +113,3 @@ func parseDateRange(from, to, days string) (time.Time, time.Time, error) {
 
 	return fromDate, toDate, nil
 }
-
-func truncateToDay(t time.Time) time.Time {
-	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
-}
@@ -6,7 +6,7 @@ import (
 )
 
 func TestParseDate(t *testing.T) {
-	now := truncateToDay(time.Now())
+	now := calendarToday()
 
 	tests := []struct {
 		input   string
@@ -38,12 +38,12 @@ func TestParseDate(t *testing.T) {
 }
 
 func TestParseDateRange_Defaults(t *testing.T) {
-	from, to, err := parseDateRange("", "", "")
+	from, to, err := parseDateRange("", "", "", "")
 	if err != nil {
 		t.Fatalf("parseDateRange defaults: %v", err)
 	}
 
-	today := truncateToDay(time.Now())
+	today := calendarToday()
 	expectedFrom := today.AddDate(0, 0, -30)
 
 	if !from.Equal(expectedFrom) {
@@ -55,12 +55,12 @@ func TestParseDateRange_Defaults(t *testing.T) {
 }
 
 func TestParseDateRange_DaysFlag(t *testing.T) {
-	from, to, err := parseDateRange("", "", "7")
+	from, to, err := parseDateRange("", "", "7", "")
 	if err != nil {
 		t.Fatalf("parseDateRange --days 7: %v", err)
 	}
 
-	today := truncateToDay(time.Now())
+	today := calendarToday()
 	expectedFrom := today.AddDate(0, 0, -7)
 
 	if !from.Equal(expectedFrom) {
@@ -72,7 +72,7 @@ func TestParseDateRange_DaysFlag(t *testing.T) {
 }
 
 func TestParseDateRange_FromTo(t *testing.T) {
-	from, to, err := parseDateRange("2024-01-01", "2024-01-31", "")
+	from, to, err := parseDateRange("2024-01-01", "2024-01-31", "", "")
 	if err != nil {
 		t.Fatalf("parseDateRange from/to: %v", err)
 	}
@@ -86,8 +86,31 @@ func TestParseDateRange_FromTo(t *testing.T) {
 }
 
 func TestParseDateRange_FromAfterTo(t *testing.T) {
-	_, _, err := parseDateRange("2024-02-01", "2024-01-01", "")
+	_, _, err := parseDateRange("2024-02-01", "2024-01-01", "", "")
 	if err == nil {
 		t.Fatal("expected error when from > to")
 	}
 }
+
+func TestParseDateRange_Period(t *testing.T) {
+	from, to, err := parseDateRange("", "", "", "2024-02")
+	if err != nil {
+		t.Fatalf("parseDateRange --period 2024-02: %v", err)
+	}
+	if from != time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC) || to != time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC) {
+		t.Errorf("period = %v..%v, want February 2024", from, to)
+	}
+
+	// --to takes the end of its period, clamped to today
+	_, to, err = parseDateRange("2024-01", "this-year", "", "")
+	if err != nil {
+		t.Fatalf("parseDateRange --to this-year: %v", err)
+	}
+	if !to.Equal(calendarToday()) {
+		t.Errorf("to = %v, want today", to)
+	}
+
+	if _, _, err := parseDateRange("2024-01-01", "", "", "last-month"); err == nil {
+		t.Error("expected error combining --period with --from")
+	}
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Levels of --debug.
+const (
+	debugOff      = iota
+	debugRequests // method, path, status, latency, retries and headers
+	debugBodies   // and the bodies
+)
+
+// parseDebug parses --debug or EBCLI_DEBUG.
+func parseDebug(s string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "0", "false", "off":
+		return debugOff, nil
+	case "1", "true", "on", "requests":
+		return debugRequests, nil
+	case "2", "bodies":
+		return debugBodies, nil
+	}
+	return debugOff, fmt.Errorf("invalid debug level %q (expected requests, bodies or off)", s)
+}
+
+// setupDebug sets app.Logger from --debug, or else EBCLI_DEBUG. The log is
+// written to stderr as JSON lines.
+func setupDebug(flagSet bool) error {
+	value, source := os.Getenv("EBCLI_DEBUG"), "EBCLI_DEBUG"
+	if flagSet {
+		value, source = flagDebug, "--debug"
+	}
+	level, err := parseDebug(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+	if level == debugOff {
+		app.Logger = nil
+		return nil
+	}
+	app.Logger = slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	app.LogBodies = level == debugBodies
+	return nil
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import "testing"
+
+func TestParseDebug(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", debugOff},
+		{"0", debugOff},
+		{"1", debugRequests},
+		{"requests", debugRequests},
+		{"TRUE", debugRequests},
+		{"bodies", debugBodies},
+	}
+	for _, tt := range tests {
+		if got, err := parseDebug(tt.in); err != nil || got != tt.want {
+			t.Errorf("parseDebug(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := parseDebug("verbose"); err == nil {
+		t.Error("parseDebug(verbose): no error")
+	}
+}
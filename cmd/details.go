@@ -5,6 +5,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/nicolasacchi/ebcli/internal/config"
 	"github.com/nicolasacchi/ebcli/internal/resolver"
 )
 
@@ -20,16 +21,16 @@ var detailsCmd = &cobra.Command{
 			return err
 		}
 
-		accounts = checkDailyLimits(accounts)
+		accounts, limitErr := checkDailyLimits(accounts)
 		if len(accounts) == 0 {
-			return ExitWithError(ExitAPIError, "all accounts skipped due to daily limits")
+			return allDailyLimited(limitErr)
 		}
 
 		var results []interface{}
 		for _, ra := range accounts {
 			details, err := app.Client.GetAccountDetails(ctx, ra.Account.UID, ra.RequiredPSUHeaders)
 			if err != nil {
-				app.Printer.Warn("failed to fetch details for %s: %v", ra.Account.Alias, err)
+				warnAccount(ra, err, "failed to fetch details for %s: %v", ra.Account.Alias, err)
 				continue
 			}
 			results = append(results, struct {
@@ -53,10 +54,13 @@ var detailsCmd = &cobra.Command{
 }
 
 func init() {
-	detailsCmd.Flags().StringP("account", "a", "", "account alias, UID, or IBAN")
+	detailsCmd.Flags().StringP("account", "a", "", "accounts: "+accountSelectorHelp)
 	rootCmd.AddCommand(detailsCmd)
 }
 
+// accountSelectorHelp describes the values --account accepts.
+const accountSelectorHelp = "alias, UID, IBAN, glob, tag:, group:, connection:, currency: or type:; comma-separated, + to combine"
+
 // resolveAccounts resolves the --account flag to a list of accounts.
 // If empty, returns all accounts (--all behavior).
 func resolveAccounts(accountFlag string) ([]resolver.Result, error) {
@@ -68,25 +72,27 @@ func resolveAccounts(accountFlag string) ([]resolver.Result, error) {
 		return results, nil
 	}
 
-	result, err := resolver.Resolve(app.Config, accountFlag)
+	results, err := resolver.Select(app.Config, accountFlag)
 	if err != nil {
 		return nil, ExitWithError(ExitUserError, "%v", err)
 	}
-	return []resolver.Result{*result}, nil
+	return results, nil
 }
 
 // checkDailyLimits filters out accounts whose connection has exceeded its daily
-// access limit. Returns the allowed accounts and warns about skipped ones.
-func checkDailyLimits(accounts []resolver.Result) []resolver.Result {
+// access limit. Returns the allowed accounts and warns about skipped ones; the
+// error is the limit of the last connection skipped, for allDailyLimited.
+func checkDailyLimits(accounts []resolver.Result) ([]resolver.Result, error) {
 	if app.RateLimit == nil {
-		return accounts
+		return accounts, nil
 	}
+	var limitErr error
 
 	checked := make(map[string]bool)   // connection name -> allowed
 	var allowed []resolver.Result
 
 	for _, ra := range accounts {
-		connName := ra.Connection.Name
+		connName := usageKey(ra.Connection.Name)
 		maxPerDay := ra.Connection.MaxAccessPerDay
 
 		if ok, seen := checked[connName]; seen {
@@ -97,7 +103,8 @@ func checkDailyLimits(accounts []resolver.Result) []resolver.Result {
 		}
 
 		if err := app.RateLimit.CheckDaily(connName, maxPerDay); err != nil {
-			app.Printer.Warn("%v", err)
+			warnConnection(ra.Connection.Name, err, "%v", err)
+			limitErr = err
 			checked[connName] = false
 			continue
 		}
@@ -106,7 +113,15 @@ func checkDailyLimits(accounts []resolver.Result) []resolver.Result {
 		allowed = append(allowed, ra)
 	}
 
-	return allowed
+	return allowed, limitErr
+}
+
+// allDailyLimited is the error of a command whose accounts checkDailyLimits
+// all skipped.
+func allDailyLimited(limitErr error) error {
+	p := problemFor(ExitAPIError, limitErr)
+	p.Message = "all accounts skipped due to daily limits"
+	return reportError(ExitAPIError, p)
 }
 
 // recordDailyAccess records one daily access per connection for the given accounts.
@@ -118,7 +133,7 @@ func recordDailyAccess(accounts []resolver.Result) {
 
 	recorded := make(map[string]bool)
 	for _, ra := range accounts {
-		connName := ra.Connection.Name
+		connName := usageKey(ra.Connection.Name)
 		if recorded[connName] {
 			continue
 		}
@@ -128,3 +143,14 @@ func recordDailyAccess(accounts []resolver.Result) {
 		}
 	}
 }
+
+// usageKey returns the daily usage cache key for a connection. Connection
+// names are only unique within a profile, and profile names cannot contain a
+// slash, so the key is "<profile>/<connection>".
+func usageKey(connName string) string {
+	profile := config.DefaultProfile
+	if app.Config != nil && app.Config.Profile != "" {
+		profile = app.Config.Profile
+	}
+	return profile + "/" + connName
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+func TestDailyLimits_PerProfile(t *testing.T) {
+	savedConfig, savedPrinter, savedRateLimit := app.Config, app.Printer, app.RateLimit
+	defer func() { app.Config, app.Printer, app.RateLimit = savedConfig, savedPrinter, savedRateLimit }()
+	app.Printer = output.NewPrinter(&bytes.Buffer{}, &bytes.Buffer{}, output.ModeCompact, false)
+
+	dir := t.TempDir()
+	today := time.Now().Format("2006-01-02")
+	// A version 2 cache, where the default profile used bare connection names
+	cache := `{"version": 2, "daily_usage": {"main": {"date": "` + today + `", "count": 1, "max_per_day": 1}}}`
+	if err := os.WriteFile(filepath.Join(dir, ratelimit.CacheFileName), []byte(cache), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tracker, err := ratelimit.NewTracker(dir, &bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app.RateLimit = tracker
+
+	conn := config.Connection{Name: "main", MaxAccessPerDay: 1}
+	accounts := []resolver.Result{{Account: config.Account{Alias: "a"}, Connection: conn}}
+
+	app.Config = &config.Config{Profile: config.DefaultProfile}
+	if allowed, err := checkDailyLimits(accounts); err == nil || len(allowed) != 0 {
+		t.Fatalf("default profile: allowed %d, err %v; want the migrated limit to apply", len(allowed), err)
+	}
+
+	app.Config = &config.Config{Profile: "work"}
+	allowed, err := checkDailyLimits(accounts)
+	if err != nil || len(allowed) != 1 {
+		t.Fatalf("work profile: allowed %d, err %v; want its own count", len(allowed), err)
+	}
+	recordDailyAccess(allowed)
+	if used, _ := tracker.DailyUsageFor("work/main"); used != 1 {
+		t.Errorf("work/main used = %d, want 1", used)
+	}
+	if used, _ := tracker.DailyUsageFor("default/main"); used != 1 {
+		t.Errorf("default/main used = %d, want 1", used)
+	}
+}
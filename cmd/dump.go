@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/output"
 )
 
 var dumpCmd = &cobra.Command{
@@ -19,61 +20,82 @@ var dumpCmd = &cobra.Command{
 		fromFlag, _ := cmd.Flags().GetString("from")
 		toFlag, _ := cmd.Flags().GetString("to")
 		daysFlag, _ := cmd.Flags().GetString("days")
+		periodFlag, _ := cmd.Flags().GetString("period")
+		windowDays, _ := cmd.Flags().GetInt("window-days")
+		maxTokens, _ := cmd.Flags().GetInt("max-tokens")
+
+		q, err := parseQuery(cmd, annotatedTransaction{}, transactionAliases)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		llm := app.Printer.Format() == output.FormatLLM
+		if maxTokens != 0 && !llm {
+			return ExitWithError(ExitUserError, "--max-tokens requires --format llm")
+		}
+		if maxTokens < 0 {
+			return ExitWithError(ExitUserError, "--max-tokens must be positive")
+		}
+		if llm && q.fields != nil {
+			return ExitWithError(ExitUserError, "--fields cannot be combined with --format llm")
+		}
 
 		accounts, err := resolveAccounts(accountFlag)
 		if err != nil {
 			return err
 		}
 
-		accounts = checkDailyLimits(accounts)
+		accounts, limitErr := checkDailyLimits(accounts)
 		if len(accounts) == 0 {
-			return ExitWithError(ExitAPIError, "all accounts skipped due to daily limits")
+			return allDailyLimited(limitErr)
 		}
 
-		fromDate, toDate, err := parseDateRange(fromFlag, toFlag, daysFlag)
+		fromDate, toDate, err := parseDateRange(fromFlag, toFlag, daysFlag, periodFlag)
 		if err != nil {
 			return ExitWithError(ExitUserError, "%v", err)
 		}
 
-		dateFrom := fromDate.Format("2006-01-02")
-		dateTo := toDate.Format("2006-01-02")
-
-		output := api.DumpOutput{
+		projected := []projectedDumpAccount{}
+		var tableTxns []annotatedTransaction // for --format table
+		dump := api.DumpOutput{
 			FetchedAt: time.Now().Format(time.RFC3339),
 			Accounts:  []api.DumpAccountOutput{},
 		}
 
+		var report fetchReport
 		for _, ra := range accounts {
 			app.Printer.Info("Fetching data for %s...", ra.Account.Alias)
+			var errs []api.AccountError
 
 			// Fetch balances
 			var balances []api.Balance
 			balResp, err := app.Client.GetBalances(ctx, ra.Account.UID, ra.RequiredPSUHeaders)
 			if err != nil {
-				app.Printer.Warn("failed to fetch balances for %s: %v", ra.Account.Alias, err)
+				warnAccount(ra, err, "failed to fetch balances for %s: %v", ra.Account.Alias, err)
+				errs = append(errs, report.accountError(ra, "balances", err))
 			} else {
 				balances = balResp.Balances
 			}
 
-			// Fetch transactions (all pages)
+			// Fetch transactions (all windows and pages)
 			var transactions []api.Transaction
-			continuationKey := ""
-			for {
-				txnResp, err := app.Client.GetTransactions(ctx, ra.Account.UID, api.TransactionParams{
-					DateFrom:          dateFrom,
-					DateTo:            dateTo,
-					ContinuationKey:   continuationKey,
-					TransactionStatus: "BOOK",
-				}, ra.RequiredPSUHeaders)
-				if err != nil {
-					app.Printer.Warn("failed to fetch transactions for %s: %v", ra.Account.Alias, err)
-					break
-				}
-				transactions = append(transactions, txnResp.Transactions...)
-				if txnResp.ContinuationKey == "" {
-					break
-				}
-				continuationKey = txnResp.ContinuationKey
+			pages := 0
+			opts := fetchOptions{status: "BOOK", windowDays: windowDays, pages: &pages}
+			if q.filter != nil {
+				opts.keep = func(txn annotatedTransaction) bool { return q.match(transactionRecord(txn)) }
+			}
+			txns, failed, err := fetchAllTransactions(ctx, ra, fromDate, toDate, opts)
+			if err != nil {
+				warnAccount(ra, err, "failed to fetch transactions for %s: %v", ra.Account.Alias, err)
+				errs = append(errs, report.accountError(ra, "transactions", err))
+			} else {
+				warnFailedWindows(ra, failed)
+			}
+			if len(failed) > 0 {
+				report.fail(ra, failed[0].Err)
+			}
+			q.sortTransactions(txns)
+			for _, txn := range txns {
+				transactions = append(transactions, txn.Transaction)
 			}
 
 			if balances == nil {
@@ -83,24 +105,91 @@ var dumpCmd = &cobra.Command{
 				transactions = []api.Transaction{}
 			}
 
-			output.Accounts = append(output.Accounts, api.DumpAccountOutput{
-				Alias:        ra.Account.Alias,
-				IBAN:         ra.Account.IBAN,
-				Balances:     balances,
-				Transactions: transactions,
-			})
+			acct := api.DumpAccountOutput{
+				ID:            ra.Account.ID,
+				Alias:         ra.Account.Alias,
+				IBAN:          ra.Account.IBAN,
+				Balances:      balances,
+				Transactions:  transactions,
+				FailedWindows: failedWindowsOutput(failed),
+				FetchComplete: len(errs) == 0 && len(failed) == 0,
+				PagesFetched:  pages,
+				Errors:        errs,
+			}
+			dump.Accounts = append(dump.Accounts, acct)
+			tableTxns = append(tableTxns, txns...)
+
+			if q.fields != nil {
+				rows := []interface{}{}
+				for _, txn := range txns {
+					rows = append(rows, q.transactionOutput(txn))
+				}
+				projected = append(projected, projectedDumpAccount{acct, rows})
+			}
 		}
 
 		recordDailyAccess(accounts)
-		return app.Printer.JSON(output)
+		if llm {
+			dump, err := redacted(dump)
+			if err != nil {
+				return err
+			}
+			period := fromDate.Format("2006-01-02") + " to " + toDate.Format("2006-01-02")
+			text, omitted := llmText(dump, period, maxTokens)
+			if omitted > 0 {
+				app.Printer.Warn("left out %d older transactions to fit --max-tokens %d", omitted, maxTokens)
+			}
+			if err := app.Printer.Text(text); err != nil {
+				return err
+			}
+			return report.strictError(cmd)
+		}
+		if err := printDump(dump, tableTxns, projected, q.fields); err != nil {
+			return err
+		}
+		return report.strictError(cmd)
 	},
 }
 
 func init() {
-	dumpCmd.Flags().StringP("account", "a", "", "account alias, UID, or IBAN")
+	dumpCmd.Flags().StringP("account", "a", "", "accounts: "+accountSelectorHelp)
 	dumpCmd.Flags().Bool("all", false, "all accounts (default when --account not specified)")
-	dumpCmd.Flags().String("from", "", "start date")
+	dumpCmd.Flags().String("from", "", "start date ("+dateHelp+")")
 	dumpCmd.Flags().String("to", "", "end date")
 	dumpCmd.Flags().String("days", "", "days back from today")
+	dumpCmd.Flags().String("period", "", "date range, e.g. last-month, 2026-Q1 or 2026-W14 (instead of --from/--to)")
+	dumpCmd.Flags().Int("max-tokens", 0, "with --format llm: leave out the oldest transactions to fit about this many tokens")
+	addStrictFlag(dumpCmd)
+	dumpCmd.Flags().Int("window-days", 0, "longest date range per query; longer ranges are split (default: the connection's window_days, or 90)")
+	addQueryFlags(dumpCmd, transactionAliases, true)
 	rootCmd.AddCommand(dumpCmd)
 }
+
+// projectedDumpAccount is an account of the dump output with its
+// transactions projected onto --fields.
+type projectedDumpAccount struct {
+	api.DumpAccountOutput
+	Transactions []interface{} `json:"transactions"`
+}
+
+// printDump writes the dump as tables, or as JSON with the transactions
+// projected onto fields if set.
+func printDump(dump api.DumpOutput, tableTxns []annotatedTransaction, projected []projectedDumpAccount, fields []string) error {
+	if app.Printer.Format() == output.FormatTable {
+		dump, err := redacted(dump)
+		if err != nil {
+			return err
+		}
+		if tableTxns, err = redacted(tableTxns); err != nil {
+			return err
+		}
+		return app.Printer.Table(dumpTables(dump, tableTxns, fields)...)
+	}
+	if fields != nil {
+		return app.Printer.JSON(struct {
+			FetchedAt string                 `json:"fetched_at"`
+			Accounts  []projectedDumpAccount `json:"accounts"`
+		}{dump.FetchedAt, projected})
+	}
+	return app.Printer.JSON(dump)
+}
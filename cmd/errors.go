@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+// Error kinds, as reported by --error-format json.
+const (
+	kindUser           = "user"            // bad flags or arguments
+	kindAuth           = "auth"            // config, key or credentials
+	kindAPI            = "api"             // the API or the bank failed
+	kindRateLimit      = "rate_limit"      // the API asked to slow down
+	kindSessionExpired = "session_expired" // the consent expired or was revoked
+	kindDailyLimit     = "daily_limit"     // the connection used today's accesses
+)
+
+// problemFor describes err for --error-format json. The kind comes from the
+// error where it is typed, and from the exit code otherwise.
+func problemFor(code int, err error) output.Problem {
+	p := output.Problem{Kind: kindForCode(code)}
+	if err != nil {
+		p.Message = err.Error()
+	}
+
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		p.Status, p.Code = apiErr.StatusCode, apiErr.ErrorCode
+		switch apiErr.StatusCode {
+		case 429:
+			p.Kind = kindRateLimit
+			p.RetryAfter = seconds(apiErr.RetryAfter)
+		case 401, 403:
+			p.Kind = kindAuth
+		}
+	}
+	var expired *api.SessionExpiredError
+	var daily *ratelimit.DailyLimitError
+	switch {
+	case errors.As(err, &expired):
+		p.Kind = kindSessionExpired
+	case errors.As(err, &daily):
+		p.Kind = kindDailyLimit
+		p.RetryAfter = seconds(daily.RetryAfter())
+	}
+	return p
+}
+
+func kindForCode(code int) string {
+	switch code {
+	case ExitAuthError:
+		return kindAuth
+	case ExitAPIError:
+		return kindAPI
+	}
+	return kindUser
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// remedy returns a command that may fix a problem of the kind, for a
+// connection if the problem has one.
+func remedy(p output.Problem) string {
+	switch p.Kind {
+	case kindSessionExpired:
+		if p.Connection != "" {
+			return "ebcli reconnect --name " + shellQuote(p.Connection)
+		}
+		return "ebcli consents"
+	case kindAuth:
+		if p.Status != 0 {
+			return "ebcli status"
+		}
+		return "ebcli config validate"
+	case kindDailyLimit:
+		return "ebcli status"
+	}
+	return ""
+}
+
+// reportError writes p as the error that ends the command and returns the
+// error for its exit code.
+func reportError(code int, p output.Problem) error {
+	p.Level, p.ExitCode = "error", code
+	if p.Remedy == "" {
+		p.Remedy = remedy(p)
+	}
+	printer().Report(p)
+	return &exitErr{code: code, msg: p.Message, reported: true}
+}
+
+// warnAccount reports err, which affected one account of a multi-account
+// command, as a warning with the account and its connection.
+func warnAccount(ra resolver.Result, err error, format string, args ...interface{}) {
+	warn(ra.Account.Alias, ra.Connection.Name, err, fmt.Sprintf(format, args...))
+}
+
+// warnConnection reports err, which affected all accounts of a connection,
+// as a warning with the connection.
+func warnConnection(connection string, err error, format string, args ...interface{}) {
+	warn("", connection, err, fmt.Sprintf(format, args...))
+}
+
+func warn(account, connection string, err error, msg string) {
+	p := problemFor(ExitAPIError, err)
+	p.Level, p.Message = "warning", msg
+	p.Account, p.Connection = account, connection
+	p.Remedy = remedy(p)
+	app.Printer.Report(p)
+}
+
+// ExitCode returns the exit code for an error returned by Execute.
+func ExitCode(err error) int {
+	var e *exitErr
+	if errors.As(err, &e) {
+		return e.code
+	}
+	if err != nil {
+		return ExitUserError
+	}
+	return ExitSuccess
+}
+
+// printer returns app.Printer, or a plain one for errors from before it is
+// set up, such as unknown flags.
+func printer() *output.Printer {
+	if app.Printer == nil {
+		app.Printer = output.NewPrinter(os.Stdout, os.Stderr, output.ModeAuto, flagQuiet)
+		if f, err := output.ParseErrorFormat(flagErrorFormat); err == nil {
+			app.Printer.SetErrorFormat(f)
+		}
+	}
+	return app.Printer
+}
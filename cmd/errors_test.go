@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+)
+
+func TestProblemFor(t *testing.T) {
+	unauthorized := &api.APIError{StatusCode: 401, ErrorCode: "unauthorized"}
+	tests := []struct {
+		name string
+		code int
+		err  error
+		want output.Problem
+	}{
+		{"plain", ExitUserError, errors.New("bad flag"), output.Problem{Kind: kindUser}},
+		{"config", ExitAuthError, nil, output.Problem{Kind: kindAuth}},
+		{"server", ExitAPIError, &api.APIError{StatusCode: 502, ErrorCode: "bad_gateway"}, output.Problem{Kind: kindAPI, Status: 502, Code: "bad_gateway"}},
+		{"rate limit", ExitAPIError, fmt.Errorf("fetching: %w", &api.APIError{StatusCode: 429, RetryAfter: 1500 * time.Millisecond}),
+			output.Problem{Kind: kindRateLimit, Status: 429, RetryAfter: 2}},
+		{"forbidden", ExitAPIError, &api.APIError{StatusCode: 403}, output.Problem{Kind: kindAuth, Status: 403}},
+		{"session", ExitAPIError, &api.SessionExpiredError{SessionID: "s", Wrapped: unauthorized},
+			output.Problem{Kind: kindSessionExpired, Status: 401, Code: "unauthorized"}},
+	}
+	for _, tt := range tests {
+		got := problemFor(tt.code, tt.err)
+		got.Message = ""
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+
+	daily := problemFor(ExitAPIError, &ratelimit.DailyLimitError{Connection: "ing", Used: 4, Max: 4})
+	if daily.Kind != kindDailyLimit || daily.RetryAfter <= 0 || daily.RetryAfter > 25*3600 {
+		t.Errorf("daily limit: %+v", daily)
+	}
+}
+
+func TestRemedy(t *testing.T) {
+	tests := []struct {
+		p    output.Problem
+		want string
+	}{
+		{output.Problem{Kind: kindSessionExpired, Connection: "ing"}, "ebcli reconnect --name ing"},
+		{output.Problem{Kind: kindSessionExpired}, "ebcli consents"},
+		{output.Problem{Kind: kindAuth}, "ebcli config validate"},
+		{output.Problem{Kind: kindAuth, Status: 401}, "ebcli status"},
+		{output.Problem{Kind: kindRateLimit}, ""},
+	}
+	for _, tt := range tests {
+		if got := remedy(tt.p); got != tt.want {
+			t.Errorf("remedy(%+v) = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if got := ExitCode(&exitErr{code: ExitAuthError}); got != ExitAuthError {
+		t.Errorf("ExitCode(exitErr) = %d", got)
+	}
+	if got := ExitCode(errors.New("unknown flag")); got != ExitUserError {
+		t.Errorf("ExitCode(other) = %d", got)
+	}
+	if got := ExitCode(nil); got != ExitSuccess {
+		t.Errorf("ExitCode(nil) = %d", got)
+	}
+}
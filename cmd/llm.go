@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/filter"
+)
+
+// llmDump writes a dump for language models (dump --format llm): markdown
+// with a header per account, a line per transaction and a summary computed
+// from all of them, in a fraction of the tokens of the JSON.
+type llmDump struct {
+	dump   api.DumpOutput
+	period string // e.g. "2026-01-01 to 2026-03-31"
+
+	lines [][]llmLine // per account, in output order
+	keep  [][]bool
+}
+
+// llmLine is a transaction as written in the llm format.
+type llmLine struct {
+	date         string
+	amount       string // signed
+	currency     string
+	counterparty string
+	category     string
+	text         string // the line, without newline
+}
+
+// llmColumns heads the transaction lines.
+const llmColumns = "date|amount|counterparty|memo|category"
+
+// llmTopN is the number of counterparties and categories in the summary.
+const llmTopN = 5
+
+func newLLMDump(dump api.DumpOutput, period string) *llmDump {
+	d := &llmDump{dump: dump, period: period}
+	for _, a := range dump.Accounts {
+		currency := accountCurrency(a)
+		lines := make([]llmLine, len(a.Transactions))
+		keep := make([]bool, len(a.Transactions))
+		for i, txn := range a.Transactions {
+			r := transactionRecord(annotatedTransaction{Account: a.Alias, Transaction: txn})
+			l := llmLine{
+				date:         transactionDate(txn),
+				amount:       filter.Text(valueOf(r, "signed_amount")),
+				currency:     txn.TransactionAmount.Currency,
+				counterparty: filter.Text(valueOf(r, "counterparty")),
+				category:     mccCategory(txn.MerchantCategoryCode),
+			}
+			amount := l.amount
+			if l.currency != "" && l.currency != currency {
+				amount += " " + l.currency
+			}
+			l.text = strings.Join([]string{l.date, amount, cell(l.counterparty), cell(filter.Text(valueOf(r, "remittance"))), l.category}, "|")
+			lines[i], keep[i] = l, true
+		}
+		d.lines = append(d.lines, lines)
+		d.keep = append(d.keep, keep)
+	}
+	return d
+}
+
+// fit leaves out the oldest transactions until the output is estimated to
+// take at most maxTokens, and returns how many it left out. The header and
+// summary are always written.
+func (d *llmDump) fit(maxTokens int) int {
+	type ref struct{ account, index int }
+	var all []ref
+	for a := range d.lines {
+		for i := range d.lines[a] {
+			d.keep[a][i] = false
+			all = append(all, ref{a, i})
+		}
+	}
+	// Newest first; later lines first on the same day
+	sort.SliceStable(all, func(i, j int) bool {
+		di, dj := d.lines[all[i].account][all[i].index].date, d.lines[all[j].account][all[j].index].date
+		if di != dj {
+			return di > dj
+		}
+		return all[i].index > all[j].index
+	})
+
+	budget := maxTokens*charsPerToken - utf8.RuneCountInString(d.String())
+	omitted := len(all)
+	shown := make([]bool, len(d.lines))
+	for _, r := range all {
+		cost := utf8.RuneCountInString(d.lines[r.account][r.index].text) + 1
+		if !shown[r.account] {
+			cost += len(llmColumns) + 1
+		}
+		if cost > budget {
+			break
+		}
+		budget -= cost
+		shown[r.account] = true
+		d.keep[r.account][r.index] = true
+		omitted--
+	}
+	return omitted
+}
+
+// charsPerToken is the rough size of a token for --max-tokens.
+const charsPerToken = 4
+
+func (d *llmDump) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Bank accounts, %s\n", d.period)
+	fmt.Fprintf(&b, "Fetched %s. Amounts are signed: negative is money out.\n", d.dump.FetchedAt)
+
+	for ai, a := range d.dump.Accounts {
+		currency := accountCurrency(a)
+		b.WriteString("\n## " + a.Alias)
+		var meta []string
+		if a.IBAN != "" {
+			meta = append(meta, a.IBAN)
+		}
+		if currency != "" {
+			meta = append(meta, currency)
+		}
+		if len(meta) > 0 {
+			b.WriteString(" (" + strings.Join(meta, ", ") + ")")
+		}
+		b.WriteString("\n")
+
+		if len(a.Balances) > 0 {
+			var balances []string
+			for _, bal := range a.Balances {
+				s := bal.BalanceType + " " + bal.BalanceAmount.Amount + " " + bal.BalanceAmount.Currency
+				if bal.ReferenceDate != "" {
+					s += " on " + bal.ReferenceDate
+				}
+				balances = append(balances, s)
+			}
+			b.WriteString("Balances: " + strings.Join(balances, "; ") + "\n")
+		}
+		for _, w := range a.FailedWindows {
+			fmt.Fprintf(&b, "Missing transactions: %s to %s (%s)\n", w.From, w.To, w.Error)
+		}
+		for _, e := range a.Errors {
+			fmt.Fprintf(&b, "Could not fetch %s: %s\n", e.Fetch, e.Message)
+		}
+
+		omitted := 0
+		for _, keep := range d.keep[ai] {
+			if !keep {
+				omitted++
+			}
+		}
+		if len(a.Transactions) == 0 {
+			b.WriteString("No transactions.\n")
+			continue
+		}
+		fmt.Fprintf(&b, "Transactions (%d):\n", len(a.Transactions))
+		if omitted < len(a.Transactions) {
+			b.WriteString(llmColumns + "\n")
+		}
+		for i, l := range d.lines[ai] {
+			if d.keep[ai][i] {
+				b.WriteString(l.text + "\n")
+			}
+		}
+		if omitted > 0 {
+			fmt.Fprintf(&b, "(%d older transactions left out to fit --max-tokens)\n", omitted)
+		}
+	}
+
+	b.WriteString("\n## Summary\n")
+	d.writeSummary(&b)
+	return b.String()
+}
+
+// writeSummary writes totals per account and the largest outgoing amounts by
+// counterparty and category, from all transactions.
+func (d *llmDump) writeSummary(b *strings.Builder) {
+	byCounterparty := totals{}
+	byCategory := totals{}
+	for ai, a := range d.dump.Accounts {
+		in, out := totals{}, totals{}
+		for _, l := range d.lines[ai] {
+			r, ok := new(big.Rat).SetString(l.amount)
+			if !ok {
+				continue
+			}
+			if r.Sign() >= 0 {
+				in.add(l.currency, r)
+				continue
+			}
+			out.add(l.currency, r)
+			if l.counterparty != "" {
+				byCounterparty.add(l.counterparty+"\x00"+l.currency, r)
+			}
+			if l.category != "" {
+				byCategory.add(l.category+"\x00"+l.currency, r)
+			}
+		}
+		fmt.Fprintf(b, "- %s: %d transactions", a.Alias, len(a.Transactions))
+		currencies := map[string]bool{}
+		for c := range in {
+			currencies[c] = true
+		}
+		for c := range out {
+			currencies[c] = true
+		}
+		for _, c := range sortedSet(currencies) {
+			net := new(big.Rat).Add(in.get(c), out.get(c))
+			fmt.Fprintf(b, "; %s in %s, out %s, net %s", c, ratString(in.get(c)), ratString(out.get(c)), ratString(net))
+		}
+		b.WriteString("\n")
+	}
+	if s := byCounterparty.top(llmTopN); s != "" {
+		b.WriteString("- Top counterparties by money out: " + s + "\n")
+	}
+	if s := byCategory.top(llmTopN); s != "" {
+		b.WriteString("- Money out by category: " + s + "\n")
+	}
+}
+
+// totals are exact sums by key.
+type totals map[string]*big.Rat
+
+func (t totals) add(key string, r *big.Rat) {
+	if t[key] == nil {
+		t[key] = new(big.Rat)
+	}
+	t[key].Add(t[key], r)
+}
+
+func (t totals) get(key string) *big.Rat {
+	if r := t[key]; r != nil {
+		return r
+	}
+	return new(big.Rat)
+}
+
+// top lists the n largest totals, keyed name and currency separated by NUL, as
+// "name -12.00 EUR".
+func (t totals) top(n int) string {
+	keys := make([]string, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if c := new(big.Rat).Abs(t[keys[i]]).Cmp(new(big.Rat).Abs(t[keys[j]])); c != 0 {
+			return c > 0
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		name, currency, _ := strings.Cut(k, "\x00")
+		parts[i] = strings.TrimSpace(name + " " + ratString(t[k]) + " " + currency)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func ratString(r *big.Rat) string {
+	return r.FloatString(2)
+}
+
+func sortedSet(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// accountCurrency is the currency of an account's balances, or else of its
+// first transaction.
+func accountCurrency(a api.DumpAccountOutput) string {
+	for _, b := range a.Balances {
+		if b.BalanceAmount.Currency != "" {
+			return b.BalanceAmount.Currency
+		}
+	}
+	for _, txn := range a.Transactions {
+		if txn.TransactionAmount.Currency != "" {
+			return txn.TransactionAmount.Currency
+		}
+	}
+	return ""
+}
+
+// cell makes s fit in a |-separated line.
+func cell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", "/")
+}
+
+// mccCategories are broad spending categories by ranges of ISO 18245
+// merchant category codes.
+var mccCategories = []struct {
+	from, to int
+	category string
+}{
+	{3000, 3299, "travel"}, // airlines
+	{3351, 3441, "transport"},
+	{3501, 3999, "travel"}, // hotels
+	{4011, 4789, "transport"},
+	{4812, 4816, "telecom"},
+	{4899, 4899, "subscriptions"},
+	{4900, 4900, "utilities"},
+	{5200, 5261, "home"},
+	{5300, 5399, "shopping"},
+	{5411, 5499, "groceries"},
+	{5511, 5599, "car"},
+	{5541, 5542, "fuel"},
+	{5600, 5699, "clothing"},
+	{5700, 5735, "home"},
+	{5811, 5814, "restaurants"},
+	{5912, 5912, "health"},
+	{5900, 5999, "shopping"},
+	{6010, 6011, "cash"},
+	{6300, 6399, "insurance"},
+	{7011, 7011, "travel"},
+	{7200, 7299, "personal care"},
+	{7800, 7999, "entertainment"},
+	{8000, 8099, "health"},
+	{8200, 8299, "education"},
+	{9211, 9399, "government"},
+}
+
+// mccCategory returns the category of a merchant category code, "other"
+// for codes not in mccCategories, and "" without a code.
+func mccCategory(mcc string) string {
+	code, err := strconv.Atoi(strings.TrimSpace(mcc))
+	if err != nil {
+		return ""
+	}
+	// The narrowest range wins, e.g. fuel within car
+	best, width := "other", -1
+	for _, c := range mccCategories {
+		if code >= c.from && code <= c.to && (width < 0 || c.to-c.from < width) {
+			best, width = c.category, c.to-c.from
+		}
+	}
+	return best
+}
+
+// llmText returns the dump in the llm format, within maxTokens if positive,
+// and how many transactions were left out.
+func llmText(dump api.DumpOutput, period string, maxTokens int) (string, int) {
+	d := newLLMDump(dump, period)
+	omitted := 0
+	if maxTokens > 0 {
+		omitted = d.fit(maxTokens)
+	}
+	return d.String(), omitted
+}
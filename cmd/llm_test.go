@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+)
+
+func llmTestDump() api.DumpOutput {
+	txn := func(date, amount, cdi, creditor, debtor, memo, mcc string) api.Transaction {
+		return api.Transaction{
+			BookingDate: date, TransactionAmount: api.Amount{Currency: "EUR", Amount: amount},
+			CreditDebitIndicator: cdi, CreditorName: creditor, DebtorName: debtor,
+			RemittanceInformation: []string{memo}, MerchantCategoryCode: mcc,
+		}
+	}
+	return api.DumpOutput{
+		FetchedAt: "2026-04-01T08:00:00Z",
+		Accounts: []api.DumpAccountOutput{{
+			Alias: "main", IBAN: "FI2112345600000785",
+			Balances: []api.Balance{{BalanceType: "CLBD", BalanceAmount: api.Amount{Currency: "EUR", Amount: "1200.00"}, ReferenceDate: "2026-03-31"}},
+			Transactions: []api.Transaction{
+				txn("2026-03-01", "2500.00", "CRDT", "Me", "Employer", "Salary | March", ""),
+				txn("2026-03-02", "42.10", "DBIT", "K-Market", "", "Groceries", "5411"),
+				txn("2026-03-05", "12.00", "DBIT", "Cafe", "", "Lunch", "5812"),
+				txn("2026-03-09", "30.40", "DBIT", "K-Market", "", "Groceries", "5411"),
+			},
+		}, {
+			Alias: "empty", Balances: []api.Balance{}, Transactions: []api.Transaction{},
+		}},
+	}
+}
+
+func TestLLMText(t *testing.T) {
+	text, omitted := llmText(llmTestDump(), "2026-03-01 to 2026-03-31", 0)
+	if omitted != 0 {
+		t.Errorf("omitted = %d", omitted)
+	}
+	for _, want := range []string{
+		"# Bank accounts, 2026-03-01 to 2026-03-31\n",
+		"## main (FI2112345600000785, EUR)\nBalances: CLBD 1200.00 EUR on 2026-03-31\n",
+		"date|amount|counterparty|memo|category\n",
+		"2026-03-01|2500.00|Employer|Salary / March|\n",
+		"2026-03-02|-42.10|K-Market|Groceries|groceries\n",
+		"2026-03-05|-12.00|Cafe|Lunch|restaurants\n",
+		"## empty\nNo transactions.\n",
+		"- main: 4 transactions; EUR in 2500.00, out -84.50, net 2415.50\n",
+		"- Top counterparties by money out: K-Market -72.50 EUR; Cafe -12.00 EUR\n",
+		"- Money out by category: groceries -72.50 EUR; restaurants -12.00 EUR\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestLLMText_MaxTokens(t *testing.T) {
+	full, _ := llmText(llmTestDump(), "March", 0)
+	budget := (utf8.RuneCountInString(full) - 40) / charsPerToken
+
+	text, omitted := llmText(llmTestDump(), "March", budget)
+	if omitted == 0 || omitted == 4 {
+		t.Fatalf("omitted = %d, want some", omitted)
+	}
+	if n := utf8.RuneCountInString(text); n > budget*charsPerToken {
+		t.Errorf("%d characters, budget %d tokens", n, budget)
+	}
+	// The oldest go first; the summary still covers them
+	if strings.Contains(text, "2026-03-01|") || !strings.Contains(text, "2026-03-09|") {
+		t.Errorf("kept the wrong transactions:\n%s", text)
+	}
+	if !strings.Contains(text, "older transactions left out") || !strings.Contains(text, "EUR in 2500.00") {
+		t.Errorf("missing note or summary:\n%s", text)
+	}
+}
+
+func TestMCCCategory(t *testing.T) {
+	for mcc, want := range map[string]string{"5411": "groceries", "5541": "fuel", "5533": "car", "5912": "health", "0742": "other", "": ""} {
+		if got := mccCategory(mcc); got != want {
+			t.Errorf("mccCategory(%q) = %q, want %q", mcc, got, want)
+		}
+	}
+}
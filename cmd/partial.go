@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+// fetchReport collects the accounts whose data could not be fetched
+// completely, for the status fields of the output and --strict.
+type fetchReport struct {
+	incomplete []string // account aliases
+	cause      error    // the first error, for the kind of the --strict error
+}
+
+// accountError records that part of ra's data could not be fetched and
+// returns the error for the output.
+func (r *fetchReport) accountError(ra resolver.Result, fetch string, err error) api.AccountError {
+	r.fail(ra, err)
+	return api.AccountError{Fetch: fetch, Kind: problemFor(ExitAPIError, err).Kind, Message: err.Error()}
+}
+
+// fail records that ra's data is incomplete.
+func (r *fetchReport) fail(ra resolver.Result, err error) {
+	if r.cause == nil {
+		r.cause = err
+	}
+	for _, alias := range r.incomplete {
+		if alias == ra.Account.Alias {
+			return
+		}
+	}
+	r.incomplete = append(r.incomplete, ra.Account.Alias)
+}
+
+// strictError returns the error that ends a command run with --strict if an
+// account is incomplete, or nil. It is returned after the output is written.
+func (r *fetchReport) strictError(cmd *cobra.Command) error {
+	if strict, _ := cmd.Flags().GetBool("strict"); !strict || len(r.incomplete) == 0 {
+		return nil
+	}
+	p := problemFor(ExitAPIError, r.cause)
+	p.Message = fmt.Sprintf("incomplete data for %s (--strict)", strings.Join(r.incomplete, ", "))
+	return reportError(ExitAPIError, p)
+}
+
+// addStrictFlag adds --strict to a command that fetches several accounts.
+func addStrictFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("strict", false, "exit with code 2 if any account could not be fetched completely (the output is still written)")
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+func TestFetchReport(t *testing.T) {
+	main := resolver.Result{Account: config.Account{Alias: "main"}}
+	save := resolver.Result{Account: config.Account{Alias: "save"}}
+	expired := &api.SessionExpiredError{SessionID: "s", Wrapped: &api.APIError{StatusCode: 401}}
+
+	var r fetchReport
+	got := r.accountError(main, "balances", expired)
+	want := api.AccountError{Fetch: "balances", Kind: kindSessionExpired, Message: expired.Error()}
+	if got != want {
+		t.Errorf("accountError = %+v, want %+v", got, want)
+	}
+	r.accountError(main, "transactions", errors.New("timeout"))
+	r.fail(save, errors.New("too old"))
+	if strings.Join(r.incomplete, ",") != "main,save" || r.cause != error(expired) {
+		t.Errorf("report = %+v", r)
+	}
+
+	var stderr bytes.Buffer
+	saved := app.Printer
+	app.Printer = output.NewPrinter(&bytes.Buffer{}, &stderr, output.ModeCompact, false)
+	defer func() { app.Printer = saved }()
+
+	cmd := &cobra.Command{}
+	addStrictFlag(cmd)
+	if err := r.strictError(cmd); err != nil {
+		t.Errorf("without --strict: %v", err)
+	}
+	cmd.Flags().Set("strict", "true")
+	if err := (&fetchReport{}).strictError(cmd); err != nil {
+		t.Errorf("complete: %v", err)
+	}
+	err := r.strictError(cmd)
+	if ExitCode(err) != ExitAPIError || !strings.Contains(stderr.String(), "incomplete data for main, save") {
+		t.Errorf("strictError = %v, stderr %q", err, stderr.String())
+	}
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage config profiles (one per Enable Banking application)",
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		file, err := config.LoadFile(cfgPath)
+		if err != nil {
+			return ExitWithError(ExitAuthError, "loading config: %v", err)
+		}
+
+		type profileOutput struct {
+			Name        string `json:"name"`
+			Current     bool   `json:"current"`
+			AppID       string `json:"app_id"`
+			Environment string `json:"environment"`
+			Connections int    `json:"connections"`
+		}
+
+		active := file.ProfileName(flagProfile)
+		output := []profileOutput{}
+		for _, name := range file.ProfileNames() {
+			p := file.Profiles[name]
+			output = append(output, profileOutput{
+				Name:        name,
+				Current:     name == active,
+				AppID:       p.AppID,
+				Environment: p.Environment,
+				Connections: len(p.Connections),
+			})
+		}
+		return app.Printer.JSON(output)
+	},
+}
+
+var profileAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		appID, _ := cmd.Flags().GetString("app-id")
+		keyPath, _ := cmd.Flags().GetString("private-key")
+		env, _ := cmd.Flags().GetString("environment")
+		callbackURL, _ := cmd.Flags().GetString("callback-url")
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		use, _ := cmd.Flags().GetBool("use")
+
+		env = strings.ToUpper(env)
+		if env != "PRODUCTION" && env != "SANDBOX" {
+			return ExitWithError(ExitUserError, "invalid --environment %q (expected PRODUCTION or SANDBOX)", env)
+		}
+
+		cfgPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		cfg := &config.Config{
+			AppID:          appID,
+			PrivateKeyPath: keyPath,
+			Environment:    env,
+			CallbackURL:    callbackURL,
+			BaseURL:        baseURL,
+		}
+		err = config.Update(cfgPath, func(f *config.File) error {
+			if err := f.AddProfile(name, cfg); err != nil {
+				return err
+			}
+			if use {
+				return f.UseProfile(name)
+			}
+			return nil
+		})
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		app.Printer.Info("Added profile %s", name)
+		if appID == "" || keyPath == "" {
+			app.Printer.Info("Finish setup with: ebcli config --init --profile %s", name)
+		}
+		return app.Printer.JSON(cfg)
+	},
+}
+
+var profileUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Set the current profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+		err = config.Update(cfgPath, func(f *config.File) error {
+			return f.UseProfile(args[0])
+		})
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		app.Printer.Info("Now using profile %s", args[0])
+		return app.Printer.JSON(struct {
+			CurrentProfile string `json:"current_profile"`
+		}{args[0]})
+	},
+}
+
+var profileRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a profile",
+	Long:  "Remove a profile from the config. Its bank sessions are not revoked;\nrun 'ebcli disconnect --profile <name>' first to revoke them.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, err := configFilePath()
+		if err != nil {
+			return err
+		}
+
+		var connections int
+		err = config.Update(cfgPath, func(f *config.File) error {
+			if p, ok := f.Profiles[args[0]]; ok {
+				connections = len(p.Connections)
+			}
+			return f.RemoveProfile(args[0])
+		})
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		if connections > 0 {
+			app.Printer.Warn("%d connection(s) removed without revoking consent", connections)
+		}
+		app.Printer.Info("Removed profile %s", args[0])
+		return app.Printer.JSON(struct {
+			Removed            string `json:"removed"`
+			ConnectionsRemoved int    `json:"connections_removed"`
+		}{args[0], connections})
+	},
+}
+
+func init() {
+	profileAddCmd.Flags().String("app-id", "", "Enable Banking application ID")
+	profileAddCmd.Flags().String("private-key", "", "path to the application's private key")
+	profileAddCmd.Flags().String("environment", "PRODUCTION", "PRODUCTION or SANDBOX")
+	profileAddCmd.Flags().String("callback-url", "", "public callback URL (default: localhost)")
+	profileAddCmd.Flags().String("base-url", "", "API base URL (default: "+api.BaseURL+")")
+	profileAddCmd.Flags().Bool("use", false, "make the new profile current")
+
+	profileCmd.AddCommand(profileListCmd, profileAddCmd, profileUseCmd, profileRemoveCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+// configFilePath resolves the config file path from --config / EBCLI_CONFIG.
+func configFilePath() (string, error) {
+	_, cfgPath, err := config.Paths(flagConfig)
+	if err != nil {
+		return "", ExitWithError(ExitAuthError, "resolving config path: %v", err)
+	}
+	return cfgPath, nil
+}
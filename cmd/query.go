@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/filter"
+)
+
+// transactionAliases are the shorthand fields of transactions, on top of
+// their JSON fields.
+var transactionAliases = []string{"amount", "signed_amount", "currency", "date", "creditor", "debtor", "counterparty", "remittance"}
+
+// balanceAliases are the fields of balances besides their JSON fields: the
+// account they belong to and shorthands.
+var balanceAliases = []string{"account", "iban", "amount", "currency", "date", "type"}
+
+// query holds the --filter, --fields and --sort options of a command.
+type query struct {
+	filter *filter.Expr
+	fields []string
+	sort   []filter.SortKey
+}
+
+// addQueryFlags registers --filter and --fields, and --sort if withSort.
+// aliases are listed in the help.
+func addQueryFlags(cmd *cobra.Command, aliases []string, withSort bool) {
+	cmd.Flags().String("filter", "", `only include records matching an expression, e.g. 'amount > 100 && creditor =~ "amazon"'`)
+	cmd.Flags().String("fields", "", "comma-separated fields to output (JSON fields or "+strings.Join(aliases, ", ")+")")
+	if withSort {
+		cmd.Flags().String("sort", "", "sort by comma-separated fields, - for descending, e.g. -amount or date,counterparty")
+	}
+}
+
+// parseQuery reads the query flags of cmd, checking that the fields they
+// name exist in sample (a record as output) or aliases.
+func parseQuery(cmd *cobra.Command, sample interface{}, aliases []string) (query, error) {
+	var q query
+	known := filter.Paths(sample)
+	for _, a := range aliases {
+		known[a] = true
+	}
+	check := func(flag string, fields []string) error {
+		for _, f := range fields {
+			if !known[f] {
+				return fmt.Errorf("unknown field %q in --%s (use a JSON field of the output, or one of: %s)", f, flag, strings.Join(aliases, ", "))
+			}
+		}
+		return nil
+	}
+
+	if expr, _ := cmd.Flags().GetString("filter"); expr != "" {
+		f, err := filter.Compile(expr)
+		if err != nil {
+			return q, fmt.Errorf("invalid --filter: %v", err)
+		}
+		if err := check("filter", f.Fields()); err != nil {
+			return q, err
+		}
+		q.filter = f
+	}
+
+	if list, _ := cmd.Flags().GetString("fields"); list != "" {
+		q.fields = filter.ParseFields(list)
+		if err := check("fields", q.fields); err != nil {
+			return q, err
+		}
+	}
+
+	if cmd.Flags().Lookup("sort") != nil {
+		if spec, _ := cmd.Flags().GetString("sort"); spec != "" {
+			keys, err := filter.ParseSort(spec)
+			if err != nil {
+				return q, fmt.Errorf("invalid --sort: %v", err)
+			}
+			var fields []string
+			for _, k := range keys {
+				fields = append(fields, k.Field)
+			}
+			if err := check("sort", fields); err != nil {
+				return q, err
+			}
+			q.sort = keys
+		}
+	}
+	return q, nil
+}
+
+// match reports whether r passes the filter, if any.
+func (q query) match(r filter.Record) bool {
+	return q.filter == nil || q.filter.Match(r)
+}
+
+// sortTransactions sorts txns by the --sort keys, keeping the bank's order
+// for ties.
+func (q query) sortTransactions(txns []annotatedTransaction) {
+	if q.sort == nil {
+		return
+	}
+	records := make([]filter.Record, len(txns))
+	for i, txn := range txns {
+		records[i] = transactionRecord(txn)
+	}
+	idx := make([]int, len(txns))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return filter.Compare(records[idx[i]], records[idx[j]], q.sort) < 0
+	})
+	sorted := make([]annotatedTransaction, len(txns))
+	for i, k := range idx {
+		sorted[i] = txns[k]
+	}
+	copy(txns, sorted)
+}
+
+// transactionOutput returns txn as output: as it is, or projected onto the
+// --fields.
+func (q query) transactionOutput(txn annotatedTransaction) interface{} {
+	if q.fields == nil {
+		return txn
+	}
+	return filter.Project(transactionRecord(txn), q.fields)
+}
+
+// transactionRecord returns txn for filtering, sorting and projection: its
+// JSON fields plus the transactionAliases.
+func transactionRecord(txn annotatedTransaction) filter.Record {
+	obj, _ := filter.NewObject(txn)
+	amount := txn.TransactionAmount.Amount
+	signed := amount
+	if txn.CreditDebitIndicator == "DBIT" && signed != "" && !strings.HasPrefix(signed, "-") {
+		signed = "-" + signed
+	}
+	counterparty := txn.CreditorName
+	if txn.CreditDebitIndicator == "CRDT" || counterparty == "" {
+		counterparty = txn.DebtorName
+	}
+	if counterparty == "" {
+		counterparty = txn.CreditorName
+	}
+	return filter.Overlay{
+		Fields: map[string]interface{}{
+			"amount":        nonEmpty(amount),
+			"signed_amount": nonEmpty(signed),
+			"currency":      nonEmpty(txn.TransactionAmount.Currency),
+			"date":          nonEmpty(transactionDate(txn.Transaction)),
+			"creditor":      nonEmpty(txn.CreditorName),
+			"debtor":        nonEmpty(txn.DebtorName),
+			"counterparty":  nonEmpty(counterparty),
+			"remittance":    nonEmpty(strings.Join(txn.RemittanceInformation, " ")),
+		},
+		Record: obj,
+	}
+}
+
+// balanceRecord returns a balance of an account for filtering and
+// projection: its JSON fields plus the balanceAliases.
+func balanceRecord(account, iban string, b api.Balance) filter.Record {
+	obj, _ := filter.NewObject(b)
+	return filter.Overlay{
+		Fields: map[string]interface{}{
+			"account":  account,
+			"iban":     nonEmpty(iban),
+			"amount":   nonEmpty(b.BalanceAmount.Amount),
+			"currency": nonEmpty(b.BalanceAmount.Currency),
+			"date":     nonEmpty(b.ReferenceDate),
+			"type":     nonEmpty(b.BalanceType),
+		},
+		Record: obj,
+	}
+}
+
+// nonEmpty returns s, or nil (a field that is not set) if s is empty.
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/filter"
+)
+
+func TestTransactionRecord(t *testing.T) {
+	txns := []annotatedTransaction{
+		{Account: "main", Transaction: api.Transaction{
+			BookingDate: "2026-03-02", TransactionAmount: api.Amount{Currency: "EUR", Amount: "42.00"},
+			CreditDebitIndicator: "DBIT", CreditorName: "Amazon", RemittanceInformation: []string{"Order", "123"},
+		}},
+		{Account: "main", Transaction: api.Transaction{
+			ValueDate: "2026-03-01", TransactionAmount: api.Amount{Currency: "EUR", Amount: "1500"},
+			CreditDebitIndicator: "CRDT", CreditorName: "Me", DebtorName: "Employer",
+		}},
+	}
+
+	var rows []string
+	for _, txn := range txns {
+		data, _ := json.Marshal(filter.Project(transactionRecord(txn), []string{"date", "signed_amount", "counterparty", "remittance"}))
+		rows = append(rows, string(data))
+	}
+	want := []string{
+		`{"date":"2026-03-02","signed_amount":"-42.00","counterparty":"Amazon","remittance":"Order 123"}`,
+		`{"date":"2026-03-01","signed_amount":"1500","counterparty":"Employer","remittance":null}`,
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("row %d = %s, want %s", i, rows[i], want[i])
+		}
+	}
+
+	q := query{sort: []filter.SortKey{{Field: "amount", Descending: true}}}
+	q.sortTransactions(txns)
+	if txns[0].DebtorName != "Employer" {
+		t.Errorf("sorted by -amount, first = %+v", txns[0])
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	parse := func(args ...string) (query, error) {
+		cmd := &cobra.Command{}
+		addQueryFlags(cmd, transactionAliases, true)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatal(err)
+		}
+		return parseQuery(cmd, annotatedTransaction{}, transactionAliases)
+	}
+
+	q, err := parse("--filter", `amount > 10 && transaction_amount.currency == "EUR"`, "--fields", "Account, date,iban", "--sort", "-amount,date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.filter == nil || strings.Join(q.fields, ",") != "account,date,iban" || len(q.sort) != 2 || !q.sort[0].Descending {
+		t.Errorf("parseQuery = %+v", q)
+	}
+
+	for _, args := range [][]string{
+		{"--filter", "amout > 10"},
+		{"--fields", "date,creditor.name"},
+		{"--sort", "balance"},
+	} {
+		if _, err := parse(args...); err == nil || !strings.Contains(err.Error(), "unknown field") {
+			t.Errorf("parse(%q) = %v, want unknown field", args, err)
+		}
+	}
+}
@@ -2,8 +2,6 @@ package cmd
 
 import (
 	"context"
-	"fmt"
-	"os"
 	"strings"
 	"time"
 
@@ -11,7 +9,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
-	"github.com/nicolasacchi/ebcli/internal/auth"
 	"github.com/nicolasacchi/ebcli/internal/config"
 )
 
@@ -25,36 +22,74 @@ func init() {
 	reconnectCmd.Flags().StringP("name", "n", "", "connection name (required)")
 	reconnectCmd.MarkFlagRequired("name")
 	reconnectCmd.Flags().Int("valid-days", 0, "consent validity in days")
-	reconnectCmd.Flags().Int("port", auth.DefaultCallbackPort, "local callback server port")
-	reconnectCmd.Flags().Int("max-access-per-day", 0, "daily data refresh limit (0=unlimited, -1=keep current)")
+	reconnectCmd.Flags().Int("max-access-per-day", -1, "daily data refresh limit (0=unlimited, -1=keep current)")
+	reconnectCmd.Flags().String("auth-method", "", "specific auth method name (default: the one used last time)")
+	reconnectCmd.Flags().String("psu-type", "", "account holder type: personal or business (default: the connection's)")
+	reconnectCmd.Flags().Bool("dry-run", false, "print the authorization request instead of sending it")
+	addAuthFlowFlags(reconnectCmd)
 	rootCmd.AddCommand(reconnectCmd)
 }
 
+// reconnectOptions are the settings for renewing a connection. Empty values
+// keep what the connection had.
+type reconnectOptions struct {
+	name            string
+	validDays       int    // 0 = bank's maximum
+	maxAccessPerDay int    // -1 = keep current
+	authMethod      string // empty reuses the connection's, if the bank still offers it
+	psuType         string
+	dryRun          bool
+	flow            authFlow
+}
+
 func runReconnect(cmd *cobra.Command, args []string) error {
 	ctx := context.Background()
-	name, _ := cmd.Flags().GetString("name")
-	validDays, _ := cmd.Flags().GetInt("valid-days")
-	port, _ := cmd.Flags().GetInt("port")
-	maxAccessPerDay, _ := cmd.Flags().GetInt("max-access-per-day")
+	var opts reconnectOptions
+	opts.name, _ = cmd.Flags().GetString("name")
+	opts.validDays, _ = cmd.Flags().GetInt("valid-days")
+	opts.maxAccessPerDay, _ = cmd.Flags().GetInt("max-access-per-day")
+	opts.authMethod, _ = cmd.Flags().GetString("auth-method")
+	opts.dryRun, _ = cmd.Flags().GetBool("dry-run")
+
+	var err error
+	if cmd.Flags().Changed("psu-type") {
+		psuType, _ := cmd.Flags().GetString("psu-type")
+		if opts.psuType, err = parsePSUType(psuType); err != nil {
+			return err
+		}
+	}
+	if opts.flow, err = authFlowFromFlags(cmd); err != nil {
+		return err
+	}
+
+	conn, err := doReconnect(ctx, opts)
+	if err != nil || conn == nil {
+		return err
+	}
+	return app.Printer.JSON(conn)
+}
+
+// doReconnect renews a connection's consent, keeping account aliases by
+// matching identification hashes. It returns nil for a dry run, after
+// printing the authorization request.
+func doReconnect(ctx context.Context, opts reconnectOptions) (*config.Connection, error) {
+	name, validDays := opts.name, opts.validDays
 
 	oldConn, err := app.Config.FindConnection(name)
 	if err != nil {
-		return ExitWithError(ExitUserError, "%v", err)
+		return nil, ExitWithError(ExitUserError, "%v", err)
 	}
 
-	// Save old accounts for identification_hash matching
-	oldAccountsByHash := make(map[string]config.Account)
-	for _, acct := range oldConn.Accounts {
-		if acct.IdentificationHash != "" {
-			oldAccountsByHash[acct.IdentificationHash] = acct
-		}
+	psuType := opts.psuType
+	if psuType == "" {
+		psuType = oldConn.PSU()
 	}
 
 	// Fetch ASPSP info
 	app.Printer.Info("Fetching bank information for %s...", oldConn.ASPSPName)
-	aspsps, err := app.Client.ListASPSPs(ctx, oldConn.ASPSPCountry, "personal")
+	aspsps, err := app.Client.ListASPSPs(ctx, oldConn.ASPSPCountry, psuType)
 	if err != nil {
-		return ExitWithError(ExitAPIError, "fetching banks: %v", err)
+		return nil, ExitWithError(ExitAPIError, "fetching banks: %v", err)
 	}
 
 	var aspsp *api.ASPSPData
@@ -65,18 +100,12 @@ func runReconnect(cmd *cobra.Command, args []string) error {
 		}
 	}
 	if aspsp == nil {
-		return ExitWithError(ExitAPIError, "bank %q no longer available", oldConn.ASPSPName)
+		return nil, ExitWithError(ExitAPIError, "bank %q no longer available", oldConn.ASPSPName)
 	}
 
-	// Select first non-EMBEDDED auth method
-	var selectedMethod string
-	var approach string
-	for _, m := range aspsp.AuthMethods {
-		if m.Approach != "EMBEDDED" {
-			selectedMethod = m.Name
-			approach = m.Approach
-			break
-		}
+	selectedMethod, approach, err := reconnectAuthMethod(aspsp, opts.authMethod, oldConn.AuthMethod, psuType)
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate valid_until
@@ -103,7 +132,7 @@ func runReconnect(cmd *cobra.Command, args []string) error {
 
 	// New auth flow
 	state := uuid.New().String()
-	callbackURL := auth.CallbackURL(port, app.Config.CallbackURL)
+	callbackURL := opts.flow.redirectURL()
 
 	authReq := &api.AuthRequest{
 		Access: api.AccessScope{
@@ -114,43 +143,73 @@ func runReconnect(cmd *cobra.Command, args []string) error {
 		ASPSP:       api.ASPSPRef{Name: aspsp.Name, Country: aspsp.Country},
 		State:       state,
 		RedirectURL: callbackURL,
-		PSUType:     "personal",
+		PSUType:     psuType,
 		AuthMethod:  selectedMethod,
 		Language:    "en",
 	}
 
+	if opts.dryRun {
+		app.Printer.Info("Dry run: not sending the authorization request")
+		return nil, app.Printer.JSON(authReq)
+	}
+
 	app.Printer.Info("Starting re-authorization for %s...", name)
-	authResp, err := app.Client.Authorize(ctx, authReq)
+	code, err := opts.flow.authorize(ctx, authReq, approach)
 	if err != nil {
-		return ExitWithError(ExitAPIError, "authorization request failed: %v", err)
+		return nil, err
 	}
 
-	if approach == "DECOUPLED" {
-		app.Printer.Info("Please complete authorization in your banking app...")
-	} else {
-		app.Printer.Info("Open this URL to authorize:")
-		fmt.Fprintln(os.Stderr, authResp.URL)
-		_ = openBrowser(authResp.URL)
+	app.Printer.Info("Creating session...")
+	session, err := app.Client.CreateSession(ctx, code)
+	if err != nil {
+		return nil, ExitWithError(ExitAPIError, "creating session: %v", err)
 	}
+	app.Printer.Event("session", map[string]interface{}{"connection": name, "accounts": len(session.Accounts)})
 
-	result, err := auth.ListenForCallback(ctx, port, state)
-	if err != nil {
-		if result != nil && result.Error != "" {
-			return ExitWithError(ExitAPIError, "authorization failed: %s — %s", result.Error, result.ErrorDescription)
-		}
-		return ExitWithError(ExitAPIError, "authorization callback: %v", err)
+	newAccounts := reconnectedAccounts(oldConn, aspsp.Name, session.Accounts)
+	app.Config.AssignAccountIDs(newAccounts)
+	warnAccountUsage(newAccounts, psuType)
+
+	// Resolve max access per day: -1 keeps old value, 0+ overrides
+	dailyLimit := oldConn.MaxAccessPerDay
+	if opts.maxAccessPerDay >= 0 {
+		dailyLimit = opts.maxAccessPerDay
 	}
 
-	app.Printer.Info("Creating session...")
-	session, err := app.Client.CreateSession(ctx, result.Code)
-	if err != nil {
-		return ExitWithError(ExitAPIError, "creating session: %v", err)
+	// Update connection, keeping what was set or learned about it, such as
+	// window_days and max_lookback_days
+	updatedConn := *oldConn
+	updatedConn.ASPSPCountry = aspsp.Country
+	updatedConn.ASPSPName = aspsp.Name
+	updatedConn.SessionID = session.SessionID
+	updatedConn.ConnectedAt = time.Now()
+	updatedConn.ValidUntil = validUntil
+	updatedConn.MaxConsentValiditySeconds = aspsp.MaximumConsentValidity
+	updatedConn.RequiredPSUHeaders = aspsp.RequiredPSUHeaders
+	updatedConn.MaxAccessPerDay = dailyLimit
+	updatedConn.PSUType = psuType
+	updatedConn.AuthMethod = selectedMethod
+	updatedConn.ReplaceAccounts(newAccounts, time.Now())
+
+	if err := app.Config.UpdateConnection(updatedConn); err != nil {
+		return nil, ExitWithError(ExitUserError, "%v", err)
+	}
+	if err := config.Save(app.ConfigPath, app.Config); err != nil {
+		return nil, ExitWithError(ExitAuthError, "saving config: %v", err)
 	}
 
-	// Match accounts by identification_hash
+	app.Printer.Info("Reconnected! %d account(s)", len(newAccounts))
+	app.Printer.Event("connected", map[string]interface{}{"connection": updatedConn.Name, "accounts": len(newAccounts)})
+	return &updatedConn, nil
+}
+
+// reconnectedAccounts turns the accounts of a new session into config
+// accounts, matching them with the connection's current and retired ones so
+// they keep their ID, alias, tags and UID history.
+func reconnectedAccounts(oldConn *config.Connection, bankName string, apiAccounts []api.AccountResource) []config.Account {
 	existingAliases := make(map[string]bool)
 	for _, conn := range app.Config.Connections {
-		if conn.Name == name {
+		if conn.Name == oldConn.Name {
 			continue // skip the connection being reconnected
 		}
 		for _, acct := range conn.Accounts {
@@ -158,74 +217,87 @@ func runReconnect(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	previous := append([]config.Account{}, oldConn.Accounts...)
+	for _, r := range oldConn.RetiredAccounts {
+		previous = append(previous, r.Account)
+	}
+	matched := make([]bool, len(previous))
+
 	var newAccounts []config.Account
-	for _, apiAcct := range session.Accounts {
-		var alias string
-		if old, ok := oldAccountsByHash[apiAcct.IdentificationHash]; ok {
-			alias = old.Alias
-			app.Printer.Info("Matched account %s -> %s", apiAcct.AccountID.IBAN, alias)
-		} else {
-			alias = generateAlias(aspsp.Name, apiAcct.Currency, existingAliases)
-			app.Printer.Warn("New account discovered: %s -> %s", apiAcct.AccountID.IBAN, alias)
+	for _, apiAcct := range apiAccounts {
+		acct := config.Account{
+			UID:                  apiAcct.UID,
+			IBAN:                 apiAcct.AccountID.IBAN,
+			Currency:             apiAcct.Currency,
+			IdentificationHash:   apiAcct.IdentificationHash,
+			IdentificationHashes: apiAcct.IdentificationHashes,
+			CashAccountType:      apiAcct.CashAccountType,
+			Usage:                strings.ToUpper(apiAcct.Usage),
 		}
-		existingAliases[strings.ToLower(alias)] = true
 
-		newAccounts = append(newAccounts, config.Account{
-			UID:                apiAcct.UID,
-			IBAN:               apiAcct.AccountID.IBAN,
-			Alias:              alias,
-			Currency:           apiAcct.Currency,
-			IdentificationHash: apiAcct.IdentificationHash,
-			CashAccountType:    apiAcct.CashAccountType,
-		})
+		i := previousAccount(previous, matched, &acct)
+		switch {
+		case i < 0:
+			acct.Alias = generateAlias(bankName, apiAcct.Currency, existingAliases)
+			app.Printer.Warn("New account discovered: %s -> %s", apiAcct.AccountID.IBAN, acct.Alias)
+		case existingAliases[strings.ToLower(previous[i].Alias)]:
+			// A retired account whose alias was given to another account since
+			matched[i] = true
+			acct.Inherit(&previous[i])
+			acct.Alias = generateAlias(bankName, apiAcct.Currency, existingAliases)
+			app.Printer.Warn("Matched account %s, but its alias %s is taken; now %s", apiAcct.AccountID.IBAN, previous[i].Alias, acct.Alias)
+		default:
+			matched[i] = true
+			acct.Inherit(&previous[i])
+			app.Printer.Info("Matched account %s -> %s", apiAcct.AccountID.IBAN, acct.Alias)
+		}
+		existingAliases[strings.ToLower(acct.Alias)] = true
+		newAccounts = append(newAccounts, acct)
 	}
 
 	// Check for lost accounts
-	for hash, oldAcct := range oldAccountsByHash {
-		found := false
-		for _, apiAcct := range session.Accounts {
-			if apiAcct.IdentificationHash == hash {
-				found = true
-				break
-			}
-		}
-		if !found {
-			app.Printer.Warn("Account %s (%s) no longer available after reconnect", oldAcct.Alias, oldAcct.IBAN)
+	for i, oldAcct := range oldConn.Accounts {
+		if !matched[i] {
+			app.Printer.Warn("Account %s (%s) no longer available after reconnect; kept as retired account %s (see: ebcli accounts link)", oldAcct.Alias, oldAcct.IBAN, oldAcct.ID)
 		}
 	}
+	return newAccounts
+}
 
-	// Resolve max access per day: -1 keeps old value, 0+ overrides
-	dailyLimit := oldConn.MaxAccessPerDay
-	if maxAccessPerDay >= 0 {
-		dailyLimit = maxAccessPerDay
+// previousAccount returns the index of the first unmatched account in
+// previous that is the same bank account as acct, or -1.
+func previousAccount(previous []config.Account, matched []bool, acct *config.Account) int {
+	for i := range previous {
+		if !matched[i] && config.SameAccount(&previous[i], acct) {
+			return i
+		}
 	}
+	return -1
+}
 
-	// Update connection
-	updatedConn := config.Connection{
-		Name:                      name,
-		ASPSPCountry:              aspsp.Country,
-		ASPSPName:                 aspsp.Name,
-		SessionID:                 session.SessionID,
-		Accounts:                  newAccounts,
-		ConnectedAt:               time.Now(),
-		ValidUntil:                validUntil,
-		MaxConsentValiditySeconds: aspsp.MaximumConsentValidity,
-		RequiredPSUHeaders:        aspsp.RequiredPSUHeaders,
-		MaxAccessPerDay:           dailyLimit,
+// reconnectAuthMethod picks the auth method for a reconnect: the requested
+// one, else the one used last time if the bank still offers it for psuType,
+// else the first supported one.
+func reconnectAuthMethod(aspsp *api.ASPSPData, requested, previous, psuType string) (name, approach string, err error) {
+	if requested != "" {
+		return selectAuthMethod(aspsp, requested, psuType)
 	}
 
-	if err := app.Config.UpdateConnection(updatedConn); err != nil {
-		return ExitWithError(ExitUserError, "%v", err)
-	}
-	if err := config.Save(app.ConfigPath, app.Config); err != nil {
-		return ExitWithError(ExitAuthError, "saving config: %v", err)
+	usable := usableAuthMethods(aspsp.AuthMethods, psuType)
+	if len(usable) == 0 {
+		if len(aspsp.AuthMethods) > 0 {
+			return "", "", ExitWithError(ExitAPIError, "no supported %s auth methods for %s", psuType, aspsp.Name)
+		}
+		return "", "REDIRECT", nil
 	}
 
-	app.Printer.Info("Reconnected! %d account(s)", len(newAccounts))
-	return app.Printer.JSON(updatedConn)
-}
-
-func init() {
-	// flags already registered above
-	_ = fmt.Sprintf // ensure fmt is used
+	if previous != "" {
+		for _, m := range usable {
+			if strings.EqualFold(m.Name, previous) {
+				return m.Name, m.Approach, nil
+			}
+		}
+		app.Printer.Warn("Auth method %q is no longer offered by %s, using %s", previous, aspsp.Name, usable[0].Name)
+	}
+	return usable[0].Name, usable[0].Approach, nil
 }
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+)
+
+func TestReconnectedAccounts_KeepsTags(t *testing.T) {
+	savedConfig, savedPrinter := app.Config, app.Printer
+	defer func() { app.Config, app.Printer = savedConfig, savedPrinter }()
+	app.Printer = output.NewPrinter(&bytes.Buffer{}, &bytes.Buffer{}, output.ModeCompact, false)
+	app.Config = &config.Config{Connections: []config.Connection{{
+		Name: "ing",
+		Accounts: []config.Account{
+			{ID: "a1", UID: "u1", Alias: "main", IdentificationHash: "h1", Tags: []string{"household", "bills"}},
+		},
+		RetiredAccounts: []config.RetiredAccount{
+			{Account: config.Account{ID: "a2", UID: "u2", Alias: "travel-card", IdentificationHash: "h2", Tags: []string{"travel"}}},
+		},
+	}}}
+
+	got := reconnectedAccounts(&app.Config.Connections[0], "ING", []api.AccountResource{
+		{UID: "u3", IdentificationHash: "h1", Currency: "EUR"},
+		{UID: "u4", IdentificationHash: "h2", Currency: "EUR"},
+	})
+	if len(got) != 2 {
+		t.Fatalf("got %d accounts, want 2", len(got))
+	}
+	if got[0].ID != "a1" || !reflect.DeepEqual(got[0].Tags, []string{"household", "bills"}) {
+		t.Errorf("current account = %+v, want ID a1 with its tags", got[0])
+	}
+	if got[1].ID != "a2" || !reflect.DeepEqual(got[1].Tags, []string{"travel"}) {
+		t.Errorf("retired account = %+v, want ID a2 with its tags", got[1])
+	}
+}
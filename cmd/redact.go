@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/redact"
+)
+
+// redactMapFile is the mapping file of --redact, in the config directory.
+const redactMapFile = "redact-map.json"
+
+var unredactCmd = &cobra.Command{
+	Use:   "unredact [file]",
+	Short: "Restore the names and account numbers in text written from --redact output",
+	Long: "Replaces the pseudonyms (party_3fa2c1d0) and masked account numbers that --redact\n" +
+		"wrote with the originals, from the mapping file in the config directory. Reads the\n" +
+		"file, or stdin, and writes the result to stdout:\n\n" +
+		"  ebcli dump --redact | llm \"Where does my money go?\" | ebcli unredact",
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		r, err := loadRedactor(redact.DefaultRules)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		in := io.Reader(os.Stdin)
+		if len(args) == 1 {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			defer f.Close()
+			in = f
+		}
+		text, err := io.ReadAll(in)
+		if err != nil {
+			return ExitWithError(ExitUserError, "reading input: %v", err)
+		}
+		_, err = io.WriteString(os.Stdout, r.Reveal(string(text)))
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unredactCmd)
+}
+
+// setupRedaction turns on --redact with the fields of the profile, or the
+// defaults when cfg is nil.
+func setupRedaction(cfg *config.Config) error {
+	if !flagRedact {
+		return nil
+	}
+	rules := redact.DefaultRules
+	if cfg != nil && cfg.Redact != nil {
+		if cfg.Redact.Mask != nil {
+			rules.Mask = cfg.Redact.Mask
+		}
+		if cfg.Redact.Pseudonymize != nil {
+			rules.Pseudonymize = cfg.Redact.Pseudonymize
+		}
+		if cfg.Redact.Remove != nil {
+			rules.Remove = cfg.Redact.Remove
+		}
+	}
+	r, err := loadRedactor(rules)
+	if err != nil {
+		return err
+	}
+	app.Redactor = r
+	app.Printer.SetRedactor(r)
+	return nil
+}
+
+func loadRedactor(rules redact.Rules) (*redact.Redactor, error) {
+	dir, _, err := config.Paths(flagConfig)
+	if err != nil {
+		return nil, fmt.Errorf("config path: %w", err)
+	}
+	r, err := redact.Load(filepath.Join(dir, redactMapFile), rules)
+	if err != nil {
+		return nil, fmt.Errorf("loading redaction map: %w", err)
+	}
+	return r, nil
+}
+
+// redacted returns v as --redact writes it, for output built from values
+// rather than encoded, such as tables. Without --redact it is v.
+func redacted[T any](v T) (T, error) {
+	if app.Redactor == nil {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v, err
+	}
+	if data, err = app.Redactor.Redact(data); err != nil {
+		return v, err
+	}
+	var out T
+	if err := json.Unmarshal(data, &out); err != nil {
+		return v, fmt.Errorf("redacting: %w", err)
+	}
+	return out, nil
+}
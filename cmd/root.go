@@ -1,18 +1,22 @@
 package cmd
 
 import (
+	"crypto/rsa"
 	"fmt"
+	"log/slog"
 	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/audit"
 	"github.com/nicolasacchi/ebcli/internal/auth"
 	"github.com/nicolasacchi/ebcli/internal/config"
 	"github.com/nicolasacchi/ebcli/internal/output"
 	"github.com/nicolasacchi/ebcli/internal/psu"
 	"github.com/nicolasacchi/ebcli/internal/ratelimit"
+	"github.com/nicolasacchi/ebcli/internal/redact"
 )
 
 const (
@@ -29,16 +33,33 @@ type App struct {
 	Client    *api.Client
 	Printer   *output.Printer
 	RateLimit *ratelimit.Tracker
+	// Passphrase unlocks an encrypted private key and encrypted connections.
+	Passphrase *auth.Passphrase
+	// Redactor rewrites output for --redact; nil without it.
+	Redactor *redact.Redactor
+	// Audit records every API request; nil until a client is configured.
+	Audit *audit.Log
+	// Logger logs API requests for --debug, with their bodies if
+	// LogBodies; nil without it.
+	Logger    *slog.Logger
+	LogBodies bool
 }
 
 var (
-	app         App
-	flagPretty  bool
-	flagCompact bool
-	flagRaw     bool
-	flagQuiet   bool
-	flagConfig  string
-	version     string
+	app             App
+	flagPretty      bool
+	flagCompact     bool
+	flagRaw         bool
+	flagQuiet       bool
+	flagEvents      bool
+	flagConfig      string
+	flagProfile     string
+	flagFormat      string
+	flagTemplate    string
+	flagRedact      bool
+	flagErrorFormat string
+	flagDebug       string
+	version         string
 )
 
 var rootCmd = &cobra.Command{
@@ -51,9 +72,41 @@ var rootCmd = &cobra.Command{
 		// Initialize printer first (always needed)
 		mode := output.ModeFromFlags(flagPretty, flagCompact, flagRaw)
 		app.Printer = output.NewPrinter(os.Stdout, os.Stderr, mode, flagQuiet)
+		if flagEvents {
+			app.Printer.EnableEvents()
+		}
+		errorFormat, err := output.ParseErrorFormat(flagErrorFormat)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		app.Printer.SetErrorFormat(errorFormat)
+		format, err := output.ParseFormat(flagFormat)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		app.Printer.SetFormat(format)
+		if err := setupDebug(cmd.Flags().Changed("debug")); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		if format == output.FormatLLM && fullCmdName(cmd) != "ebcli dump" {
+			return ExitWithError(ExitUserError, "--format llm is only supported by dump")
+		}
+		if flagTemplate != "" {
+			if format != output.FormatJSON {
+				return ExitWithError(ExitUserError, "--template cannot be combined with --format %s", flagFormat)
+			}
+			tmpl, err := loadTemplate(flagTemplate)
+			if err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
+			app.Printer.SetTemplate(tmpl)
+		}
 
 		// Commands that don't need full config/client initialization
 		if skipInit(cmd) {
+			if err := setupRedaction(nil); err != nil {
+				return ExitWithError(ExitUserError, "%v", err)
+			}
 			return nil
 		}
 
@@ -64,12 +117,29 @@ var rootCmd = &cobra.Command{
 		}
 		app.ConfigPath = cfgPath
 
-		cfg, err := config.Load(cfgPath)
+		cfg, err := config.LoadProfile(cfgPath, flagProfile)
 		if err != nil {
 			return exitError(ExitAuthError, "loading config: %v", err)
 		}
 		app.Config = cfg
 
+		app.Passphrase = &auth.Passphrase{Command: cfg.PassphraseCommand, NoTTY: flagEvents}
+		if cfg.IsSealed() {
+			err := app.Passphrase.Unlock("Config passphrase", func(passphrase auth.PassphraseFunc) error {
+				pass, err := passphrase("Config passphrase")
+				if err != nil {
+					return fmt.Errorf("reading config passphrase: %w", err)
+				}
+				return cfg.Unseal(pass)
+			})
+			if err != nil {
+				return exitError(ExitAuthError, "%v", err)
+			}
+		}
+		if err := setupRedaction(cfg); err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
 		// Commands that only need config (no API client)
 		if configOnly(cmd) {
 			return nil
@@ -79,16 +149,17 @@ var rootCmd = &cobra.Command{
 		if cfg.AppID == "" {
 			return exitError(ExitAuthError, "app_id not configured. Run: ebcli config --init")
 		}
-		if cfg.PrivateKeyPath == "" {
-			return exitError(ExitAuthError, "private_key_path not configured. Run: ebcli config --init")
-		}
 
-		keyPath, err := config.ExpandTilde(cfg.PrivateKeyPath)
+		keySource, err := keySourceFor(cfg)
 		if err != nil {
-			return exitError(ExitAuthError, "expanding key path: %v", err)
+			return exitError(ExitAuthError, "%v", err)
 		}
 
-		privateKey, err := auth.LoadPrivateKey(keyPath)
+		var privateKey *rsa.PrivateKey
+		err = app.Passphrase.Unlock("Private key passphrase", func(passphrase auth.PassphraseFunc) error {
+			privateKey, err = auth.LoadKey(keySource, passphrase)
+			return err
+		})
 		if err != nil {
 			return exitError(ExitAuthError, "loading private key: %v", err)
 		}
@@ -105,9 +176,11 @@ var rootCmd = &cobra.Command{
 		psuProvider := psu.NewProvider(version)
 
 		// Initialize API client
-		opts := []api.ClientOption{
-			api.WithVersion(version),
+		opts, err := clientOptions(cfg)
+		if err != nil {
+			return exitError(ExitAuthError, "%v", err)
 		}
+		opts = append(opts, api.WithVersion(version))
 		if psuProvider != nil {
 			opts = append(opts, api.WithPSUProvider(psuProvider))
 		}
@@ -126,7 +199,15 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&flagCompact, "compact", false, "force compact JSON output")
 	rootCmd.PersistentFlags().BoolVar(&flagRaw, "raw", false, "output raw API response without transformation")
 	rootCmd.PersistentFlags().BoolVar(&flagQuiet, "quiet", false, "suppress informational messages on stderr")
+	rootCmd.PersistentFlags().StringVar(&flagErrorFormat, "error-format", "text", "how errors and warnings are written to stderr: text, or json (one object per line)")
+	rootCmd.PersistentFlags().StringVar(&flagDebug, "debug", "", "log API requests and responses to stderr as JSON lines: requests, or bodies to include them (default: EBCLI_DEBUG)")
+	rootCmd.PersistentFlags().Lookup("debug").NoOptDefVal = "requests"
+	rootCmd.PersistentFlags().BoolVar(&flagEvents, "events", false, "write progress to stderr as JSON lines and never prompt (for wrapper tools)")
 	rootCmd.PersistentFlags().StringVar(&flagConfig, "config", "", "path to config file")
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "config profile to use (default: EBCLI_PROFILE or current profile)")
+	rootCmd.PersistentFlags().StringVar(&flagFormat, "format", "json", "output format: json, table for accounts, audit, balances, consents, transactions, dump and status, or llm for dump")
+	rootCmd.PersistentFlags().BoolVar(&flagRedact, "redact", false, "mask IBANs, pseudonymize counterparty names and drop owner names in the output (see: ebcli unredact)")
+	rootCmd.PersistentFlags().StringVar(&flagTemplate, "template", "", "render output with a Go text/template: inline, @file or a name from the templates directory")
 }
 
 // Execute runs the root command. Called from main.
@@ -135,6 +216,17 @@ func Execute(v string) error {
 	rootCmd.Version = v
 
 	err := rootCmd.Execute()
+	if e, ok := err.(*exitErr); err != nil && (!ok || !e.reported) {
+		code := ExitCode(err)
+		p := problemFor(code, err)
+		p.Message = err.Error()
+		err = reportError(code, p)
+	}
+	if app.Redactor != nil {
+		if saveErr := app.Redactor.Save(); saveErr != nil && app.Printer != nil {
+			app.Printer.Warn("saving redaction map: %v", saveErr)
+		}
+	}
 	if err != nil {
 		// Persist rate limit cache on exit
 		if app.RateLimit != nil {
@@ -149,20 +241,79 @@ func Execute(v string) error {
 	return nil
 }
 
+// keySourceFor picks where the private key comes from. Priority:
+// EBCLI_PRIVATE_KEY_PEM, EBCLI_PRIVATE_KEY_FD, EBCLI_PRIVATE_KEY,
+// key_command, private_key_path.
+func keySourceFor(cfg *config.Config) (auth.KeySource, error) {
+	switch {
+	case cfg.PrivateKeyPEM != "":
+		return auth.InlineKeySource{PEM: cfg.PrivateKeyPEM}, nil
+	case cfg.PrivateKeyFD > 0:
+		return auth.FDKeySource{FD: cfg.PrivateKeyFD}, nil
+	case cfg.KeyCommand != "" && cfg.KeyPathEnv == "":
+		return auth.CommandKeySource{Command: cfg.KeyCommand}, nil
+	case cfg.KeyPath() != "":
+		keyPath, err := config.ExpandTilde(cfg.KeyPath())
+		if err != nil {
+			return nil, fmt.Errorf("expanding key path: %w", err)
+		}
+		return auth.FileKeySource{Path: keyPath}, nil
+	default:
+		return nil, fmt.Errorf("no private key configured (private_key_path, key_command, EBCLI_PRIVATE_KEY_PEM or EBCLI_PRIVATE_KEY_FD). Run: ebcli config --init")
+	}
+}
+
+// clientOptions returns the transport-level client options from config:
+// base URL, proxy and extra CA bundle.
+func clientOptions(cfg *config.Config) ([]api.ClientOption, error) {
+	var opts []api.ClientOption
+
+	if baseURL := cfg.APIBaseURL(); baseURL != "" {
+		opts = append(opts, api.WithBaseURL(baseURL))
+	}
+	if app.Logger != nil {
+		opts = append(opts, api.WithLogger(app.Logger, app.LogBodies))
+	}
+
+	// Every request is recorded in the audit log
+	auditLog, err := openAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, api.WithAuditLog(auditLog, connectionFor))
+
+	if cfg.Proxy != "" || cfg.CABundle != "" {
+		caBundle, err := config.ExpandTilde(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("expanding CA bundle path: %w", err)
+		}
+		hc, err := api.NewHTTPClient(cfg.Proxy, caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("configuring HTTP client: %w", err)
+		}
+		opts = append(opts, api.WithHTTPClient(hc))
+	}
+
+	return opts, nil
+}
+
 // skipInit returns true for commands that need no config or client at all.
 func skipInit(cmd *cobra.Command) bool {
 	name := fullCmdName(cmd)
-	// config --init creates config, doesn't need to load it
-	if name == "ebcli config" {
+	// config --init creates config, doesn't need to load it; validate must
+	// work on configs that fail to load
+	if name == "ebcli config" || name == "ebcli config validate" || name == "ebcli templates" || name == "ebcli unredact" {
 		return true
 	}
-	return false
+	// profile commands operate on the whole config file, not a single profile
+	return strings.HasPrefix(name, "ebcli profile")
 }
 
 // configOnly returns true for commands that need config but no API client.
 func configOnly(cmd *cobra.Command) bool {
 	name := fullCmdName(cmd)
-	return name == "ebcli accounts"
+	return name == "ebcli accounts" || strings.HasPrefix(name, "ebcli accounts ") || name == "ebcli audit" ||
+		strings.HasPrefix(name, "ebcli config ") || strings.HasPrefix(name, "ebcli connections ")
 }
 
 func fullCmdName(cmd *cobra.Command) string {
@@ -174,8 +325,9 @@ func fullCmdName(cmd *cobra.Command) string {
 }
 
 type exitErr struct {
-	code int
-	msg  string
+	code     int
+	msg      string
+	reported bool // already written to stderr
 }
 
 func (e *exitErr) Error() string { return e.msg }
@@ -185,7 +337,16 @@ func exitError(code int, format string, args ...interface{}) error {
 }
 
 // ExitWithError prints an error to stderr and returns an error for the exit code.
+// The error's kind for --error-format json comes from the last error in args,
+// if any, or else from the code.
 func ExitWithError(code int, format string, args ...interface{}) error {
-	app.Printer.Error(format, args...)
-	return &exitErr{code: code, msg: fmt.Sprintf(format, args...)}
+	var cause error
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			cause = err
+		}
+	}
+	p := problemFor(code, cause)
+	p.Message = fmt.Sprintf(format, args...)
+	return reportError(code, p)
 }
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/nicolasacchi/ebcli/internal/auth"
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+func TestKeySourceFor(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want auth.KeySource
+	}{
+		{"path", config.Config{PrivateKeyPath: "/etc/key.pem"}, auth.FileKeySource{Path: "/etc/key.pem"}},
+		{"command", config.Config{PrivateKeyPath: "/etc/key.pem", KeyCommand: "pass key"}, auth.CommandKeySource{Command: "pass key"}},
+		{"env path", config.Config{KeyCommand: "pass key", KeyPathEnv: "/env/key.pem"}, auth.FileKeySource{Path: "/env/key.pem"}},
+		{"fd", config.Config{KeyPathEnv: "/env/key.pem", PrivateKeyFD: 3}, auth.FDKeySource{FD: 3}},
+	}
+	for _, tt := range tests {
+		got, err := keySourceFor(&tt.cfg)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: keySourceFor = %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+}
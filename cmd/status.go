@@ -5,12 +5,14 @@ import (
 	"fmt"
 	"math"
 	"os"
-	"text/tabwriter"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/output"
 )
 
 var statusCmd = &cobra.Command{
@@ -19,7 +21,7 @@ var statusCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ctx := context.Background()
 
-		output := api.StatusOutput{
+		result := api.StatusOutput{
 			Connections: []api.ConnectionStatus{},
 		}
 
@@ -28,7 +30,7 @@ var statusCmd = &cobra.Command{
 		if err != nil {
 			app.Printer.Warn("could not fetch application status: %v", err)
 		} else {
-			output.Application = appInfo
+			result.Application = appInfo
 		}
 
 		// Print application info to stderr
@@ -38,23 +40,29 @@ var statusCmd = &cobra.Command{
 				status = "ACTIVE"
 			}
 			app.Printer.Info("Application: %s (%s, %s)", appInfo.Name, appInfo.Environment, status)
+			if app.Config.Environment != "" && !strings.EqualFold(appInfo.Environment, app.Config.Environment) {
+				app.Printer.Warn("config environment is %s but the application is registered as %s", app.Config.Environment, appInfo.Environment)
+			}
 		}
 		fmt.Fprintln(os.Stderr)
 
-		// Check each connection
+		// Check each connection. The table goes to stderr next to the JSON,
+		// or is the output with --format table
+		var table *output.Table
 		if len(app.Config.Connections) == 0 {
 			app.Printer.Info("No connections configured. Run: ebcli connect")
 		} else {
-			w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
-			fmt.Fprintf(w, "CONNECTION\tBANK\tACCOUNTS\tVALID UNTIL\tSTATUS\tDAYS LEFT\tTODAY\n")
-			fmt.Fprintf(w, "----------\t----\t--------\t-----------\t------\t---------\t-----\n")
+			table = &output.Table{Columns: []output.Column{
+				{Header: "CONNECTION"}, {Header: "BANK"}, {Header: "TYPE"}, {Header: "ACCOUNTS", Align: output.AlignRight},
+				{Header: "VALID UNTIL"}, {Header: "STATUS"}, {Header: "DAYS LEFT", Align: output.AlignRight}, {Header: "TODAY"},
+			}}
 
 			for _, conn := range app.Config.Connections {
 				sessionStatus := "UNKNOWN"
 				sessInfo, err := app.Client.GetSession(ctx, conn.SessionID)
 				if err != nil {
 					sessionStatus = "ERROR"
-					app.Printer.Warn("could not check session for %s: %v", conn.Name, err)
+					warnConnection(conn.Name, err, "could not check session for %s: %v", conn.Name, err)
 				} else {
 					sessionStatus = sessInfo.Status
 				}
@@ -69,17 +77,18 @@ var statusCmd = &cobra.Command{
 
 				todayStr := "-"
 				if conn.MaxAccessPerDay > 0 && app.RateLimit != nil {
-					used, _ := app.RateLimit.DailyUsageFor(conn.Name)
+					used, _ := app.RateLimit.DailyUsageFor(usageKey(conn.Name))
 					todayStr = fmt.Sprintf("%d/%d", used, conn.MaxAccessPerDay)
 				}
 
-				fmt.Fprintf(w, "%s\t%s %s\t%d\t%s\t%s\t%d\t%s\n",
+				table.AddRow(
 					conn.Name,
-					conn.ASPSPName, conn.ASPSPCountry,
-					len(conn.Accounts),
+					conn.ASPSPName+" "+conn.ASPSPCountry,
+					conn.PSU(),
+					strconv.Itoa(len(conn.Accounts)),
 					conn.ValidUntil.Format("2006-01-02"),
 					sessionStatus,
-					daysLeft,
+					strconv.Itoa(daysLeft),
 					todayStr,
 				)
 
@@ -87,6 +96,7 @@ var statusCmd = &cobra.Command{
 					Name:       conn.Name,
 					Bank:       conn.ASPSPName,
 					Country:    conn.ASPSPCountry,
+					PSUType:    conn.PSU(),
 					Status:     sessionStatus,
 					Accounts:   len(conn.Accounts),
 					ValidUntil: conn.ValidUntil,
@@ -95,15 +105,23 @@ var statusCmd = &cobra.Command{
 				if conn.MaxAccessPerDay > 0 {
 					connStatus.MaxAccessPerDay = conn.MaxAccessPerDay
 					if app.RateLimit != nil {
-						connStatus.DailyUsed, _ = app.RateLimit.DailyUsageFor(conn.Name)
+						connStatus.DailyUsed, _ = app.RateLimit.DailyUsageFor(usageKey(conn.Name))
 					}
 				}
-				output.Connections = append(output.Connections, connStatus)
+				result.Connections = append(result.Connections, connStatus)
 			}
-			w.Flush()
 		}
 
-		return app.Printer.JSON(output)
+		if app.Printer.Format() == output.FormatTable {
+			if table == nil {
+				return nil
+			}
+			return app.Printer.Table(table)
+		}
+		if table != nil {
+			table.Render(os.Stderr, 0)
+		}
+		return app.Printer.JSON(result)
 	},
 }
 
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/filter"
+	"github.com/nicolasacchi/ebcli/internal/output"
+)
+
+// printResult writes v to stdout as JSON or, with --format table, as the
+// table that table builds from v, redacted with --redact. Commands without
+// a table view pass nil and always write JSON.
+func printResult[T any](v T, table func(T) *output.Table) error {
+	if table != nil && app.Printer.Format() == output.FormatTable {
+		v, err := redacted(v)
+		if err != nil {
+			return err
+		}
+		return app.Printer.Table(table(v))
+	}
+	return app.Printer.JSON(v)
+}
+
+func printAccounts(accounts []api.AccountOutput) error {
+	return printResult(accounts, accountsTable)
+}
+
+func printBalances(accounts []api.BalanceOutput) error {
+	return printResult(accounts, balancesTable)
+}
+
+// printRows prints records projected with --fields.
+func printRows(fields []string, rows []filter.Row) error {
+	return printResult(rows, func(rows []filter.Row) *output.Table { return rowsTable(fields, rows) })
+}
+
+// formatAmount formats an amount for tables, in the locale of the
+// environment.
+func formatAmount(amount api.Amount) string {
+	return output.LocaleFromEnv().FormatAmount(amount.Amount, amount.Currency)
+}
+
+func accountsTable(accounts []api.AccountOutput) *output.Table {
+	retired := false
+	for _, a := range accounts {
+		retired = retired || a.RetiredAt != nil
+	}
+	t := &output.Table{Columns: []output.Column{
+		{Header: "ALIAS"}, {Header: "ID"}, {Header: "IBAN"}, {Header: "CONNECTION"},
+		{Header: "CURRENCY"}, {Header: "TYPE"}, {Header: "TAGS", Shrink: true}, {Header: "VALID UNTIL"},
+	}}
+	if retired {
+		t.Columns = append(t.Columns, output.Column{Header: "RETIRED"})
+	}
+	for _, a := range accounts {
+		row := []string{a.Alias, a.ID, a.IBAN, a.Connection, a.Currency, a.CashAccountType, strings.Join(a.Tags, ","), a.ValidUntil.Format("2006-01-02")}
+		if a.RetiredAt != nil {
+			row = append(row, a.RetiredAt.Format("2006-01-02"))
+		}
+		t.AddRow(row...)
+	}
+	return t
+}
+
+func balancesTable(accounts []api.BalanceOutput) *output.Table {
+	t := &output.Table{Columns: []output.Column{
+		{Header: "ACCOUNT"}, {Header: "IBAN"}, {Header: "TYPE"}, {Header: "NAME", Shrink: true},
+		{Header: "AMOUNT", Align: output.AlignRight}, {Header: "DATE"},
+	}}
+	for _, a := range accounts {
+		for _, b := range a.Balances {
+			style := output.StylePlain
+			if strings.HasPrefix(b.BalanceAmount.Amount, "-") {
+				style = output.StyleDebit
+			}
+			t.Rows = append(t.Rows, []output.Cell{
+				{Text: a.Account}, {Text: a.IBAN}, {Text: b.BalanceType}, {Text: b.Name, Style: output.StyleMuted},
+				{Text: formatAmount(b.BalanceAmount), Style: style}, {Text: b.ReferenceDate},
+			})
+		}
+	}
+	return t
+}
+
+func transactionsTable(txns []annotatedTransaction) *output.Table {
+	pending := false
+	for _, txn := range txns {
+		pending = pending || (txn.Status != "" && txn.Status != "BOOK")
+	}
+	t := &output.Table{Columns: []output.Column{
+		{Header: "DATE"}, {Header: "ACCOUNT"}, {Header: "AMOUNT", Align: output.AlignRight},
+		{Header: "COUNTERPARTY", Shrink: true}, {Header: "DESCRIPTION", Shrink: true},
+	}}
+	if pending {
+		t.Columns = append(t.Columns, output.Column{Header: "STATUS"})
+	}
+	for _, txn := range txns {
+		r := transactionRecord(txn)
+		amount := txn.TransactionAmount
+		amount.Amount = filter.Text(valueOf(r, "signed_amount"))
+		style := output.StyleCredit
+		if strings.HasPrefix(amount.Amount, "-") {
+			style = output.StyleDebit
+		}
+		row := []output.Cell{
+			{Text: transactionDate(txn.Transaction)}, {Text: txn.Account},
+			{Text: formatAmount(amount), Style: style},
+			{Text: filter.Text(valueOf(r, "counterparty"))},
+			{Text: filter.Text(valueOf(r, "remittance")), Style: output.StyleMuted},
+		}
+		if pending {
+			row = append(row, output.Cell{Text: txn.Status})
+		}
+		t.Rows = append(t.Rows, row)
+	}
+	return t
+}
+
+// dumpTables shows a dump as a table of balances and one of transactions.
+func dumpTables(dump api.DumpOutput, txns []annotatedTransaction, fields []string) []*output.Table {
+	var balances []api.BalanceOutput
+	for _, a := range dump.Accounts {
+		balances = append(balances, api.BalanceOutput{Account: a.Alias, IBAN: a.IBAN, Balances: a.Balances})
+	}
+	if fields == nil {
+		return []*output.Table{balancesTable(balances), transactionsTable(txns)}
+	}
+	rows := make([]filter.Row, len(txns))
+	for i, txn := range txns {
+		rows[i] = filter.Project(transactionRecord(txn), fields)
+	}
+	return []*output.Table{balancesTable(balances), rowsTable(fields, rows)}
+}
+
+// rowsTable shows records projected with --fields, a column per field.
+func rowsTable(fields []string, rows []filter.Row) *output.Table {
+	t := &output.Table{}
+	for _, f := range fields {
+		t.Columns = append(t.Columns, output.Column{Header: strings.ToUpper(f), Shrink: true})
+	}
+	for _, row := range rows {
+		values := make([]string, len(fields))
+		for _, c := range row {
+			// By name: --redact may have removed some
+			for i, f := range fields {
+				if c.Name == f {
+					values[i] = filter.Text(c.Value)
+				}
+			}
+		}
+		t.AddRow(values...)
+	}
+	return t
+}
+
+func valueOf(r filter.Record, name string) interface{} {
+	v, _ := r.Value(name)
+	return v
+}
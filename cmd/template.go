@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/output"
+)
+
+// templateExt is the extension of named templates in the templates directory.
+const templateExt = ".tmpl"
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates",
+	Short: "List the named templates for --template",
+	Long: "Named templates are text/template files in the templates directory next to the\n" +
+		"config file (~/.config/ebcli/templates/<name>.tmpl). Use one with --template <name>.\n" +
+		"Templates can include each other with {{template \"<name>\" .}}.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := templatesDir()
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+		named, err := namedTemplates(dir)
+		if err != nil {
+			return ExitWithError(ExitUserError, "%v", err)
+		}
+
+		type templateOutput struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+		}
+		results := []templateOutput{}
+		for _, name := range sortedKeys(named) {
+			results = append(results, templateOutput{Name: name, Path: named[name]})
+		}
+		if len(results) == 0 {
+			app.Printer.Info("No templates in %s", dir)
+		}
+		return app.Printer.JSON(results)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templatesCmd)
+}
+
+// templatesDir returns the directory of named templates.
+func templatesDir() (string, error) {
+	dir, _, err := config.Paths(flagConfig)
+	if err != nil {
+		return "", fmt.Errorf("config path: %w", err)
+	}
+	return filepath.Join(dir, "templates"), nil
+}
+
+// namedTemplates returns the paths of the templates in dir by name.
+func namedTemplates(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	named := map[string]string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), templateExt) {
+			named[strings.TrimSuffix(e.Name(), templateExt)] = filepath.Join(dir, e.Name())
+		}
+	}
+	return named, nil
+}
+
+// loadTemplate parses the --template value: a file as @path, an inline
+// template (anything with "{{"), or the name of a template in the templates
+// directory. The named templates it uses with {{template}} are parsed with
+// it; the others are not read.
+func loadTemplate(spec string) (*template.Template, error) {
+	dir, err := templatesDir()
+	if err != nil {
+		return nil, err
+	}
+
+	name, src := "template", spec
+	switch {
+	case strings.HasPrefix(spec, "@"):
+		path, err := config.ExpandTilde(spec[1:])
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		name, src = filepath.Base(path), string(data)
+	case !strings.Contains(spec, "{{"):
+		named, err := namedTemplates(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading templates: %w", err)
+		}
+		path, ok := named[strings.TrimSuffix(spec, templateExt)]
+		if !ok {
+			return nil, fmt.Errorf("no template named %q in %s (inline templates contain {{ }}, files are given as @path)", spec, dir)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading template: %w", err)
+		}
+		name, src = strings.TrimSuffix(spec, templateExt), string(data)
+	}
+
+	tmpl, err := output.ParseTemplate(name, src)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	if err := parseUsedTemplates(tmpl, dir); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// parseUsedTemplates adds to tmpl the named templates from dir that it
+// uses and does not define, and those they use in turn.
+func parseUsedTemplates(tmpl *template.Template, dir string) error {
+	var named map[string]string
+	for {
+		used := map[string]bool{}
+		for _, t := range tmpl.Templates() {
+			if t.Tree != nil {
+				templateRefs(t.Tree.Root, used)
+			}
+		}
+		missing := map[string]string{}
+		for ref := range used {
+			if tmpl.Lookup(ref) != nil {
+				continue
+			}
+			if named == nil {
+				var err error
+				if named, err = namedTemplates(dir); err != nil {
+					return fmt.Errorf("reading templates: %w", err)
+				}
+			}
+			if path, ok := named[ref]; ok {
+				missing[ref] = path
+			}
+		}
+		if len(missing) == 0 {
+			return nil // anything else fails when executed, as a missing template
+		}
+		for _, ref := range sortedKeys(missing) {
+			data, err := os.ReadFile(missing[ref])
+			if err != nil {
+				return fmt.Errorf("reading template: %w", err)
+			}
+			if _, err := tmpl.New(ref).Parse(string(data)); err != nil {
+				return fmt.Errorf("parsing template %s: %w", ref, err)
+			}
+		}
+	}
+}
+
+// templateRefs adds the names of the templates node calls to refs.
+func templateRefs(node parse.Node, refs map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			templateRefs(child, refs)
+		}
+	case *parse.IfNode:
+		templateRefs(n.List, refs)
+		templateRefs(n.ElseList, refs)
+	case *parse.RangeNode:
+		templateRefs(n.List, refs)
+		templateRefs(n.ElseList, refs)
+	case *parse.WithNode:
+		templateRefs(n.List, refs)
+		templateRefs(n.ElseList, refs)
+	case *parse.TemplateNode:
+		refs[n.Name] = true
+	}
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
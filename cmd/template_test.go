@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	flagConfig = filepath.Join(dir, "config.json")
+	defer func() { flagConfig = "" }()
+
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "templates", "line.tmpl"), `[{{.}}]`)
+	write(filepath.Join(dir, "templates", "report.tmpl"), `report {{template "line" .}}`)
+	write(filepath.Join(dir, "templates", "nested.tmpl"), `<{{if .}}{{template "report" .}}{{end}}>`)
+	write(filepath.Join(dir, "templates", "broken.tmpl"), `{{.`) // not used, so not parsed
+	write(filepath.Join(dir, "other.txt"), `file {{template "line" .}}`)
+
+	tests := []struct {
+		spec, want string
+	}{
+		{"report", "report [x]"},
+		{"report.tmpl", "report [x]"},
+		{"nested", "<report [x]>"},
+		{"inline {{template \"line\" .}}", "inline [x]"},
+		{"@" + filepath.Join(dir, "other.txt"), "file [x]"},
+	}
+	for _, tt := range tests {
+		tmpl, err := loadTemplate(tt.spec)
+		if err != nil {
+			t.Errorf("loadTemplate(%q): %v", tt.spec, err)
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, "x"); err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+		}
+		if buf.String() != tt.want {
+			t.Errorf("%q rendered %q, want %q", tt.spec, buf.String(), tt.want)
+		}
+	}
+
+	if _, err := loadTemplate("missing"); err == nil || !strings.Contains(err.Error(), `no template named "missing"`) {
+		t.Errorf("missing template: %v", err)
+	}
+	if _, err := loadTemplate("@" + filepath.Join(dir, "nope")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
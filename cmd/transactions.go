@@ -4,9 +4,11 @@ import (
 	"context"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"github.com/nicolasacchi/ebcli/internal/api"
-	"github.com/nicolasacchi/ebcli/internal/resolver"
+	"github.com/nicolasacchi/ebcli/internal/filter"
+	"github.com/nicolasacchi/ebcli/internal/output"
 )
 
 var transactionsCmd = &cobra.Command{
@@ -16,14 +18,26 @@ var transactionsCmd = &cobra.Command{
 }
 
 func init() {
-	transactionsCmd.Flags().StringP("account", "a", "", "account alias, UID, or IBAN")
+	transactionsCmd.Flags().StringP("account", "a", "", "accounts: "+accountSelectorHelp)
 	transactionsCmd.Flags().Bool("all", false, "fetch all accounts")
-	transactionsCmd.Flags().String("from", "", "start date (YYYY-MM-DD, today, yesterday, -Nd)")
+	transactionsCmd.Flags().String("from", "", "start date ("+dateHelp+")")
 	transactionsCmd.Flags().String("to", "", "end date")
 	transactionsCmd.Flags().String("days", "", "number of days back from today")
+	transactionsCmd.Flags().String("period", "", "date range, e.g. last-month, 2026-Q1 or 2026-W14 (instead of --from/--to)")
 	transactionsCmd.Flags().Int("limit", 0, "max transactions to return (0=unlimited)")
 	transactionsCmd.Flags().String("status", "", "transaction status: BOOK or PDNG")
 	transactionsCmd.Flags().Bool("include-pending", false, "include pending transactions")
+	transactionsCmd.Flags().Int("window-days", 0, "longest date range per query; longer ranges are split (default: the connection's window_days, or 90)")
+	transactionsCmd.Flags().Bool("ndjson", false, "write one JSON object per line as pages arrive, instead of a single array (alias: --stream)")
+	transactionsCmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "stream" {
+			name = "ndjson" // the flag's first name
+		}
+		return pflag.NormalizedName(name)
+	})
+	transactionsCmd.Flags().Bool("with-status", false, "add how the fetch of each account went: an object with accounts and transactions, or account_status lines with --ndjson")
+	addStrictFlag(transactionsCmd)
+	addQueryFlags(transactionsCmd, transactionAliases, true)
 	rootCmd.AddCommand(transactionsCmd)
 }
 
@@ -33,28 +47,43 @@ func runTransactions(cmd *cobra.Command, args []string) error {
 	fromFlag, _ := cmd.Flags().GetString("from")
 	toFlag, _ := cmd.Flags().GetString("to")
 	daysFlag, _ := cmd.Flags().GetString("days")
+	periodFlag, _ := cmd.Flags().GetString("period")
 	limit, _ := cmd.Flags().GetInt("limit")
 	statusFlag, _ := cmd.Flags().GetString("status")
 	includePending, _ := cmd.Flags().GetBool("include-pending")
+	windowDays, _ := cmd.Flags().GetInt("window-days")
+	ndjson, _ := cmd.Flags().GetBool("ndjson")
+	withStatus, _ := cmd.Flags().GetBool("with-status")
+
+	q, err := parseQuery(cmd, annotatedTransaction{}, transactionAliases)
+	if err != nil {
+		return ExitWithError(ExitUserError, "%v", err)
+	}
+	if q.sort != nil && ndjson {
+		return ExitWithError(ExitUserError, "--sort cannot be combined with --ndjson, as transactions are written as they arrive")
+	}
+	if app.Printer.Format() == output.FormatTable && ndjson {
+		return ExitWithError(ExitUserError, "--format table cannot be combined with --ndjson")
+	}
+	if app.Printer.Format() == output.FormatTable && withStatus {
+		return ExitWithError(ExitUserError, "--format table cannot be combined with --with-status")
+	}
 
 	accounts, err := resolveAccounts(accountFlag)
 	if err != nil {
 		return err
 	}
 
-	accounts = checkDailyLimits(accounts)
+	accounts, limitErr := checkDailyLimits(accounts)
 	if len(accounts) == 0 {
-		return ExitWithError(ExitAPIError, "all accounts skipped due to daily limits")
+		return allDailyLimited(limitErr)
 	}
 
-	fromDate, toDate, err := parseDateRange(fromFlag, toFlag, daysFlag)
+	fromDate, toDate, err := parseDateRange(fromFlag, toFlag, daysFlag, periodFlag)
 	if err != nil {
 		return ExitWithError(ExitUserError, "%v", err)
 	}
 
-	dateFrom := fromDate.Format("2006-01-02")
-	dateTo := toDate.Format("2006-01-02")
-
 	// Determine statuses to fetch
 	statuses := []string{"BOOK"}
 	if statusFlag != "" {
@@ -64,68 +93,139 @@ func runTransactions(cmd *cobra.Command, args []string) error {
 		statuses = []string{"BOOK", "PDNG"}
 	}
 
+	// With --ndjson, transactions are written as they arrive instead of
+	// being collected into one array
+	var stream *output.Stream
+	var writeErr error
+	if ndjson {
+		stream = app.Printer.Stream()
+	}
+
+	// The limit applies after sorting, so everything is fetched to sort
+	sortedLimit := 0
+	if q.sort != nil {
+		sortedLimit, limit = limit, 0
+	}
+
 	var allTxns []annotatedTransaction
+	var report fetchReport
+	accountStatus := make([]api.AccountStatus, 0, len(accounts))
 	for _, ra := range accounts {
+		accountStatus = append(accountStatus, api.AccountStatus{Account: ra.Account.Alias, IBAN: ra.Account.IBAN})
+		st := &accountStatus[len(accountStatus)-1]
 		for _, status := range statuses {
-			txns, err := fetchAllTransactions(ctx, ra, dateFrom, dateTo, status, limit)
+			opts := fetchOptions{status: status, limit: limit, windowDays: windowDays, pages: &st.PagesFetched}
+			if q.filter != nil {
+				opts.keep = func(txn annotatedTransaction) bool { return q.match(transactionRecord(txn)) }
+			}
+			if stream != nil {
+				if limit > 0 {
+					if opts.limit = limit - stream.Count(); opts.limit <= 0 {
+						break
+					}
+				}
+				opts.emit = func(txn annotatedTransaction) error {
+					if writeErr = stream.Write(q.transactionOutput(txn)); writeErr == nil {
+						st.Transactions++
+					}
+					return writeErr
+				}
+			}
+
+			txns, failed, err := fetchAllTransactions(ctx, ra, fromDate, toDate, opts)
+			if writeErr != nil {
+				recordDailyAccess(accounts)
+				return ExitWithError(ExitUserError, "writing output: %v", writeErr)
+			}
 			if err != nil {
-				app.Printer.Warn("failed to fetch %s transactions for %s: %v", status, ra.Account.Alias, err)
+				warnAccount(ra, err, "failed to fetch %s transactions for %s: %v", status, ra.Account.Alias, err)
+				st.Errors = append(st.Errors, report.accountError(ra, "transactions", err))
 				continue
 			}
+			warnFailedWindows(ra, failed)
+			if len(failed) > 0 {
+				report.fail(ra, failed[0].Err)
+				st.FailedWindows = append(st.FailedWindows, failedWindowsOutput(failed)...)
+			}
 			allTxns = append(allTxns, txns...)
 		}
 
+		if stream != nil && limit > 0 && stream.Count() >= limit {
+			break
+		}
 		if limit > 0 && len(allTxns) >= limit {
 			allTxns = allTxns[:limit]
 			break
 		}
 	}
 
+	recordDailyAccess(accounts)
+	for i := range accountStatus {
+		st := &accountStatus[i]
+		st.FetchComplete = len(st.Errors) == 0 && len(st.FailedWindows) == 0
+	}
+	if stream != nil {
+		if withStatus {
+			for _, st := range accountStatus {
+				if err := stream.Write(map[string]api.AccountStatus{"account_status": st}); err != nil {
+					return ExitWithError(ExitUserError, "writing output: %v", err)
+				}
+			}
+		}
+		return report.strictError(cmd)
+	}
+
+	q.sortTransactions(allTxns)
+	if sortedLimit > 0 && len(allTxns) > sortedLimit {
+		allTxns = allTxns[:sortedLimit]
+	}
 	if allTxns == nil {
 		allTxns = []annotatedTransaction{}
 	}
-
-	recordDailyAccess(accounts)
-	return app.Printer.JSON(allTxns)
-}
-
-type annotatedTransaction struct {
-	Account string `json:"account"`
-	IBAN    string `json:"iban,omitempty"`
-	api.Transaction
+	if err := printTransactions(q, allTxns, accountStatus, withStatus); err != nil {
+		return err
+	}
+	return report.strictError(cmd)
 }
 
-func fetchAllTransactions(ctx context.Context, ra resolver.Result, dateFrom, dateTo, status string, limit int) ([]annotatedTransaction, error) {
-	var all []annotatedTransaction
-	continuationKey := ""
-
-	for {
-		resp, err := app.Client.GetTransactions(ctx, ra.Account.UID, api.TransactionParams{
-			DateFrom:          dateFrom,
-			DateTo:            dateTo,
-			ContinuationKey:   continuationKey,
-			TransactionStatus: status,
-		}, ra.RequiredPSUHeaders)
-		if err != nil {
-			return all, err
+// printTransactions writes the transactions, projected onto --fields if set,
+// and with the status of each account for --with-status.
+func printTransactions(q query, txns []annotatedTransaction, accountStatus []api.AccountStatus, withStatus bool) error {
+	if withStatus {
+		// Counted here, as transactions of the accounts may be cut by --limit
+		for i := range accountStatus {
+			accountStatus[i].Transactions = 0
+			for _, txn := range txns {
+				if txn.Account == accountStatus[i].Account {
+					accountStatus[i].Transactions++
+				}
+			}
 		}
-
-		for _, txn := range resp.Transactions {
-			all = append(all, annotatedTransaction{
-				Account:     ra.Account.Alias,
-				IBAN:        ra.Account.IBAN,
-				Transaction: txn,
-			})
-			if limit > 0 && len(all) >= limit {
-				return all, nil
+		var out interface{} = txns
+		if q.fields != nil {
+			rows := make([]interface{}, len(txns))
+			for i, txn := range txns {
+				rows[i] = q.transactionOutput(txn)
 			}
+			out = rows
 		}
-
-		if resp.ContinuationKey == "" {
-			break
+		return app.Printer.JSON(struct {
+			Accounts     []api.AccountStatus `json:"accounts"`
+			Transactions interface{}         `json:"transactions"`
+		}{accountStatus, out})
+	}
+	if q.fields != nil {
+		rows := make([]filter.Row, len(txns))
+		for i, txn := range txns {
+			rows[i] = filter.Project(transactionRecord(txn), q.fields)
 		}
-		continuationKey = resp.ContinuationKey
+		return printRows(q.fields, rows)
 	}
+	return printResult(txns, transactionsTable)
+}
 
-	return all, nil
+type annotatedTransaction struct {
+	Account string `json:"account"`
+	IBAN    string `json:"iban,omitempty"`
+	api.Transaction
 }
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+	"github.com/nicolasacchi/ebcli/internal/config"
+	"github.com/nicolasacchi/ebcli/internal/resolver"
+)
+
+// defaultWindowDays is the longest date range fetched in one transactions
+// query unless the connection or --window-days says otherwise. Many banks
+// reject longer spans.
+const defaultWindowDays = 90
+
+// dateWindow is an inclusive range of days fetched with one query.
+type dateWindow struct {
+	From, To time.Time
+}
+
+func (w dateWindow) String() string {
+	return w.From.Format("2006-01-02") + ".." + w.To.Format("2006-01-02")
+}
+
+// failedWindow is a window whose transactions are missing from the result.
+type failedWindow struct {
+	dateWindow
+	Err error
+}
+
+// splitWindows splits from..to into consecutive windows of at most days days,
+// oldest first. Windows are counted back from to, so only the oldest one can
+// be shorter.
+func splitWindows(from, to time.Time, days int) []dateWindow {
+	if days <= 0 {
+		return []dateWindow{{from, to}}
+	}
+	var windows []dateWindow
+	for end := to; !end.Before(from); end = end.AddDate(0, 0, -days) {
+		start := end.AddDate(0, 0, 1-days)
+		if start.Before(from) {
+			start = from
+		}
+		windows = append([]dateWindow{{start, end}}, windows...)
+	}
+	return windows
+}
+
+// fetchOptions control fetchAllTransactions.
+type fetchOptions struct {
+	status     string // BOOK or PDNG
+	limit      int    // max transactions; 0 = unlimited
+	windowDays int    // 0 = the connection's window_days, or defaultWindowDays
+
+	// keep, if set, selects the transactions to return; the others are
+	// dropped as they arrive and don't count towards limit.
+	keep func(annotatedTransaction) bool
+
+	// emit, if set, receives each transaction as its page arrives, newest
+	// window first, instead of the transactions being collected and
+	// returned. An error from emit stops the fetch and is returned.
+	emit func(annotatedTransaction) error
+
+	// pages, if set, is incremented for each page of transactions the bank
+	// returns.
+	pages *int
+}
+
+// fetchAllTransactions fetches the transactions of an account between from
+// and to, in windows of at most opts.windowDays days. Windows are fetched
+// newest first and merged in the bank's order, dropping transactions that a
+// neighbouring window already returned. Windows that fail are reported
+// rather than failing the whole fetch; the error is only set if nothing
+// could be fetched.
+//
+// Windows older than the bank's history (the connection's max_lookback_days)
+// are reported as failed without querying the bank. When the bank rejects a
+// window for being too old and says how much history it keeps, that limit is
+// saved; without a number, it is guessed from the oldest window that worked,
+// for this fetch only. When the bank says how long a window may be, the
+// remaining windows are split again and window_days is saved.
+func fetchAllTransactions(ctx context.Context, ra resolver.Result, from, to time.Time, opts fetchOptions) ([]annotatedTransaction, []failedWindow, error) {
+	conn := ra.Connection
+	if current, err := app.Config.FindConnection(conn.Name); err == nil {
+		conn = *current // may have learned its lookback since the account was resolved
+	}
+	windowDays := opts.windowDays
+	if windowDays <= 0 {
+		windowDays = conn.WindowDays
+	}
+	if windowDays <= 0 {
+		windowDays = defaultWindowDays
+	}
+	today := calendarToday()
+	lookback, guessed := conn.MaxLookbackDays, false
+	tooOld := func() error {
+		if guessed {
+			return fmt.Errorf("older than the %d days of history %s seems to provide", lookback, conn.ASPSPName)
+		}
+		return fmt.Errorf("older than the %d days of history %s provides (connections.%s.max_lookback_days; unset it to try older dates)", lookback, conn.ASPSPName, conn.Name)
+	}
+	annotate := func(txn api.Transaction) annotatedTransaction {
+		return annotatedTransaction{Account: ra.Account.Alias, IBAN: ra.Account.IBAN, Transaction: txn}
+	}
+
+	windows := splitWindows(from, to, windowDays)
+	results := make([][]api.Transaction, len(windows))
+	seen := map[string]bool{} // keys of emitted transactions from earlier windows
+	var failed []failedWindow
+	fetched, total := 0, 0
+	var oldestOK time.Time
+
+	for i := len(windows) - 1; i >= 0; i-- {
+		w := windows[i]
+		if opts.limit > 0 && total >= opts.limit {
+			break
+		}
+
+		if lookback > 0 {
+			earliest := today.AddDate(0, 0, -lookback)
+			if w.To.Before(earliest) {
+				failed = append(failed, failedWindow{w, tooOld()})
+				continue
+			}
+			if w.From.Before(earliest) {
+				failed = append(failed, failedWindow{dateWindow{w.From, earliest.AddDate(0, 0, -1)}, tooOld()})
+				w.From = earliest
+			}
+		}
+
+		var txns []api.Transaction
+		var keys []string
+		var emitErr error
+		err := fetchWindow(ctx, ra, w, opts.status, func(page []api.Transaction) bool {
+			if opts.pages != nil {
+				*opts.pages++
+			}
+			for _, txn := range page {
+				if opts.keep != nil && !opts.keep(annotate(txn)) {
+					continue
+				}
+				if opts.emit == nil {
+					txns = append(txns, txn)
+				} else {
+					key := transactionKey(txn)
+					if seen[key] {
+						continue
+					}
+					keys = append(keys, key)
+					if emitErr = opts.emit(annotate(txn)); emitErr != nil {
+						return false
+					}
+				}
+				total++
+				if opts.limit > 0 && total >= opts.limit {
+					return false
+				}
+			}
+			return true
+		})
+		if emitErr != nil {
+			return nil, nil, emitErr
+		}
+		if limit, ok := dateLimitError(err); ok {
+			switch {
+			case limit.lookback && limit.days > 0 && (lookback == 0 || guessed || limit.days < lookback):
+				lookback, guessed = limit.days, false
+				rememberLookback(conn.Name, limit.days)
+				i++ // retry the window, now clamped to the history
+				continue
+			case limit.lookback && limit.days == 0 && lookback == 0 && !oldestOK.IsZero():
+				lookback, guessed = int(today.Sub(oldestOK).Hours()/24), true
+				i++
+				continue
+			case !limit.lookback && limit.days > 0 && limit.days < windowDays:
+				// Split what is left into shorter windows: the older windows
+				// too, unless they are past the history and never fetched
+				windowDays = limit.days
+				rememberWindowDays(conn.Name, limit.days)
+				start, keep := windows[0].From, 0
+				if !w.From.Equal(windows[i].From) {
+					start, keep = w.From, i
+				}
+				rest := splitWindows(start, w.To, windowDays)
+				windows = append(append(windows[:keep:keep], rest...), windows[i+1:]...)
+				results = append(append(results[:keep:keep], make([][]api.Transaction, len(rest))...), results[i+1:]...)
+				i = keep + len(rest)
+				continue
+			}
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+		if err != nil {
+			failed = append(failed, failedWindow{w, err})
+			continue
+		}
+
+		results[i] = txns
+		fetched++
+		oldestOK = w.From
+	}
+
+	// Windows were tried newest first; report them oldest first, joining
+	// neighbours that failed for the same reason
+	sort.Slice(failed, func(i, j int) bool { return failed[i].From.Before(failed[j].From) })
+	var joined []failedWindow
+	for _, f := range failed {
+		if n := len(joined); n > 0 && joined[n-1].To.AddDate(0, 0, 1).Equal(f.From) && joined[n-1].Err.Error() == f.Err.Error() {
+			joined[n-1].To = f.To
+			continue
+		}
+		joined = append(joined, f)
+	}
+	failed = joined
+	if fetched == 0 && len(failed) > 0 {
+		return nil, failed, failed[len(failed)-1].Err
+	}
+
+	var all []annotatedTransaction
+	for _, txn := range mergeWindows(results) {
+		all = append(all, annotate(txn))
+	}
+	if opts.limit > 0 && len(all) > opts.limit {
+		all = all[:opts.limit]
+	}
+	return all, failed, nil
+}
+
+// fetchWindow fetches the pages of one window, passing each to page until it
+// returns false or there are no more.
+func fetchWindow(ctx context.Context, ra resolver.Result, w dateWindow, status string, page func([]api.Transaction) bool) error {
+	continuationKey := ""
+	for {
+		resp, err := app.Client.GetTransactions(ctx, ra.Account.UID, api.TransactionParams{
+			DateFrom:          w.From.Format("2006-01-02"),
+			DateTo:            w.To.Format("2006-01-02"),
+			ContinuationKey:   continuationKey,
+			TransactionStatus: status,
+		}, ra.RequiredPSUHeaders)
+		if err != nil {
+			return err
+		}
+
+		if !page(resp.Transactions) || resp.ContinuationKey == "" {
+			return nil
+		}
+		continuationKey = resp.ContinuationKey
+	}
+}
+
+// mergeWindows concatenates the transactions of consecutive windows (oldest
+// first) in the order the bank lists them, newest first if that is how the
+// bank sorts. A transaction already seen in another window is dropped, as
+// banks don't all filter on the same date; identical transactions within a
+// window are kept.
+func mergeWindows(windows [][]api.Transaction) []api.Transaction {
+	if bankListsNewestFirst(windows) {
+		reversed := make([][]api.Transaction, len(windows))
+		for i, w := range windows {
+			reversed[len(windows)-1-i] = w
+		}
+		windows = reversed
+	}
+
+	var merged []api.Transaction
+	seen := map[string]bool{}
+	for _, w := range windows {
+		var keys []string
+		for _, txn := range w {
+			key := transactionKey(txn)
+			if seen[key] {
+				continue
+			}
+			keys = append(keys, key)
+			merged = append(merged, txn)
+		}
+		for _, key := range keys {
+			seen[key] = true
+		}
+	}
+	return merged
+}
+
+// bankListsNewestFirst reports whether the first window with transactions on
+// different days lists them in descending date order.
+func bankListsNewestFirst(windows [][]api.Transaction) bool {
+	for _, w := range windows {
+		if len(w) < 2 {
+			continue
+		}
+		first, last := transactionDate(w[0]), transactionDate(w[len(w)-1])
+		if first != last && first != "" && last != "" {
+			return first > last
+		}
+	}
+	return false
+}
+
+func transactionDate(txn api.Transaction) string {
+	for _, d := range []string{txn.BookingDate, txn.ValueDate, txn.TransactionDate} {
+		if d != "" {
+			return d
+		}
+	}
+	return ""
+}
+
+// transactionKey identifies a transaction across queries: by the bank's
+// transaction ID or entry reference, or else by its contents.
+func transactionKey(txn api.Transaction) string {
+	if txn.TransactionID != "" {
+		return "id:" + txn.TransactionID
+	}
+	if txn.EntryReference != "" {
+		return "ref:" + txn.EntryReference
+	}
+	return strings.Join([]string{
+		"txn", txn.Status, txn.BookingDate, txn.ValueDate, txn.TransactionDate,
+		txn.TransactionAmount.Amount, txn.TransactionAmount.Currency, txn.CreditDebitIndicator,
+		txn.CreditorName, txn.DebtorName, strings.Join(txn.RemittanceInformation, "\n"),
+	}, "\x00")
+}
+
+var limitDaysPattern = regexp.MustCompile(`(\d+)\s*(day|month|year)`)
+
+// dateLimit is a limit on the dates of a transactions query stated by a bank.
+type dateLimit struct {
+	lookback bool // on how far back the query reaches; else on its span
+	days     int  // 0 if the bank doesn't say
+}
+
+// dateLimitError reports whether err is the bank refusing a date range for
+// reaching too far back or spanning too long, and which. Errors that only
+// mention dates are neither.
+func dateLimitError(err error) (dateLimit, bool) {
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) || (apiErr.StatusCode != 400 && apiErr.StatusCode != 422) {
+		return dateLimit{}, false
+	}
+	text := strings.ToLower(strings.ReplaceAll(apiErr.ErrorCode+" "+apiErr.ErrorDescription, "_", " "))
+	var limit dateLimit
+	switch {
+	case containsAny(text, "history", "historical", "too old", "older than", "too far back", "in the past", " ago"):
+		limit.lookback = true
+	case containsAny(text, "exceed", "span", "range", "too long", "too large", "longer than", "maximum period", "max period"):
+	default:
+		return dateLimit{}, false
+	}
+	if m := limitDaysPattern.FindStringSubmatch(text); m != nil {
+		limit.days, _ = strconv.Atoi(m[1])
+		switch m[2] {
+		case "month":
+			limit.days *= 30
+		case "year":
+			limit.days *= 365
+		}
+	}
+	return limit, true
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// rememberLookback saves how many days of history a connection's bank
+// provides, so later fetches don't ask for more.
+func rememberLookback(connName string, days int) {
+	conn, err := app.Config.FindConnection(connName)
+	if err != nil || conn.MaxLookbackDays == days {
+		return
+	}
+	conn.MaxLookbackDays = days
+	app.Printer.Info("%s provides %d days of transaction history; older dates are skipped from now on (connections.%s.max_lookback_days)", conn.ASPSPName, days, conn.Name)
+	if err := config.Save(app.ConfigPath, app.Config); err != nil {
+		app.Printer.Warn("saving max_lookback_days: %v", err)
+	}
+}
+
+// rememberWindowDays saves the longest date range a connection's bank
+// accepts in one query, so later fetches use shorter windows.
+func rememberWindowDays(connName string, days int) {
+	conn, err := app.Config.FindConnection(connName)
+	if err != nil || conn.WindowDays == days {
+		return
+	}
+	conn.WindowDays = days
+	app.Printer.Info("%s accepts at most %d days per query; transactions are fetched in windows of %d days from now on (connections.%s.window_days)", conn.ASPSPName, days, days, conn.Name)
+	if err := config.Save(app.ConfigPath, app.Config); err != nil {
+		app.Printer.Warn("saving window_days: %v", err)
+	}
+}
+
+// warnFailedWindows reports the windows of an account that could not be fetched.
+func warnFailedWindows(ra resolver.Result, failed []failedWindow) {
+	alias := ra.Account.Alias
+	for _, f := range failed {
+		warnAccount(ra, f.Err, "transactions for %s from %s missing: %v", alias, f.dateWindow, f.Err)
+		app.Printer.Event("window_failed", map[string]interface{}{
+			"account": alias, "from": f.From.Format("2006-01-02"), "to": f.To.Format("2006-01-02"), "error": f.Err.Error(),
+		})
+	}
+}
+
+// failedWindowsOutput converts failed windows for JSON output.
+func failedWindowsOutput(failed []failedWindow) []api.FailedWindow {
+	var out []api.FailedWindow
+	for _, f := range failed {
+		out = append(out, api.FailedWindow{From: f.From.Format("2006-01-02"), To: f.To.Format("2006-01-02"), Error: f.Err.Error()})
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/api"
+)
+
+func TestSplitWindows(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	got := splitWindows(day(1), day(25), 10)
+	want := []dateWindow{{day(1), day(5)}, {day(6), day(15)}, {day(16), day(25)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitWindows = %v, want %v", got, want)
+	}
+	if got := splitWindows(day(1), day(1), 90); len(got) != 1 || got[0] != (dateWindow{day(1), day(1)}) {
+		t.Errorf("single day = %v", got)
+	}
+}
+
+func TestMergeWindows(t *testing.T) {
+	txn := func(ref, date string) api.Transaction {
+		return api.Transaction{EntryReference: ref, BookingDate: date}
+	}
+	coffee := api.Transaction{BookingDate: "2026-01-31", TransactionAmount: api.Amount{Amount: "3.20", Currency: "EUR"}}
+
+	// The bank lists newest first and returns "b" in both windows
+	windows := [][]api.Transaction{
+		{txn("b", "2026-01-31"), coffee, coffee, txn("a", "2026-01-02")},
+		{txn("c", "2026-02-10"), txn("b", "2026-01-31")},
+	}
+	var refs []string
+	for _, t := range mergeWindows(windows) {
+		refs = append(refs, t.EntryReference+t.TransactionAmount.Amount)
+	}
+	want := []string{"c", "b", "3.20", "3.20", "a"}
+	if !reflect.DeepEqual(refs, want) {
+		t.Errorf("merged = %v, want %v", refs, want)
+	}
+}
+
+func TestDateLimitError(t *testing.T) {
+	tests := []struct {
+		err   error
+		limit dateLimit
+		ok    bool
+	}{
+		{&api.APIError{StatusCode: 422, ErrorCode: "Transaction history is limited to 13 months"}, dateLimit{lookback: true, days: 390}, true},
+		{&api.APIError{StatusCode: 400, ErrorCode: "WRONG_REQUEST_PARAMETERS", ErrorDescription: "date_from cannot be more than 2 years ago"}, dateLimit{lookback: true, days: 730}, true},
+		{fmt.Errorf("fetching: %w", &api.APIError{StatusCode: 400, ErrorCode: "DATE_FROM_TOO_OLD"}), dateLimit{lookback: true}, true},
+		{&api.APIError{StatusCode: 400, ErrorCode: "WRONG_TRANSACTIONS_PERIOD", ErrorDescription: "period cannot exceed 90 days"}, dateLimit{days: 90}, true},
+		{&api.APIError{StatusCode: 422, ErrorCode: "Date range too long"}, dateLimit{}, true},
+		{&api.APIError{StatusCode: 400, ErrorCode: "WRONG_TRANSACTIONS_PERIOD", ErrorDescription: "date_from must be within 90 days"}, dateLimit{}, false},
+		{&api.APIError{StatusCode: 400, ErrorCode: "WRONG_REQUEST_PARAMETERS"}, dateLimit{}, false},
+		{&api.APIError{StatusCode: 500, ErrorCode: "history backend down"}, dateLimit{}, false},
+		{nil, dateLimit{}, false},
+	}
+	for _, tt := range tests {
+		limit, ok := dateLimitError(tt.err)
+		if limit != tt.limit || ok != tt.ok {
+			t.Errorf("dateLimitError(%v) = %+v, %v; want %+v, %v", tt.err, limit, ok, tt.limit, tt.ok)
+		}
+	}
+}
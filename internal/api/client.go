@@ -7,11 +7,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"github.com/nicolasacchi/ebcli/internal/audit"
 	"github.com/nicolasacchi/ebcli/internal/auth"
 	"github.com/nicolasacchi/ebcli/internal/psu"
 	"github.com/nicolasacchi/ebcli/internal/ratelimit"
@@ -31,6 +33,10 @@ type Client struct {
 	psuProvider *psu.Provider
 	rateLimiter *ratelimit.Tracker
 	version     string
+	auditLog    *audit.Log
+	connection  func(accountUID, sessionID string) string // for auditLog
+	logger      *slog.Logger
+	logBodies   bool
 }
 
 // ClientOption is a functional option for configuring the Client.
@@ -70,6 +76,12 @@ func WithVersion(v string) ClientOption {
 	return func(c *Client) { c.version = v }
 }
 
+// WithAuditLog records every request in l, with the connection that
+// connection finds for the account or session of the request.
+func WithAuditLog(l *audit.Log, connection func(accountUID, sessionID string) string) ClientOption {
+	return func(c *Client) { c.auditLog, c.connection = l, connection }
+}
+
 // doRequest performs an authenticated HTTP request with retry logic.
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, result interface{}, requiredPSUHeaders []string) ([]byte, error) {
 	return c.doRequestWithRetry(ctx, method, path, body, result, requiredPSUHeaders, true)
@@ -84,12 +96,13 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, path string, bo
 
 	// Marshal body
 	var bodyReader io.Reader
+	var reqBody []byte
 	if body != nil {
-		data, err := json.Marshal(body)
+		reqBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("marshaling request body: %w", err)
 		}
-		bodyReader = bytes.NewReader(data)
+		bodyReader = bytes.NewReader(reqBody)
 	}
 
 	// Create request
@@ -117,9 +130,20 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, path string, bo
 	}
 
 	// Execute request
+	attempt := 1
+	if !canRetry {
+		attempt = 2
+	}
+	c.logRequest(ctx, req, reqBody, attempt)
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		if auditErr := c.audit(method, path, start, nil, 0, err); auditErr != nil {
+			return nil, auditErr
+		}
+		c.logResponse(ctx, req, nil, nil, time.Since(start), attempt, err)
 		if canRetry {
+			c.logRetry(ctx, method, path, "network error", 500*time.Millisecond)
 			time.Sleep(500 * time.Millisecond)
 			return c.doRequestWithRetry(ctx, method, path, body, result, requiredPSUHeaders, false)
 		}
@@ -128,6 +152,11 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, path string, bo
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	// Nothing is returned that the audit log does not know of
+	if auditErr := c.audit(method, path, start, resp, len(respBody), err); auditErr != nil {
+		return nil, auditErr
+	}
+	c.logResponse(ctx, req, resp, respBody, time.Since(start), attempt, err)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
@@ -148,17 +177,20 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, path string, bo
 				c.rateLimiter.RecordRetryAfter(accountUID, endpoint, retryAt)
 				waitDur := time.Until(retryAt)
 				if waitDur > 0 && waitDur < 5*time.Minute {
+					c.logRetry(ctx, method, path, "rate limited, Retry-After", waitDur)
 					time.Sleep(waitDur)
 					return c.doRequestWithRetry(ctx, method, path, body, result, requiredPSUHeaders, false)
 				}
 			}
 		}
 		// Default backoff for 429
+		c.logRetry(ctx, method, path, "rate limited", 2*time.Second)
 		time.Sleep(2 * time.Second)
 		return c.doRequestWithRetry(ctx, method, path, body, result, requiredPSUHeaders, false)
 	}
 
 	if resp.StatusCode >= 500 && canRetry {
+		c.logRetry(ctx, method, path, fmt.Sprintf("status %d", resp.StatusCode), 500*time.Millisecond)
 		time.Sleep(500 * time.Millisecond)
 		return c.doRequestWithRetry(ctx, method, path, body, result, requiredPSUHeaders, false)
 	}
@@ -171,7 +203,13 @@ func (c *Client) doRequestWithRetry(ctx context.Context, method, path string, bo
 				Wrapped:   parseAPIError(resp.StatusCode, respBody),
 			}
 		}
-		return nil, parseAPIError(resp.StatusCode, respBody)
+		apiErr := parseAPIError(resp.StatusCode, respBody)
+		if resp.StatusCode == 429 {
+			if retryAt, ok := ratelimit.ParseRetryAfter(resp); ok {
+				apiErr.RetryAfter = max(0, time.Until(retryAt))
+			}
+		}
+		return nil, apiErr
 	}
 
 	// Success — unmarshal if result provided
@@ -291,6 +329,46 @@ func (c *Client) DoRaw(ctx context.Context, method, path string, body interface{
 
 // --- Helpers ---
 
+// audit records a request in the audit log: its response, or the error that
+// prevented one.
+func (c *Client) audit(method, path string, start time.Time, resp *http.Response, size int, err error) error {
+	if c.auditLog == nil {
+		return nil
+	}
+	// Session IDs grant access to accounts, so only the connection they
+	// belong to is recorded
+	sessionID := extractSessionID(path)
+	r := audit.Record{
+		Time:       start,
+		Method:     method,
+		Endpoint:   hideSessionID(path, sessionID),
+		Account:    extractAccountUID(path),
+		Bytes:      size,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if i := strings.Index(r.Endpoint, "?"); i != -1 {
+		r.Endpoint = r.Endpoint[:i]
+	}
+	if c.connection != nil {
+		r.Connection = c.connection(r.Account, sessionID)
+	}
+	if resp != nil {
+		r.Status = resp.StatusCode
+		for _, h := range audit.RateLimitHeaders {
+			if v := resp.Header.Get(h); v != "" {
+				if r.RateLimit == nil {
+					r.RateLimit = map[string]string{}
+				}
+				r.RateLimit[strings.ToLower(h)] = v
+			}
+		}
+	}
+	if err != nil {
+		r.Error = hideSessionID(err.Error(), sessionID) // URL errors quote the path
+	}
+	return c.auditLog.Append(r)
+}
+
 func parseAPIError(statusCode int, body []byte) *APIError {
 	apiErr := &APIError{StatusCode: statusCode}
 	// Try to parse as Enable Banking error format
@@ -332,6 +410,29 @@ func extractAccountUID(path string) string {
 	return ""
 }
 
+// extractSessionID extracts the session ID from API paths like /sessions/{id}.
+func extractSessionID(path string) string {
+	if idx := strings.Index(path, "?"); idx != -1 {
+		path = path[:idx]
+	}
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) >= 2 && parts[0] == "sessions" {
+		return parts[1]
+	}
+	return ""
+}
+
+// sessionIDPlaceholder replaces session IDs in the audit and debug logs.
+const sessionIDPlaceholder = "{session_id}"
+
+// hideSessionID replaces sessionID in s with sessionIDPlaceholder.
+func hideSessionID(s, sessionID string) string {
+	if sessionID == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, sessionID, sessionIDPlaceholder)
+}
+
 // extractEndpoint extracts the endpoint name from API paths.
 func extractEndpoint(path string) string {
 	// Strip query string
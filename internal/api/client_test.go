@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/nicolasacchi/ebcli/internal/audit"
 	"github.com/nicolasacchi/ebcli/internal/auth"
 )
 
@@ -188,6 +192,22 @@ func TestClient_APIError(t *testing.T) {
 	}
 }
 
+func TestClient_RetryAfter(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+
+	_, err := client.GetBalances(context.Background(), "test-uid", nil)
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		t.Fatalf("error = %v, want *APIError", err)
+	}
+	if apiErr.RetryAfter < 590*time.Second || apiErr.RetryAfter > 600*time.Second {
+		t.Errorf("RetryAfter = %v, want about 10m", apiErr.RetryAfter)
+	}
+}
+
 func TestClient_RateLimitHeaders(t *testing.T) {
 	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Ratelimit-Remaining", "3")
@@ -203,6 +223,86 @@ func TestClient_RateLimitHeaders(t *testing.T) {
 	// Rate limit headers are tracked silently — no error expected
 }
 
+func TestClient_AuditLog(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Remaining", "3")
+		w.Header().Set("X-Ratelimit-Limit", "10")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(TransactionsResponse{Transactions: []Transaction{{EntryReference: "secret-ref"}}})
+	}))
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	WithAuditLog(audit.Open(path, "ebcli transactions"), func(accountUID, sessionID string) string {
+		return "conn-of-" + accountUID
+	})(client)
+
+	if _, err := client.GetTransactions(context.Background(), "acc-1", TransactionParams{DateFrom: "2026-01-01"}, nil); err != nil {
+		t.Fatalf("GetTransactions: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-ref") || strings.Contains(string(data), "date_from") {
+		t.Errorf("audit log has the payload or query: %s", data)
+	}
+	records, _, err := audit.Read(path, nil)
+	if err != nil || len(records) != 1 {
+		t.Fatalf("Read = %v, %v", records, err)
+	}
+	r := records[0]
+	if r.Command != "ebcli transactions" || r.Method != "GET" || r.Endpoint != "/accounts/acc-1/transactions" ||
+		r.Account != "acc-1" || r.Connection != "conn-of-acc-1" || r.Status != 200 || r.Outcome != audit.OutcomeOK || r.Bytes == 0 {
+		t.Errorf("record = %+v", r)
+	}
+	if r.RateLimit["x-ratelimit-remaining"] != "3" || r.RateLimit["x-ratelimit-limit"] != "10" {
+		t.Errorf("RateLimit = %v", r.RateLimit)
+	}
+}
+
+func TestClient_AuditLog_SessionID(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"AUTHORIZED"}`))
+	}))
+	path := filepath.Join(t.TempDir(), audit.FileName)
+	var lookedUp string
+	WithAuditLog(audit.Open(path, "ebcli status"), func(accountUID, sessionID string) string {
+		lookedUp = sessionID
+		return "ing"
+	})(client)
+
+	if _, err := client.GetSession(context.Background(), "live-session-73c1"); err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "live-session-73c1") {
+		t.Errorf("audit log has the session ID: %s", data)
+	}
+	records, _, _ := audit.Read(path, nil)
+	if len(records) != 1 || records[0].Endpoint != "/sessions/{session_id}" || records[0].Connection != "ing" || lookedUp != "live-session-73c1" {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestClient_AuditLogFails(t *testing.T) {
+	calls := 0
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(BalancesResponse{Balances: []Balance{}})
+	}))
+	dir := filepath.Join(t.TempDir(), audit.FileName)
+	os.Mkdir(dir, 0700) // not a file, so it cannot be appended to
+	WithAuditLog(audit.Open(dir, "ebcli balances"), nil)(client)
+
+	if _, err := client.GetBalances(context.Background(), "acc-1", nil); err == nil || !strings.Contains(err.Error(), "audit log") {
+		t.Errorf("GetBalances error = %v, want an audit log error", err)
+	}
+}
+
 func TestExtractAccountUID(t *testing.T) {
 	tests := []struct {
 		path string
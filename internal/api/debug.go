@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/audit"
+	"github.com/nicolasacchi/ebcli/internal/redact"
+)
+
+// WithLogger logs every request and response at debug level to l, with
+// their bodies if bodies is set. The JWT, PSU IP addresses, session IDs,
+// authorization codes and IBANs are redacted.
+func WithLogger(l *slog.Logger, bodies bool) ClientOption {
+	return func(c *Client) { c.logger, c.logBodies = l, bodies }
+}
+
+// redactedHeaders are request headers whose values are never logged.
+var redactedHeaders = map[string]bool{
+	"authorization":  true,
+	"psu-ip-address": true,
+	"psu-ip-port":    true,
+}
+
+// redactedBodyKeys are JSON keys whose values are never logged: the
+// authorization code sent to create a session, and the session ID returned.
+var redactedBodyKeys = map[string]bool{
+	"code":       true,
+	"session_id": true,
+}
+
+const redacted = "[REDACTED]"
+
+func (c *Client) logRequest(ctx context.Context, req *http.Request, body []byte, attempt int) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", logPath(req.URL.RequestURI())),
+		slog.Int("attempt", attempt),
+		slog.Any("headers", logHeaders(req.Header)),
+	}
+	if c.logBodies && len(body) > 0 {
+		attrs = append(attrs, logBody(body))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "request", attrs...)
+}
+
+// logResponse logs the response to req, or the error that prevented one.
+func (c *Client) logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, latency time.Duration, attempt int, err error) {
+	if c.logger == nil {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", logPath(req.URL.RequestURI())),
+		slog.Int("attempt", attempt),
+		slog.Int64("latency_ms", latency.Milliseconds()),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode), slog.Int("bytes", len(body)))
+		var limits []any
+		for _, h := range audit.RateLimitHeaders {
+			if v := resp.Header.Get(h); v != "" {
+				limits = append(limits, slog.String(strings.ToLower(h), v))
+			}
+		}
+		if limits != nil {
+			attrs = append(attrs, slog.Group("rate_limit", limits...))
+		}
+		attrs = append(attrs, slog.Any("headers", logHeaders(resp.Header)))
+	}
+	if err != nil {
+		msg := hideSessionID(err.Error(), extractSessionID(req.URL.Path))
+		attrs = append(attrs, slog.String("error", redact.MaskIBANs(msg)))
+	}
+	if c.logBodies && len(body) > 0 {
+		attrs = append(attrs, logBody(body))
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "response", attrs...)
+}
+
+// logRetry logs that a request is retried after wait.
+func (c *Client) logRetry(ctx context.Context, method, path, reason string, wait time.Duration) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.LogAttrs(ctx, slog.LevelDebug, "retry",
+		slog.String("method", method),
+		slog.String("path", logPath(path)),
+		slog.String("reason", reason),
+		slog.Int64("wait_ms", wait.Milliseconds()),
+	)
+}
+
+// logHeaders returns h for the log, one value per name, with the values of
+// redactedHeaders replaced.
+func logHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name := range h {
+		v := strings.Join(h.Values(name), ", ")
+		if redactedHeaders[strings.ToLower(name)] {
+			v = redacted
+			if strings.EqualFold(name, "Authorization") && strings.HasPrefix(h.Get(name), "Bearer ") {
+				v = "Bearer " + redacted
+			}
+		}
+		out[name] = redact.MaskIBANs(v)
+	}
+	return out
+}
+
+// logPath returns the path of a request for the log, with its session ID
+// and IBANs hidden.
+func logPath(path string) string {
+	return redact.MaskIBANs(hideSessionID(path, extractSessionID(path)))
+}
+
+// logBody returns body for the log with its IBANs masked and the values of
+// redactedBodyKeys replaced: as JSON if it is, so the log stays queryable,
+// or as text.
+func logBody(body []byte) slog.Attr {
+	masked := redact.MaskIBANs(string(body))
+	if json.Valid([]byte(masked)) {
+		var doc any
+		dec := json.NewDecoder(strings.NewReader(masked))
+		dec.UseNumber()
+		if err := dec.Decode(&doc); err == nil {
+			if data, err := json.Marshal(redactKeys(doc)); err == nil {
+				return slog.Any("body", json.RawMessage(data))
+			}
+		}
+	}
+	return slog.String("body", masked)
+}
+
+// redactKeys replaces the values of redactedBodyKeys anywhere in doc.
+func redactKeys(doc any) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if redactedBodyKeys[key] {
+				v[key] = redacted
+			} else {
+				v[key] = redactKeys(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactKeys(value)
+		}
+	}
+	return doc
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_DebugLog(t *testing.T) {
+	calls := 0
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("X-Ratelimit-Remaining", "7")
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"account_id":{"iban":"FI2112345600000785"},"name":"Main"}`))
+	}))
+	var buf bytes.Buffer
+	WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), true)(client)
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Psu-Ip-Address", "203.0.113.7")
+	if got := logHeaders(req.Header)["Psu-Ip-Address"]; got != redacted {
+		t.Errorf("Psu-Ip-Address logged as %q", got)
+	}
+
+	if _, err := client.GetAccountDetails(context.Background(), "acc-1", nil); err != nil {
+		t.Fatalf("GetAccountDetails: %v", err)
+	}
+	log := buf.String()
+	for _, leak := range []string{"FI2112345600000785", "eyJ"} {
+		if strings.Contains(log, leak) {
+			t.Errorf("log contains %q:\n%s", leak, log)
+		}
+	}
+
+	var msgs []string
+	var last map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(log), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("not JSON: %s", line)
+		}
+		msgs = append(msgs, entry["msg"].(string))
+		last = entry
+	}
+	if strings.Join(msgs, ",") != "request,response,retry,request,response" {
+		t.Errorf("messages = %v", msgs)
+	}
+	if last["status"] != 200.0 || last["attempt"] != 2.0 || last["path"] != "/accounts/acc-1/details" {
+		t.Errorf("response = %v", last)
+	}
+	if rl, _ := last["rate_limit"].(map[string]any); rl["x-ratelimit-remaining"] != "7" {
+		t.Errorf("rate_limit = %v", last["rate_limit"])
+	}
+	if body, _ := last["body"].(map[string]any); body["name"] != "Main" {
+		t.Errorf("body = %v", last["body"])
+	}
+	if !strings.Contains(log, `"Authorization":"Bearer [REDACTED]"`) {
+		t.Errorf("Authorization not redacted:\n%s", log)
+	}
+}
+
+func TestClient_DebugLog_Session(t *testing.T) {
+	client := testClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "POST" {
+			w.Write([]byte(`{"session_id":"sess-5ecret","accounts":[{"uid":"acc-1","account_id":{"iban":"fi2112345600000785"}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"NOT_FOUND"}`))
+	}))
+	var buf bytes.Buffer
+	WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})), true)(client)
+
+	if _, err := client.CreateSession(context.Background(), "auth-c0de"); err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	client.GetSession(context.Background(), "sess-5ecret")
+
+	log := buf.String()
+	for _, leak := range []string{"auth-c0de", "sess-5ecret", "fi2112345600000785"} {
+		if strings.Contains(log, leak) {
+			t.Errorf("log contains %q:\n%s", leak, log)
+		}
+	}
+	if !strings.Contains(log, `"path":"/sessions/{session_id}"`) || !strings.Contains(log, `"uid":"acc-1"`) {
+		t.Errorf("log:\n%s", log)
+	}
+}
@@ -1,12 +1,19 @@
 package api
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // APIError represents an error response from the Enable Banking API.
 type APIError struct {
 	StatusCode       int    `json:"status_code"`
 	ErrorCode        string `json:"error"`
 	ErrorDescription string `json:"error_description,omitempty"`
+
+	// RetryAfter is how long to wait before retrying, from the Retry-After
+	// header of a 429 response; 0 if the API did not say.
+	RetryAfter time.Duration `json:"-"`
 }
 
 func (e *APIError) Error() string {
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// NewHTTPClient builds the HTTP client used to talk to the API.
+// proxyURL, if set, overrides the standard HTTPS_PROXY/NO_PROXY environment.
+// caBundle, if set, is a PEM file whose certificates are trusted in addition
+// to the system roots (for corporate TLS-intercepting proxies or mock servers).
+func NewHTTPClient(proxyURL, caBundle string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy URL: %w", err)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q (expected scheme://host:port)", proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(u)
+	}
+
+	if caBundle != "" {
+		pemData, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caBundle)
+		}
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return &http.Client{Timeout: requestTimeout, Transport: transport}, nil
+}
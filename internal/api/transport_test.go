@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewHTTPClient_CABundle(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	hc, err := NewHTTPClient("", bundle)
+	if err != nil {
+		t.Fatalf("NewHTTPClient: %v", err)
+	}
+	resp, err := hc.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("GET with CA bundle: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNoContent)
+	}
+}
+
+func TestNewHTTPClient_InvalidProxy(t *testing.T) {
+	if _, err := NewHTTPClient("localhost", ""); err == nil {
+		t.Fatal("expected error for proxy URL without scheme")
+	}
+}
+
+func TestNewHTTPClient_EmptyCABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "empty.pem")
+	os.WriteFile(bundle, []byte("not a certificate"), 0600)
+
+	if _, err := NewHTTPClient("", bundle); err == nil {
+		t.Fatal("expected error for CA bundle without certificates")
+	}
+}
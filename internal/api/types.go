@@ -227,21 +227,29 @@ type BankOutput struct {
 
 // AccountOutput is the JSON output for the accounts command.
 type AccountOutput struct {
+	ID                 string    `json:"id"` // stable across reconnects
 	UID                string    `json:"uid"`
 	IBAN               string    `json:"iban,omitempty"`
 	Alias              string    `json:"alias"`
 	Connection         string    `json:"connection"`
 	Currency           string    `json:"currency"`
 	CashAccountType    string    `json:"cash_account_type"`
+	Usage              string    `json:"usage,omitempty"` // PRIV or ORGA
+	PSUType            string    `json:"psu_type"`        // of the connection
+	Tags               []string  `json:"tags,omitempty"`
 	IdentificationHash string    `json:"identification_hash"`
+	PreviousUIDs       []string  `json:"previous_uids,omitempty"`
 	ValidUntil         time.Time `json:"valid_until"`
+	RetiredAt          *time.Time `json:"retired_at,omitempty"` // set for retired accounts
 }
 
 // BalanceOutput is the JSON output for the balances command.
 type BalanceOutput struct {
-	Account  string    `json:"account"`
-	IBAN     string    `json:"iban,omitempty"`
-	Balances []Balance `json:"balances"`
+	Account       string         `json:"account"`
+	IBAN          string         `json:"iban,omitempty"`
+	Balances      []Balance      `json:"balances"`
+	FetchComplete bool           `json:"fetch_complete"`
+	Errors        []AccountError `json:"errors,omitempty"`
 }
 
 // DumpOutput is the JSON output for the dump command.
@@ -252,10 +260,41 @@ type DumpOutput struct {
 
 // DumpAccountOutput represents a single account in the dump output.
 type DumpAccountOutput struct {
+	ID           string        `json:"id"`
 	Alias        string        `json:"alias"`
 	IBAN         string        `json:"iban,omitempty"`
 	Balances     []Balance     `json:"balances"`
 	Transactions []Transaction `json:"transactions"`
+	FailedWindows []FailedWindow `json:"failed_windows,omitempty"` // date ranges whose transactions are missing
+	FetchComplete bool           `json:"fetch_complete"`           // no errors and no failed windows
+	PagesFetched  int            `json:"pages_fetched"`            // transaction pages returned by the bank
+	Errors        []AccountError `json:"errors,omitempty"`
+}
+
+// AccountStatus is how the fetch of an account's transactions went, for
+// transactions --with-status.
+type AccountStatus struct {
+	Account       string         `json:"account"`
+	IBAN          string         `json:"iban,omitempty"`
+	FetchComplete bool           `json:"fetch_complete"`
+	PagesFetched  int            `json:"pages_fetched"`
+	Transactions  int            `json:"transactions"`
+	FailedWindows []FailedWindow `json:"failed_windows,omitempty"`
+	Errors        []AccountError `json:"errors,omitempty"`
+}
+
+// AccountError is a part of an account's data that could not be fetched.
+type AccountError struct {
+	Fetch   string `json:"fetch"` // "balances" or "transactions"
+	Kind    string `json:"kind"`  // as in --error-format json, e.g. "session_expired"
+	Message string `json:"message"`
+}
+
+// FailedWindow is a date range whose transactions could not be fetched.
+type FailedWindow struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Error string `json:"error"`
 }
 
 // StatusOutput is the JSON output for the status command.
@@ -264,11 +303,25 @@ type StatusOutput struct {
 	Connections []ConnectionStatus    `json:"connections"`
 }
 
+// ConsentOutput is one connection in the consents command output.
+type ConsentOutput struct {
+	Connection  string    `json:"connection"`
+	Bank        string    `json:"bank"`
+	Country     string    `json:"country"`
+	PSUType     string    `json:"psu_type"`
+	ValidUntil  time.Time `json:"valid_until"`
+	DaysLeft    int       `json:"days_left"`
+	Expired     bool      `json:"expired"`
+	Reconnected bool      `json:"reconnected,omitempty"`
+	Error       string    `json:"error,omitempty"` // why reconnecting failed
+}
+
 // ConnectionStatus shows the status of a single connection.
 type ConnectionStatus struct {
 	Name            string    `json:"name"`
 	Bank            string    `json:"bank"`
 	Country         string    `json:"country"`
+	PSUType         string    `json:"psu_type"`
 	Status          string    `json:"status"`
 	Accounts        int       `json:"accounts"`
 	ValidUntil      time.Time `json:"valid_until"`
@@ -0,0 +1,214 @@
+// Package audit keeps an append-only log of every request made to the
+// Enable Banking API: when, by whom, from which command, for which account,
+// and how it went. It never records what the API returned, only how much.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+const (
+	FileName        = "audit.jsonl"
+	FilePermissions = os.FileMode(0600)
+)
+
+// Outcomes of a request.
+const (
+	OutcomeOK           = "ok"            // a response below 400
+	OutcomeHTTPError    = "http_error"    // a response of 400 or above, other than 429
+	OutcomeRateLimited  = "rate_limited"  // a 429 response
+	OutcomeNetworkError = "network_error" // no response
+)
+
+// RateLimitHeaders are the response headers kept in Record.RateLimit.
+var RateLimitHeaders = []string{"X-Ratelimit-Limit", "X-Ratelimit-Remaining", "Retry-After"}
+
+// Record is a line of the audit log: one HTTP request, retries included.
+type Record struct {
+	Time       time.Time         `json:"time"`
+	User       string            `json:"user"`    // user@host
+	Command    string            `json:"command"` // the command line
+	Method     string            `json:"method"`
+	Endpoint   string            `json:"endpoint"`          // the path, without query
+	Account    string            `json:"account,omitempty"` // UID
+	Connection string            `json:"connection,omitempty"`
+	Status     int               `json:"status,omitempty"` // 0 without a response
+	Outcome    string            `json:"outcome"`
+	Error      string            `json:"error,omitempty"`      // for network errors
+	RateLimit  map[string]string `json:"rate_limit,omitempty"` // RateLimitHeaders as returned
+	Bytes      int               `json:"bytes"`                // size of the response body
+	DurationMS int64             `json:"duration_ms"`
+}
+
+// OutcomeFor returns the outcome of a request with status, 0 if there was no
+// response.
+func OutcomeFor(status int) string {
+	switch {
+	case status == 0:
+		return OutcomeNetworkError
+	case status == 429:
+		return OutcomeRateLimited
+	case status >= 400:
+		return OutcomeHTTPError
+	}
+	return OutcomeOK
+}
+
+// Log appends Records to the audit log file.
+type Log struct {
+	// Command and User are set on every record appended.
+	Command string
+	User    string
+
+	mu   sync.Mutex
+	path string
+}
+
+// Open returns the log at path, for records by the current user.
+func Open(path, command string) *Log {
+	return &Log{Command: command, User: currentUser(), path: path}
+}
+
+// Check makes sure records can be appended, creating the log if needed.
+func (l *Log) Check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.write(nil); err != nil {
+		return fmt.Errorf("audit log: %w", err)
+	}
+	return nil
+}
+
+// Append writes r as a line at the end of the log, setting its time if zero.
+func (l *Log) Append(r Record) error {
+	if r.Time.IsZero() {
+		r.Time = time.Now()
+	}
+	r.Time = r.Time.UTC()
+	r.Command, r.User = l.Command, l.User
+	if r.Outcome == "" {
+		r.Outcome = OutcomeFor(r.Status)
+	}
+	data, err := json.Marshal(r)
+	if err == nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		err = l.write(append(data, '\n'))
+	}
+	if err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+	return nil
+}
+
+// write appends data in a single write, so lines from processes sharing the
+// file do not interleave.
+func (l *Log) write(data []byte) error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, FilePermissions)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return f.Close()
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func currentUser() string {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
+
+// Read returns the records of the log at path that keep accepts, oldest
+// first, and how many lines could not be parsed. A missing log has no
+// records.
+func Read(path string, keep func(Record) bool) ([]Record, int, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var records []Record
+	bad := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var r Record
+		if err := json.Unmarshal(line, &r); err != nil {
+			bad++ // e.g. a line cut short by a crash
+			continue
+		}
+		if keep == nil || keep(r) {
+			records = append(records, r)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return records, bad, nil
+}
+
+// Query selects records for the audit command. Zero fields match anything.
+type Query struct {
+	From, To    time.Time       // From <= time < To
+	Accounts    map[string]bool // account UIDs
+	Connections map[string]bool
+	Outcome     string // an outcome, or "error" for any but ok
+	Status      int
+}
+
+// Match reports whether r is selected by q.
+func (q Query) Match(r Record) bool {
+	switch {
+	case !q.From.IsZero() && r.Time.Before(q.From):
+		return false
+	case !q.To.IsZero() && !r.Time.Before(q.To):
+		return false
+	case q.Accounts != nil && !q.Accounts[r.Account]:
+		return false
+	case q.Connections != nil && !q.Connections[r.Connection]:
+		return false
+	case q.Status != 0 && r.Status != q.Status:
+		return false
+	}
+	switch q.Outcome {
+	case "":
+		return true
+	case "error":
+		return r.Outcome != OutcomeOK
+	}
+	return r.Outcome == q.Outcome
+}
+
+// ParseOutcome checks an outcome for Query.
+func ParseOutcome(s string) (string, error) {
+	switch s {
+	case "", "error", OutcomeOK, OutcomeHTTPError, OutcomeRateLimited, OutcomeNetworkError:
+		return s, nil
+	}
+	return "", fmt.Errorf("unknown outcome %q (expected ok, error, %s, %s or %s)", s, OutcomeHTTPError, OutcomeRateLimited, OutcomeNetworkError)
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLog_AppendRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	day := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	// Two commands append to the same file
+	first := Open(path, "ebcli balances")
+	second := Open(path, "ebcli transactions")
+	for _, err := range []error{
+		first.Append(Record{Time: day, Method: "GET", Endpoint: "/accounts/a/balances", Account: "a", Status: 200}),
+		second.Append(Record{Time: day.Add(time.Hour), Method: "GET", Endpoint: "/accounts/b/transactions", Account: "b", Status: 429}),
+		second.Append(Record{Time: day.AddDate(0, 0, 1), Method: "GET", Endpoint: "/accounts/a/transactions", Account: "a", Error: "connection refused"}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != FilePermissions {
+		t.Errorf("mode = %v, want %v", info.Mode().Perm(), FilePermissions)
+	}
+
+	// A line cut short is skipped
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	f.WriteString(`{"time":"2026-03`)
+	f.Close()
+
+	records, bad, err := Read(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || bad != 1 {
+		t.Fatalf("Read = %d records, %d bad", len(records), bad)
+	}
+	if records[0].Command != "ebcli balances" || records[1].Command != "ebcli transactions" || records[0].User == "" {
+		t.Errorf("records = %+v", records)
+	}
+	outcomes := []string{records[0].Outcome, records[1].Outcome, records[2].Outcome}
+	if outcomes[0] != OutcomeOK || outcomes[1] != OutcomeRateLimited || outcomes[2] != OutcomeNetworkError {
+		t.Errorf("outcomes = %v", outcomes)
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want int
+	}{
+		{"all", Query{}, 3},
+		{"day", Query{From: day.Truncate(24 * time.Hour), To: day.Truncate(24*time.Hour).AddDate(0, 0, 1)}, 2},
+		{"account", Query{Accounts: map[string]bool{"a": true}}, 2},
+		{"errors", Query{Outcome: "error"}, 2},
+		{"outcome", Query{Outcome: OutcomeRateLimited}, 1},
+		{"status", Query{Status: 200}, 1},
+		{"account errors", Query{Accounts: map[string]bool{"a": true}, Outcome: "error"}, 1},
+	}
+	for _, tt := range tests {
+		got, _, err := Read(path, tt.q.Match)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("%s: got %d records (%v), want %d", tt.name, len(got), err, tt.want)
+		}
+	}
+}
+
+func TestLog_NotWritable(t *testing.T) {
+	// A directory where the log should be cannot be appended to
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	l := Open(path, "ebcli balances")
+	if err := l.Check(); err == nil {
+		t.Error("Check: no error")
+	}
+	if err := l.Append(Record{Method: "GET", Endpoint: "/aspsps", Status: 200}); err == nil {
+		t.Error("Append: no error")
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	records, bad, err := Read(filepath.Join(t.TempDir(), FileName), nil)
+	if records != nil || bad != 0 || err != nil {
+		t.Errorf("Read = %v, %d, %v", records, bad, err)
+	}
+}
+
+func TestParseOutcome(t *testing.T) {
+	for _, s := range []string{"", "ok", "error", "http_error", "rate_limited", "network_error"} {
+		if _, err := ParseOutcome(s); err != nil {
+			t.Errorf("ParseOutcome(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseOutcome("failed"); err == nil {
+		t.Error("ParseOutcome(failed): no error")
+	}
+}
@@ -2,9 +2,14 @@ package auth
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"html/template"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,45 +27,127 @@ type CallbackResult struct {
 	ErrorDescription string
 }
 
+// CallbackPage is the data passed to the page shown after the redirect.
+type CallbackPage struct {
+	OK               bool
+	Error            string // bank error code, or why the callback was rejected
+	ErrorDescription string
+}
+
+// DefaultCallbackPage is the page shown after the redirect unless a custom
+// template is configured.
+var DefaultCallbackPage = template.Must(template.New("callback").Parse(`<!DOCTYPE html><html><body>
+{{if .OK}}<h2>Authorization complete</h2>
+<p>You can close this tab and return to the terminal.</p>
+<script>window.close()</script>
+{{else}}<h2>Authorization failed</h2>
+<p>{{.Error}}{{with .ErrorDescription}} — {{.}}{{end}}</p>
+<p>Return to the terminal for details.</p>
+{{end}}</body></html>`))
+
+// LoadCallbackPage parses an html/template file for the callback page. The
+// template receives a CallbackPage.
+func LoadCallbackPage(path string) (*template.Template, error) {
+	tmpl, err := template.ParseFiles(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading callback page template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// CallbackServer receives the OAuth redirect from the bank.
+type CallbackServer struct {
+	Host     string // interface to bind; empty means all interfaces
+	Port     int
+	CertFile string // with KeyFile, serve HTTPS instead of HTTP
+	KeyFile  string
+	Page     *template.Template // nil means DefaultCallbackPage
+}
+
+// TLS reports whether the server serves HTTPS.
+func (s *CallbackServer) TLS() bool {
+	return s.CertFile != "" && s.KeyFile != ""
+}
+
+// URL returns the redirect URL pointing at this server.
+func (s *CallbackServer) URL() string {
+	scheme := "http"
+	if s.TLS() {
+		scheme = "https"
+	}
+	host := s.Host
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(host, strconv.Itoa(s.Port)), CallbackPath)
+}
+
 // ListenForCallback starts a local HTTP server on the given port,
 // waits for a single OAuth callback, validates the state parameter,
 // and returns the authorization code.
 func ListenForCallback(ctx context.Context, port int, expectedState string) (*CallbackResult, error) {
-	resultCh := make(chan *CallbackResult, 1)
+	s := &CallbackServer{Port: port}
+	return s.Listen(ctx, expectedState)
+}
+
+// Listen serves until a single OAuth callback arrives or CallbackTimeout
+// passes, validates the state parameter, and returns the authorization code.
+func (s *CallbackServer) Listen(ctx context.Context, expectedState string) (*CallbackResult, error) {
+	type callback struct {
+		result *CallbackResult
+		err    error
+	}
+	resultCh := make(chan callback, 1)
+
+	page := s.Page
+	if page == nil {
+		page = DefaultCallbackPage
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc(CallbackPath, func(w http.ResponseWriter, r *http.Request) {
-		result := &CallbackResult{
-			Code:             r.URL.Query().Get("code"),
-			State:            r.URL.Query().Get("state"),
-			Error:            r.URL.Query().Get("error"),
-			ErrorDescription: r.URL.Query().Get("error_description"),
+		result, err := validateCallback(resultFromQuery(r.URL.Query()), expectedState)
+
+		data := CallbackPage{OK: err == nil}
+		if err != nil {
+			data.Error = err.Error()
+			if result != nil && result.Error != "" {
+				data.Error, data.ErrorDescription = result.Error, result.ErrorDescription
+			}
 		}
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.WriteHeader(http.StatusOK)
-		fmt.Fprint(w, `<!DOCTYPE html><html><body>
-<h2>Authorization complete</h2>
-<p>You can close this tab and return to the terminal.</p>
-<script>window.close()</script>
-</body></html>`)
+		page.Execute(w, data)
 
 		select {
-		case resultCh <- result:
+		case resultCh <- callback{result, err}:
 		default:
 		}
 	})
 
 	server := &http.Server{
-		Addr:              fmt.Sprintf(":%d", port),
+		Addr:              net.JoinHostPort(s.Host, strconv.Itoa(s.Port)),
 		Handler:           mux,
 		ReadHeaderTimeout: 10 * time.Second,
 	}
 
+	var tlsConfig *tls.Config
+	if s.TLS() {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading callback TLS certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
 	// Check port availability
 	ln, err := net.Listen("tcp", server.Addr)
 	if err != nil {
-		return nil, fmt.Errorf("port %d unavailable: %w", port, err)
+		return nil, fmt.Errorf("%s unavailable: %w", server.Addr, err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
 	}
 
 	go func() {
@@ -77,33 +164,73 @@ func ListenForCallback(ctx context.Context, port int, expectedState string) (*Ca
 	}()
 
 	select {
-	case result := <-resultCh:
-		// Validate state parameter (CSRF protection)
-		if result.State != expectedState {
-			return nil, fmt.Errorf("state mismatch: expected %q, got %q (possible CSRF attack)", expectedState, result.State)
-		}
+	case cb := <-resultCh:
+		return cb.result, cb.err
 
-		// Check for error from bank
-		if result.Error != "" {
-			return result, fmt.Errorf("authorization failed: %s — %s", result.Error, result.ErrorDescription)
-		}
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting for bank authorization (waited %v)", CallbackTimeout)
+	}
+}
 
-		if result.Code == "" {
-			return nil, fmt.Errorf("no authorization code received in callback")
-		}
+// ParseCallbackURL extracts the callback parameters from the URL the bank
+// redirected to, e.g. copied from the browser's address bar on another
+// machine, and validates it the same way as ListenForCallback.
+// A bare query string ("code=...&state=...") is accepted as well.
+func ParseCallbackURL(raw, expectedState string) (*CallbackResult, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, fmt.Errorf("empty callback URL")
+	}
+
+	query := raw
+	if u, err := url.Parse(raw); err == nil && u.RawQuery != "" {
+		query = u.RawQuery
+	}
+	values, err := url.ParseQuery(strings.TrimPrefix(query, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid callback URL: %w", err)
+	}
+	if values.Get("state") == "" {
+		return nil, fmt.Errorf("callback URL has no state parameter; paste the full URL from the address bar")
+	}
 
-		return result, nil
+	return validateCallback(resultFromQuery(values), expectedState)
+}
 
-	case <-ctx.Done():
-		return nil, fmt.Errorf("timed out waiting for bank authorization (waited %v)", CallbackTimeout)
+func resultFromQuery(q url.Values) *CallbackResult {
+	return &CallbackResult{
+		Code:             q.Get("code"),
+		State:            q.Get("state"),
+		Error:            q.Get("error"),
+		ErrorDescription: q.Get("error_description"),
 	}
 }
 
+// validateCallback checks the state parameter (CSRF protection), then the
+// bank's error and the authorization code.
+func validateCallback(result *CallbackResult, expectedState string) (*CallbackResult, error) {
+	if result.State != expectedState {
+		return nil, fmt.Errorf("state mismatch: expected %q, got %q (possible CSRF attack)", expectedState, result.State)
+	}
+
+	// Check for error from bank
+	if result.Error != "" {
+		return result, fmt.Errorf("authorization failed: %s — %s", result.Error, result.ErrorDescription)
+	}
+
+	if result.Code == "" {
+		return nil, fmt.Errorf("no authorization code received in callback")
+	}
+
+	return result, nil
+}
+
 // CallbackURL returns the full redirect URL for a given port.
 // If override is non-empty, it is returned as-is (for public callback URLs).
 func CallbackURL(port int, override string) string {
 	if override != "" {
 		return override
 	}
-	return fmt.Sprintf("http://localhost:%d%s", port, CallbackPath)
+	s := &CallbackServer{Port: port}
+	return s.URL()
 }
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCallbackURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		code    string
+		wantErr bool
+	}{
+		{"full URL", "http://localhost:18271/callback?code=abc&state=s1", "abc", false},
+		{"public URL with spaces", "  https://example.com/callback?state=s1&code=xyz\n", "xyz", false},
+		{"bare query", "?code=abc&state=s1", "abc", false},
+		{"state mismatch", "http://localhost:18271/callback?code=abc&state=other", "", true},
+		{"no state", "http://localhost:18271/callback?code=abc", "", true},
+		{"bank error", "http://localhost:18271/callback?state=s1&error=access_denied", "", true},
+		{"no code", "http://localhost:18271/callback?state=s1", "", true},
+		{"empty", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseCallbackURL(tt.raw, "s1")
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseCallbackURL: %v", err)
+			}
+			if result.Code != tt.code {
+				t.Errorf("Code = %q, want %q", result.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestCallbackServer_URL(t *testing.T) {
+	tests := []struct {
+		server CallbackServer
+		want   string
+	}{
+		{CallbackServer{Port: 18271}, "http://localhost:18271/callback"},
+		{CallbackServer{Host: "0.0.0.0", Port: 1}, "http://localhost:1/callback"},
+		{CallbackServer{Host: "192.168.1.5", Port: 1}, "http://192.168.1.5:1/callback"},
+		{CallbackServer{Host: "::1", Port: 1, CertFile: "c", KeyFile: "k"}, "https://[::1]:1/callback"},
+	}
+	for _, tt := range tests {
+		if got := tt.server.URL(); got != tt.want {
+			t.Errorf("URL() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestCallbackServer_TLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureSelfSignedCert(dir)
+	if err != nil {
+		t.Fatalf("EnsureSelfSignedCert: %v", err)
+	}
+
+	s := &CallbackServer{
+		Host:     "127.0.0.1",
+		Port:     freePort(t),
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		Page:     template.Must(template.New("p").Parse(`{{if .OK}}done{{else}}failed: {{.Error}}{{end}}`)),
+	}
+
+	type listenResult struct {
+		result *CallbackResult
+		err    error
+	}
+	done := make(chan listenResult, 1)
+	go func() {
+		result, err := s.Listen(context.Background(), "s1")
+		done <- listenResult{result, err}
+	}()
+
+	body := getCallback(t, s.URL()+"?code=abc&state=s1", certFile)
+	if body != "done" {
+		t.Errorf("page = %q, want %q", body, "done")
+	}
+	r := <-done
+	if r.err != nil || r.result.Code != "abc" {
+		t.Errorf("Listen = %+v, %v; want code abc", r.result, r.err)
+	}
+
+	go func() {
+		result, err := s.Listen(context.Background(), "s1")
+		done <- listenResult{result, err}
+	}()
+	body = getCallback(t, s.URL()+"?code=abc&state=other", certFile)
+	if !strings.HasPrefix(body, "failed: ") {
+		t.Errorf("page = %q, want failure page", body)
+	}
+	if r := <-done; r.err == nil {
+		t.Error("expected state mismatch error")
+	}
+}
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, err := EnsureSelfSignedCert(dir)
+	if err != nil {
+		t.Fatalf("EnsureSelfSignedCert: %v", err)
+	}
+	if info, err := os.Stat(keyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file = %v, %v; want mode 0600", info, err)
+	}
+	first, _ := os.ReadFile(certFile)
+
+	// Reused while valid
+	EnsureSelfSignedCert(dir)
+	if again, _ := os.ReadFile(certFile); string(again) != string(first) {
+		t.Error("certificate regenerated although still valid")
+	}
+
+	// Regenerated for a host it does not cover
+	EnsureSelfSignedCert(dir, "192.168.1.5")
+	cert := parseCert(t, filepath.Join(dir, SelfSignedCertFile))
+	for _, h := range []string{"localhost", "127.0.0.1", "::1", "192.168.1.5"} {
+		if err := cert.VerifyHostname(h); err != nil {
+			t.Errorf("VerifyHostname(%s): %v", h, err)
+		}
+	}
+	if !cert.NotAfter.After(time.Now().Add(300 * 24 * time.Hour)) {
+		t.Errorf("NotAfter = %v, want about a year from now", cert.NotAfter)
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func parseCert(t *testing.T, path string) *x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		t.Fatal("no PEM block in certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// getCallback requests url, trusting only certFile, retrying until the
+// server is up.
+func getCallback(t *testing.T, url, certFile string) string {
+	t.Helper()
+	pool := x509.NewCertPool()
+	pool.AddCert(parseCert(t, certFile))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	var lastErr error
+	for i := 0; i < 50; i++ {
+		resp, err := client.Get(url)
+		if err != nil {
+			lastErr = err
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+	t.Fatalf("GET %s: %v", url, lastErr)
+	return ""
+}
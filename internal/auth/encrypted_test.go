@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptPrivateKeyPEM_RoundTrip(t *testing.T) {
+	key := generateTestKey(t)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	plain := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	encrypted, err := EncryptPrivateKeyPEM(plain, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("EncryptPrivateKeyPEM: %v", err)
+	}
+	if !IsEncryptedPEM(encrypted) {
+		t.Fatal("encrypted key not detected as encrypted")
+	}
+	if bytes.Contains(encrypted, []byte("BEGIN PRIVATE KEY")) {
+		t.Error("encrypted output should not contain a plaintext key block")
+	}
+
+	if _, err := ParsePrivateKey(encrypted); !errors.Is(err, ErrKeyEncrypted) {
+		t.Errorf("ParsePrivateKey on encrypted key: err = %v, want ErrKeyEncrypted", err)
+	}
+
+	if _, err := DecryptPrivateKeyPEM(encrypted, []byte("wrong")); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+
+	path := filepath.Join(t.TempDir(), "enc.pem")
+	os.WriteFile(path, encrypted, 0600)
+
+	calls := 0
+	loaded, err := LoadPrivateKeyWithPassphrase(path, func(string) ([]byte, error) {
+		calls++
+		return []byte("correct horse"), nil
+	})
+	if err != nil {
+		t.Fatalf("LoadPrivateKeyWithPassphrase: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("passphrase called %d times, want 1", calls)
+	}
+	if loaded.N.Cmp(key.N) != 0 {
+		t.Error("decrypted key does not match original")
+	}
+}
+
+func TestLoadPrivateKeyWithPassphrase_Unencrypted(t *testing.T) {
+	var privBuf, pubBuf bytes.Buffer
+	if err := GenerateKeyPair(&privBuf, &pubBuf); err != nil {
+		t.Fatalf("GenerateKeyPair: %v", err)
+	}
+
+	_, err := ParsePrivateKeyWithPassphrase(privBuf.Bytes(), func(string) ([]byte, error) {
+		t.Error("passphrase should not be requested for an unencrypted key")
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyWithPassphrase: %v", err)
+	}
+}
+
+func TestPassphrase_Env(t *testing.T) {
+	t.Setenv(PassphraseEnv, "from-env")
+	p := &Passphrase{Command: "echo from-command"}
+
+	got, err := p.Get("test")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "from-env" {
+		t.Errorf("passphrase = %q, want from-env", got)
+	}
+}
+
+func TestPassphrase_Command(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	p := &Passphrase{Command: "echo from-command"}
+
+	got, err := p.Get("test")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if string(got) != "from-command" {
+		t.Errorf("passphrase = %q, want from-command", got)
+	}
+}
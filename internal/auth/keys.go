@@ -22,13 +22,47 @@ func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
 	return ParsePrivateKey(data)
 }
 
+// LoadPrivateKeyWithPassphrase is LoadPrivateKey for keys that may be
+// passphrase-protected. passphrase is only called if the key is encrypted.
+func LoadPrivateKeyWithPassphrase(path string, passphrase PassphraseFunc) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	return ParsePrivateKeyWithPassphrase(data, passphrase)
+}
+
+// ParsePrivateKeyWithPassphrase parses PEM key data, decrypting it first if
+// it is passphrase-protected.
+func ParsePrivateKeyWithPassphrase(data []byte, passphrase PassphraseFunc) (*rsa.PrivateKey, error) {
+	if !IsEncryptedPEM(data) {
+		return ParsePrivateKey(data)
+	}
+	if passphrase == nil {
+		return nil, ErrKeyEncrypted
+	}
+	pass, err := passphrase("Private key passphrase")
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase: %w", err)
+	}
+	decrypted, err := DecryptPrivateKeyPEM(data, pass)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(decrypted)
+}
+
 // ParsePrivateKey parses PEM-encoded RSA private key data.
 // Tries PKCS#8 first, falls back to PKCS#1.
+// Returns ErrKeyEncrypted for passphrase-protected keys.
 func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
 	block, _ := pem.Decode(data)
 	if block == nil {
 		return nil, fmt.Errorf("no PEM block found in key data")
 	}
+	if IsEncryptedPEM(data) {
+		return nil, ErrKeyEncrypted
+	}
 
 	switch block.Type {
 	case "PRIVATE KEY":
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// KeySource supplies PEM-encoded private key data.
+type KeySource interface {
+	// Load returns the PEM data. Sources backed by pipes may only be read once.
+	Load() ([]byte, error)
+	// String describes the source for messages; it never includes key material.
+	String() string
+}
+
+// FileKeySource reads the key from a PEM file on disk.
+type FileKeySource struct {
+	Path string
+}
+
+func (s FileKeySource) Load() ([]byte, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key: %w", err)
+	}
+	return data, nil
+}
+
+func (s FileKeySource) String() string { return "file " + s.Path }
+
+// InlineKeySource holds the PEM data itself, e.g. from EBCLI_PRIVATE_KEY_PEM.
+type InlineKeySource struct {
+	PEM string
+}
+
+func (s InlineKeySource) Load() ([]byte, error) { return []byte(s.PEM), nil }
+
+func (s InlineKeySource) String() string { return "inline PEM" }
+
+// FDKeySource reads the key from an already-open file descriptor, e.g. a pipe
+// set up by the parent process: ebcli ... 3< <(vault read ...).
+type FDKeySource struct {
+	FD int
+}
+
+func (s FDKeySource) Load() ([]byte, error) {
+	f := os.NewFile(uintptr(s.FD), fmt.Sprintf("fd%d", s.FD))
+	if f == nil {
+		return nil, fmt.Errorf("invalid file descriptor %d", s.FD)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading private key from fd %d: %w", s.FD, err)
+	}
+	return data, nil
+}
+
+func (s FDKeySource) String() string { return fmt.Sprintf("file descriptor %d", s.FD) }
+
+// CommandKeySource runs a shell command and reads the key from its stdout.
+type CommandKeySource struct {
+	Command string
+}
+
+func (s CommandKeySource) Load() ([]byte, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("sh", "-c", s.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("key command failed: %w", err)
+	}
+	return stdout.Bytes(), nil
+}
+
+func (s CommandKeySource) String() string { return "command " + s.Command }
+
+// LoadKey reads and parses the private key from src, decrypting it with
+// passphrase if it is encrypted.
+func LoadKey(src KeySource, passphrase PassphraseFunc) (*rsa.PrivateKey, error) {
+	data, err := src.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("empty private key from %s", src)
+	}
+	key, err := ParsePrivateKeyWithPassphrase(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src, err)
+	}
+	return key, nil
+}
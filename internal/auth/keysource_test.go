@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKey_Sources(t *testing.T) {
+	key := generateTestKey(t)
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	plain := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "key.pem")
+	os.WriteFile(path, plain, 0600)
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Pipe: %v", err)
+	}
+	go func() {
+		w.Write(plain)
+		w.Close()
+	}()
+
+	sources := []KeySource{
+		FileKeySource{Path: path},
+		InlineKeySource{PEM: string(plain)},
+		FDKeySource{FD: int(r.Fd())},
+		CommandKeySource{Command: "cat " + path},
+	}
+	for _, src := range sources {
+		t.Run(src.String(), func(t *testing.T) {
+			got, err := LoadKey(src, nil)
+			if err != nil {
+				t.Fatalf("LoadKey: %v", err)
+			}
+			if !got.Equal(key) {
+				t.Error("loaded key does not match")
+			}
+		})
+	}
+}
+
+func TestLoadKey_Errors(t *testing.T) {
+	if _, err := LoadKey(InlineKeySource{PEM: "  \n"}, nil); err == nil {
+		t.Error("expected error for empty key")
+	}
+	if _, err := LoadKey(CommandKeySource{Command: "exit 1"}, nil); err == nil {
+		t.Error("expected error for failing key command")
+	}
+	if _, err := LoadKey(FileKeySource{Path: "/nonexistent/key.pem"}, nil); err == nil {
+		t.Error("expected error for missing key file")
+	}
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// PassphraseEnv is the environment variable checked for a passphrase
+// before running a passphrase command or prompting.
+const PassphraseEnv = "EBCLI_PASSPHRASE"
+
+// PassphraseFunc returns the passphrase for an encrypted key or config section.
+// prompt is a short human-readable label shown when reading from a terminal.
+type PassphraseFunc func(prompt string) ([]byte, error)
+
+// ErrWrongPassphrase is wrapped by the errors of decrypting with a wrong
+// passphrase.
+var ErrWrongPassphrase = errors.New("wrong passphrase")
+
+// unlockAttempts is how many times Unlock prompts on a terminal.
+const unlockAttempts = 3
+
+// Passphrase resolves a passphrase from, in order: the EBCLI_PASSPHRASE env
+// var, the stdout of Command (run via sh -c), or a prompt on /dev/tty.
+// Passphrases are cached by prompt, so the config and the private key can
+// have different ones and the user is asked for each at most once per run.
+type Passphrase struct {
+	Command string
+	NoTTY   bool // fail instead of prompting, for non-interactive runs
+
+	mu     sync.Mutex
+	cached map[string][]byte // by prompt
+	last   []byte            // the most recently resolved passphrase
+}
+
+// Get returns the passphrase for prompt. It satisfies PassphraseFunc.
+func (p *Passphrase) Get(prompt string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if pass, ok := p.cached[prompt]; ok {
+		return pass, nil
+	}
+
+	pass, err := p.resolve(prompt, false)
+	if err != nil {
+		return nil, err
+	}
+	p.remember(prompt, pass)
+	return pass, nil
+}
+
+// New returns a passphrase for encrypting something new. When prompting on a
+// terminal, the passphrase is asked twice and must match.
+func (p *Passphrase) New(prompt string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pass, err := p.resolve(prompt, true)
+	if err != nil {
+		return nil, err
+	}
+	p.last = pass
+	return pass, nil
+}
+
+// Unlock calls open with a PassphraseFunc for prompt, which open only needs
+// to call if what it opens is encrypted. The passphrase given earlier in the
+// run for another prompt is tried first, since one passphrase usually
+// protects both the key and the connections. While open fails with
+// ErrWrongPassphrase, the user is asked again on the terminal.
+func (p *Passphrase) Unlock(prompt string, open func(PassphraseFunc) error) error {
+	tried := false
+	for attempt := 1; ; attempt++ {
+		var used []byte
+		fresh := false
+		err := open(func(string) ([]byte, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			if pass, ok := p.cached[prompt]; ok && !tried {
+				used = pass
+				return pass, nil
+			}
+			if p.last != nil && !tried {
+				used = p.last
+				return p.last, nil
+			}
+			ask := prompt
+			if attempt > 1 {
+				ask = "Wrong passphrase. " + prompt
+			}
+			pass, err := p.resolve(ask, false)
+			if err != nil {
+				return nil, err
+			}
+			used, fresh = pass, true
+			return pass, nil
+		})
+		if used == nil {
+			return err // no passphrase needed, or none could be read
+		}
+		if err == nil {
+			p.mu.Lock()
+			p.remember(prompt, used)
+			p.mu.Unlock()
+			return nil
+		}
+
+		p.mu.Lock()
+		delete(p.cached, prompt)
+		p.mu.Unlock()
+		if !errors.Is(err, ErrWrongPassphrase) || (fresh && !p.prompts()) || attempt >= unlockAttempts {
+			return err
+		}
+		if !fresh {
+			attempt-- // a reused passphrase does not count as an attempt
+		}
+		tried = true
+	}
+}
+
+func (p *Passphrase) remember(prompt string, pass []byte) {
+	if p.cached == nil {
+		p.cached = make(map[string][]byte)
+	}
+	p.cached[prompt] = pass
+	p.last = pass
+}
+
+// prompts reports whether resolve reads from the terminal, so that asking
+// again can give a different answer.
+func (p *Passphrase) prompts() bool {
+	return os.Getenv(PassphraseEnv) == "" && p.Command == "" && !p.NoTTY
+}
+
+func (p *Passphrase) resolve(prompt string, confirm bool) ([]byte, error) {
+	if v := os.Getenv(PassphraseEnv); v != "" {
+		return []byte(v), nil
+	}
+
+	if p.Command != "" {
+		var stdout bytes.Buffer
+		cmd := exec.Command("sh", "-c", p.Command)
+		cmd.Stdout = &stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("passphrase command failed: %w", err)
+		}
+		pass := bytes.TrimRight(stdout.Bytes(), "\r\n")
+		if len(pass) == 0 {
+			return nil, fmt.Errorf("passphrase command printed nothing")
+		}
+		return pass, nil
+	}
+
+	if p.NoTTY {
+		return nil, fmt.Errorf("%s needed: set %s or passphrase_command", strings.ToLower(prompt), PassphraseEnv)
+	}
+	pass, err := readTTYPassphrase(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		again, err := readTTYPassphrase("Repeat " + strings.ToLower(prompt[:1]) + prompt[1:])
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(pass, again) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	if len(pass) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+	return pass, nil
+}
+
+// readTTYPassphrase prompts on /dev/tty with echo disabled, so it works even
+// when stdin and stdout are pipes.
+func readTTYPassphrase(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal to prompt for passphrase; set %s or a passphrase command", PassphraseEnv)
+	}
+	defer tty.Close()
+
+	if err := stty(tty, "-echo"); err == nil {
+		defer stty(tty, "echo")
+	}
+
+	fmt.Fprintf(tty, "%s: ", prompt)
+	line, err := bufio.NewReader(tty).ReadString('\n')
+	fmt.Fprintln(tty)
+	if err != nil && line == "" {
+		return nil, fmt.Errorf("reading passphrase: %w", err)
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+func stty(tty *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"fmt"
+	"testing"
+)
+
+// opener accepts only want, failing like a decryption with any other passphrase.
+func opener(want string, calls *int) func(PassphraseFunc) error {
+	return func(passphrase PassphraseFunc) error {
+		*calls++
+		pass, err := passphrase("")
+		if err != nil {
+			return err
+		}
+		if string(pass) != want {
+			return fmt.Errorf("decrypting: %w", ErrWrongPassphrase)
+		}
+		return nil
+	}
+}
+
+func TestPassphraseUnlock_PerPurpose(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	p := &Passphrase{Command: "echo one", NoTTY: true}
+
+	var calls int
+	if err := p.Unlock("Config passphrase", opener("one", &calls)); err != nil {
+		t.Fatalf("config: %v", err)
+	}
+
+	// The config passphrase is tried first, then the key's own
+	p.Command = "echo two"
+	calls = 0
+	if err := p.Unlock("Private key passphrase", opener("two", &calls)); err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("key opened %d times, want 2", calls)
+	}
+
+	for prompt, want := range map[string]string{"Config passphrase": "one", "Private key passphrase": "two"} {
+		if got, _ := p.Get(prompt); string(got) != want {
+			t.Errorf("Get(%s) = %q, want %q", prompt, got, want)
+		}
+	}
+}
+
+func TestPassphraseUnlock_Errors(t *testing.T) {
+	t.Setenv(PassphraseEnv, "")
+	p := &Passphrase{Command: "echo one", NoTTY: true}
+
+	// A wrong passphrase that cannot change is not asked again
+	var calls int
+	if err := p.Unlock("Config passphrase", opener("other", &calls)); err == nil {
+		t.Error("expected error for wrong passphrase")
+	}
+	if calls != 1 {
+		t.Errorf("opened %d times, want 1", calls)
+	}
+
+	// Nothing encrypted: no passphrase is read
+	p.Command = "exit 1"
+	if err := p.Unlock("Private key passphrase", func(PassphraseFunc) error { return nil }); err != nil {
+		t.Errorf("unencrypted: %v", err)
+	}
+}
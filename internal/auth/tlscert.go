@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File names of the generated callback certificate in the config directory.
+const (
+	SelfSignedCertFile = "callback-cert.pem"
+	SelfSignedKeyFile  = "callback-key.pem"
+)
+
+// selfSignedValidity is how long a generated certificate is valid. It is
+// regenerated once less than a day remains.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// EnsureSelfSignedCert returns the paths of a self-signed certificate in dir
+// for localhost, 127.0.0.1, ::1 and any extra hosts, generating a new one if
+// it is missing, about to expire or does not cover a host.
+func EnsureSelfSignedCert(dir string, hosts ...string) (certFile, keyFile string, err error) {
+	certFile = filepath.Join(dir, SelfSignedCertFile)
+	keyFile = filepath.Join(dir, SelfSignedKeyFile)
+
+	hosts = append([]string{"localhost", "127.0.0.1", "::1"}, hosts...)
+	if selfSignedCertUsable(certFile, keyFile, hosts, time.Now()) {
+		return certFile, keyFile, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("generating callback key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", fmt.Errorf("generating certificate serial: %w", err)
+	}
+
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "ebcli callback"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if h == "" {
+			continue
+		}
+		if ip := net.ParseIP(h); ip != nil {
+			if !ip.IsUnspecified() {
+				tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+			}
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return "", "", fmt.Errorf("creating callback certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", "", fmt.Errorf("encoding callback key: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", "", fmt.Errorf("creating config directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return "", "", fmt.Errorf("writing callback key: %w", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		return "", "", fmt.Errorf("writing callback certificate: %w", err)
+	}
+	return certFile, keyFile, nil
+}
+
+func selfSignedCertUsable(certFile, keyFile string, hosts []string, now time.Time) bool {
+	if _, err := os.Stat(keyFile); err != nil {
+		return false
+	}
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return false
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	if now.Add(24 * time.Hour).After(cert.NotAfter) {
+		return false
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); h == "" || (ip != nil && ip.IsUnspecified()) {
+			continue
+		}
+		if cert.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
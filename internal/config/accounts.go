@@ -0,0 +1,287 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Account identity
+//
+// A bank account gets a new UID with every session, so ebcli gives it a local
+// ID that survives reconnects. Accounts from the old and new session are
+// matched by identification hash, then by IBAN; the new account takes over
+// the old one's ID and alias and remembers its UID in PreviousUIDs. Accounts
+// the bank no longer returns are kept as RetiredAccounts until they are
+// linked to a new account or dropped.
+
+// Hashes returns every identification hash known for the account.
+func (a *Account) Hashes() []string {
+	hashes := appendUnique(nil, a.IdentificationHash)
+	return appendUnique(hashes, a.IdentificationHashes...)
+}
+
+// SameAccount reports whether a and b are the same bank account: they share
+// an identification hash or, when neither has one, an IBAN.
+func SameAccount(a, b *Account) bool {
+	ha, hb := a.Hashes(), b.Hashes()
+	for _, x := range ha {
+		for _, y := range hb {
+			if x == y {
+				return true
+			}
+		}
+	}
+	if len(ha) > 0 && len(hb) > 0 {
+		return false
+	}
+	return a.IBAN != "" && normalizeIBAN(a.IBAN) == normalizeIBAN(b.IBAN)
+}
+
+// Inherit makes a continue old: it takes old's ID and alias, adds old's tags
+// to its own, and adds old's UIDs and hashes to its history.
+func (a *Account) Inherit(old *Account) {
+	a.ID = old.ID
+	a.Alias = old.Alias
+	for _, tag := range old.Tags {
+		if !a.HasTag(tag) {
+			a.Tags = append(a.Tags, tag)
+		}
+	}
+
+	uids := append([]string{}, old.PreviousUIDs...)
+	if old.UID != a.UID {
+		uids = appendUnique(uids, old.UID)
+	}
+	for _, uid := range a.PreviousUIDs {
+		if uid != a.UID {
+			uids = appendUnique(uids, uid)
+		}
+	}
+	a.PreviousUIDs = uids
+
+	a.IdentificationHashes = appendUnique(a.IdentificationHashes, old.Hashes()...)
+	if len(a.IdentificationHashes) == 1 && a.IdentificationHashes[0] == a.IdentificationHash {
+		a.IdentificationHashes = nil
+	}
+}
+
+// HasTag reports whether the account has tag, compared case-insensitively.
+func (a *Account) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUID reports whether uid is the account's UID or one of its previous UIDs.
+func (a *Account) HasUID(uid string) bool {
+	if strings.EqualFold(a.UID, uid) {
+		return true
+	}
+	for _, prev := range a.PreviousUIDs {
+		if strings.EqualFold(prev, uid) {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignAccountIDs gives each account in accounts that has no ID a new one,
+// unique across the config and accounts.
+func (cfg *Config) AssignAccountIDs(accounts []Account) {
+	taken := map[string]bool{}
+	for _, conn := range cfg.Connections {
+		for _, acct := range conn.Accounts {
+			taken[acct.ID] = true
+		}
+		for _, acct := range conn.RetiredAccounts {
+			taken[acct.ID] = true
+		}
+	}
+	for _, acct := range accounts {
+		taken[acct.ID] = true
+	}
+	for i := range accounts {
+		if accounts[i].ID == "" {
+			accounts[i].ID = newAccountID(&accounts[i], taken)
+			taken[accounts[i].ID] = true
+		}
+	}
+}
+
+// fillAccountIDs assigns IDs to accounts saved before IDs existed. IDs are
+// derived from the account's identifiers, so they are the same on every load
+// until the config is saved with them.
+func (cfg *Config) fillAccountIDs() {
+	for i := range cfg.Connections {
+		conn := &cfg.Connections[i]
+		cfg.AssignAccountIDs(conn.Accounts)
+		for j := range conn.RetiredAccounts {
+			acct := &conn.RetiredAccounts[j].Account
+			if acct.ID == "" {
+				single := []Account{*acct}
+				cfg.AssignAccountIDs(single)
+				acct.ID = single[0].ID
+			}
+		}
+	}
+}
+
+// newAccountID derives an ID from the account's most stable identifier,
+// adding a counter if it is taken.
+func newAccountID(a *Account, taken map[string]bool) string {
+	key := "uid:" + a.UID
+	switch {
+	case a.IdentificationHash != "":
+		key = "hash:" + a.IdentificationHash
+	case len(a.IdentificationHashes) > 0:
+		key = "hash:" + a.IdentificationHashes[0]
+	case a.IBAN != "":
+		key = "iban:" + normalizeIBAN(a.IBAN)
+	}
+	for n := 0; ; n++ {
+		seed := key
+		if n > 0 {
+			seed = fmt.Sprintf("%s#%d", key, n)
+		}
+		sum := sha256.Sum256([]byte(seed))
+		id := hex.EncodeToString(sum[:6])
+		if !taken[id] {
+			return id
+		}
+	}
+}
+
+// ReplaceAccounts sets the connection's accounts after a reconnect. Previous
+// accounts that are not carried over (compared by ID) become retired, and
+// retired accounts that are back are no longer retired.
+func (conn *Connection) ReplaceAccounts(accounts []Account, now time.Time) {
+	kept := map[string]bool{}
+	for _, acct := range accounts {
+		kept[acct.ID] = true
+	}
+	var retired []RetiredAccount
+	for _, r := range conn.RetiredAccounts {
+		if !kept[r.ID] {
+			retired = append(retired, r)
+		}
+	}
+	for _, acct := range conn.Accounts {
+		if !kept[acct.ID] {
+			retired = append(retired, RetiredAccount{Account: acct, RetiredAt: now})
+		}
+	}
+	conn.Accounts = accounts
+	conn.RetiredAccounts = retired
+}
+
+// LinkAccount merges the retired account with ID retiredID into the current
+// account with ID id, for when the bank changed the account's identification
+// hash. The current account takes over the retired one's ID, alias (unless
+// another account uses it), tags and history. Returns the merged account.
+func (cfg *Config) LinkAccount(retiredID, id string) (*Account, error) {
+	var retired *RetiredAccount
+	var retiredConn *Connection
+	var target *Account
+	for i := range cfg.Connections {
+		conn := &cfg.Connections[i]
+		for j := range conn.RetiredAccounts {
+			if conn.RetiredAccounts[j].ID == retiredID {
+				retired, retiredConn = &conn.RetiredAccounts[j], conn
+			}
+		}
+		for j := range conn.Accounts {
+			if conn.Accounts[j].ID == id {
+				target = &conn.Accounts[j]
+			}
+		}
+	}
+	if retired == nil {
+		return nil, fmt.Errorf("retired account %q not found", retiredID)
+	}
+	if target == nil {
+		return nil, fmt.Errorf("account %q not found", id)
+	}
+
+	old := retired.Account
+	alias := target.Alias
+	if old.Alias != "" && !cfg.aliasUsedByOther(old.Alias, target) {
+		alias = old.Alias
+	}
+	target.Inherit(&old)
+	target.Alias = alias
+
+	kept := retiredConn.RetiredAccounts[:0]
+	for _, r := range retiredConn.RetiredAccounts {
+		if r.ID != retiredID {
+			kept = append(kept, r)
+		}
+	}
+	retiredConn.RetiredAccounts = kept
+	if len(kept) == 0 {
+		retiredConn.RetiredAccounts = nil
+	}
+	return target, nil
+}
+
+// LinkUID records uid as a previous UID of the account with ID id, for UIDs
+// from before ebcli kept account history.
+func (cfg *Config) LinkUID(uid, id string) (*Account, error) {
+	uid = strings.TrimSpace(uid)
+	if uid == "" {
+		return nil, fmt.Errorf("empty UID")
+	}
+	for i := range cfg.Connections {
+		for j := range cfg.Connections[i].Accounts {
+			acct := &cfg.Connections[i].Accounts[j]
+			if acct.ID != id {
+				continue
+			}
+			if !acct.HasUID(uid) {
+				acct.PreviousUIDs = append(acct.PreviousUIDs, uid)
+			}
+			return acct, nil
+		}
+	}
+	return nil, fmt.Errorf("account %q not found", id)
+}
+
+func (cfg *Config) aliasUsedByOther(alias string, self *Account) bool {
+	for i := range cfg.Connections {
+		for j := range cfg.Connections[i].Accounts {
+			acct := &cfg.Connections[i].Accounts[j]
+			if acct != self && strings.EqualFold(acct.Alias, alias) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		found := false
+		for _, x := range list {
+			if x == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, v)
+		}
+	}
+	return list
+}
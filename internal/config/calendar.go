@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/dates"
+)
+
+// calendarKeys are the settings Calendar reads.
+var calendarKeys = []string{"timezone", "week_start", "fiscal_year_start"}
+
+// Calendar returns the conventions date expressions are evaluated with,
+// from the timezone, week_start and fiscal_year_start settings.
+func (cfg *Config) Calendar() (dates.Calendar, error) {
+	cal := dates.Default
+	if cfg == nil {
+		return cal, nil
+	}
+	for _, key := range calendarKeys {
+		if err := cfg.applyCalendarKey(&cal, key); err != nil {
+			return cal, err
+		}
+	}
+	return cal, nil
+}
+
+// applyCalendarKey sets the part of cal that the setting key configures, if
+// it is one of calendarKeys and set.
+func (cfg *Config) applyCalendarKey(cal *dates.Calendar, key string) error {
+	switch {
+	case key == "timezone" && cfg.Timezone != "":
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+		cal.Location = loc
+	case key == "week_start" && cfg.WeekStart != "":
+		day, err := dates.ParseWeekday(cfg.WeekStart)
+		if err != nil {
+			return fmt.Errorf("week_start: %w", err)
+		}
+		cal.WeekStart = day
+	case key == "fiscal_year_start" && cfg.FiscalYearStart != "":
+		md, err := dates.ParseMonthDay(cfg.FiscalYearStart)
+		if err != nil {
+			return fmt.Errorf("fiscal_year_start: %w", err)
+		}
+		cal.FiscalStart = md
+	}
+	return nil
+}
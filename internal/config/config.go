@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -13,6 +14,7 @@ import (
 const (
 	DefaultConfigDir  = ".config/ebcli"
 	DefaultConfigFile = "config.json"
+	DefaultProfile    = "default"
 	FilePermissions   = os.FileMode(0600)
 	DirPermissions    = os.FileMode(0700)
 )
@@ -46,32 +48,100 @@ func Paths(override string) (dir string, filePath string, err error) {
 	return dir, filePath, nil
 }
 
-// Load reads the config file from disk. Returns empty Config if file doesn't exist.
-// Env var overrides are applied after loading.
+// Load reads the current profile from the config file.
+// See LoadProfile.
 func Load(path string) (*Config, error) {
-	cfg := &Config{}
+	return LoadProfile(path, "")
+}
+
+// LoadProfile reads the named profile from the config file. An empty name
+// selects EBCLI_PROFILE, then the file's current profile, then "default".
+// Returns an empty Config if the file doesn't exist or has no profiles yet.
+// Env var overrides are applied after loading.
+func LoadProfile(path, name string) (*Config, error) {
+	f, err := LoadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	name = f.ProfileName(name)
+	cfg, ok := f.Profiles[name]
+	if !ok {
+		if len(f.Profiles) > 0 {
+			return nil, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(f.ProfileNames(), ", "))
+		}
+		cfg = &Config{Profile: name}
+	}
+
+	if err := applyEnvOverrides(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
 
+// LoadFile reads the whole config file with all profiles. Returns an empty
+// File if the file doesn't exist. Files in an older layout are upgraded in
+// memory; see migrate.
+func LoadFile(path string) (*File, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			applyEnvOverrides(cfg)
-			return cfg, nil
+			return &File{Version: CurrentVersion, Profiles: map[string]*Config{}}, nil
 		}
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
+	return parseFile(data)
+}
+
+func parseFile(data []byte) (*File, error) {
+	migrated, from, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
 
-	if err := json.Unmarshal(data, cfg); err != nil {
+	f := &File{}
+	if err := json.Unmarshal(migrated, f); err != nil {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
+	if from != CurrentVersion {
+		f.migratedFrom = from
+	}
 
-	applyEnvOverrides(cfg)
-	return cfg, nil
+	if f.Profiles == nil {
+		f.Profiles = map[string]*Config{}
+	}
+	for name, cfg := range f.Profiles {
+		if cfg == nil {
+			cfg = &Config{}
+			f.Profiles[name] = cfg
+		}
+		cfg.Profile = name
+		cfg.fillAccountIDs()
+	}
+	return f, nil
 }
 
-// Save writes the config to disk with an exclusive file lock.
-// Creates the directory with 0700 and file with 0600 permissions.
-// Uses atomic write: write to temp file in same dir, then rename.
+// Save writes cfg back into its profile in the config file, leaving other
+// profiles untouched. See Update for locking and write semantics.
 func Save(path string, cfg *Config) error {
+	return Update(path, func(f *File) error {
+		name := cfg.Profile
+		if name == "" {
+			name = DefaultProfile
+		}
+		f.Profiles[name] = cfg
+		if f.CurrentProfile == "" {
+			f.CurrentProfile = name
+		}
+		return nil
+	})
+}
+
+// Update applies fn to the config file under an exclusive file lock and writes
+// the result back, upgraded to CurrentVersion. A file in an older layout is
+// first copied to BackupPath. Creates the directory with 0700 and file with 0600 permissions.
+// Uses atomic write: see WriteFileAtomic.
+func Update(path string, fn func(f *File) error) error {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, DirPermissions); err != nil {
 		return fmt.Errorf("creating config directory: %w", err)
@@ -93,20 +163,47 @@ func Save(path string, cfg *Config) error {
 		return fmt.Errorf("acquiring lock: %w", err)
 	}
 
-	data, err := json.MarshalIndent(cfg, "", "  ")
+	original, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	f := &File{Version: CurrentVersion, Profiles: map[string]*Config{}}
+	if original != nil {
+		if f, err = parseFile(original); err != nil {
+			return err
+		}
+	}
+	if err := fn(f); err != nil {
+		return err
+	}
+
+	// Keep the old layout around before it is overwritten
+	if f.migratedFrom != 0 {
+		if err := WriteBackup(path, original, f.migratedFrom); err != nil {
+			return err
+		}
+	}
+	f.Version = CurrentVersion
+
+	data, err := json.MarshalIndent(f, "", "  ")
 	if err != nil {
 		return fmt.Errorf("marshaling config: %w", err)
 	}
 	data = append(data, '\n')
 
-	// Atomic write: temp file + rename
-	tmpFile, err := os.CreateTemp(dir, "config-*.json.tmp")
+	return WriteFileAtomic(path, data, FilePermissions)
+}
+
+// WriteFileAtomic writes data to a temp file in the same directory and renames
+// it over path, so readers never see a partially written file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+"-*.tmp")
 	if err != nil {
 		return fmt.Errorf("creating temp file: %w", err)
 	}
 	tmpPath := tmpFile.Name()
 
-	if err := os.Chmod(tmpPath, FilePermissions); err != nil {
+	if err := os.Chmod(tmpPath, perm); err != nil {
 		tmpFile.Close()
 		os.Remove(tmpPath)
 		return fmt.Errorf("setting file permissions: %w", err)
@@ -125,7 +222,7 @@ func Save(path string, cfg *Config) error {
 
 	if err := os.Rename(tmpPath, path); err != nil {
 		os.Remove(tmpPath)
-		return fmt.Errorf("renaming config file: %w", err)
+		return fmt.Errorf("renaming %s: %w", filepath.Base(path), err)
 	}
 
 	return nil
@@ -155,6 +252,15 @@ func ExpandTilde(path string) (string, error) {
 	return filepath.Join(home, path[1:]), nil
 }
 
+// PSU returns the connection's PSU type. Connections made before it was
+// recorded are personal.
+func (c *Connection) PSU() string {
+	if c.PSUType == "" {
+		return PSUPersonal
+	}
+	return c.PSUType
+}
+
 // AddConnection adds a connection to the config. If a connection with the same
 // name already exists, it returns an error.
 func (cfg *Config) AddConnection(conn Connection) error {
@@ -199,6 +305,132 @@ func (cfg *Config) FindConnection(name string) (*Connection, error) {
 	return nil, fmt.Errorf("connection %q not found", name)
 }
 
+// RenameConnection changes a connection's name. Names are unique (case-insensitive).
+// The old name is kept in FormerNames.
+func (cfg *Config) RenameConnection(name, newName string) error {
+	newName = strings.TrimSpace(newName)
+	if newName == "" {
+		return fmt.Errorf("connection name cannot be empty")
+	}
+	conn, err := cfg.FindConnection(name)
+	if err != nil {
+		return err
+	}
+	if other, err := cfg.FindConnection(newName); err == nil && other != conn {
+		return fmt.Errorf("connection %q already exists", other.Name)
+	}
+	if conn.Name == newName {
+		return nil
+	}
+	var former []string
+	for _, n := range conn.FormerNames {
+		if n != conn.Name && n != newName {
+			former = append(former, n)
+		}
+	}
+	conn.FormerNames = append(former, conn.Name)
+	conn.Name = newName
+	return nil
+}
+
+// Aliases returns the aliases of all accounts, lowercased.
+func (cfg *Config) Aliases() map[string]bool {
+	aliases := make(map[string]bool)
+	for _, conn := range cfg.Connections {
+		for _, acct := range conn.Accounts {
+			aliases[strings.ToLower(acct.Alias)] = true
+		}
+	}
+	return aliases
+}
+
+// AliasReserved are the characters aliases and tags cannot contain, as they
+// have a meaning in account selectors.
+const AliasReserved = ",+:*?[]"
+
+// RenameAccount sets the alias of the account with the given UID. Aliases are
+// unique across all connections (case-insensitive).
+func (cfg *Config) RenameAccount(uid, alias string) error {
+	alias = strings.TrimSpace(alias)
+	if err := checkAlias(alias); err != nil {
+		return err
+	}
+
+	var target *Account
+	for i := range cfg.Connections {
+		for j := range cfg.Connections[i].Accounts {
+			acct := &cfg.Connections[i].Accounts[j]
+			if acct.UID == uid {
+				target = acct
+			} else if strings.EqualFold(acct.Alias, alias) {
+				return fmt.Errorf("alias %q is already used by another account", alias)
+			}
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("account %q not found", uid)
+	}
+	target.Alias = alias
+	return nil
+}
+
+// checkAlias returns why alias cannot name an account, if it can't.
+func checkAlias(alias string) error {
+	if alias == "" {
+		return fmt.Errorf("alias cannot be empty")
+	}
+	if strings.ContainsAny(alias, " \t"+AliasReserved) {
+		return fmt.Errorf("alias %q must not contain spaces or any of %s", alias, AliasReserved)
+	}
+	return nil
+}
+
+// TagAccount adds tags to the account with the given UID, or removes them
+// if remove is set. Tags are compared case-insensitively.
+func (cfg *Config) TagAccount(uid string, tags []string, remove bool) error {
+	var target *Account
+	for i := range cfg.Connections {
+		for j := range cfg.Connections[i].Accounts {
+			if cfg.Connections[i].Accounts[j].UID == uid {
+				target = &cfg.Connections[i].Accounts[j]
+			}
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("account %q not found", uid)
+	}
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return fmt.Errorf("tag cannot be empty")
+		}
+		if strings.ContainsAny(tag, " \t"+AliasReserved) {
+			return fmt.Errorf("tag %q must not contain spaces or any of %s", tag, AliasReserved)
+		}
+
+		kept := target.Tags[:0:0]
+		found := false
+		for _, t := range target.Tags {
+			if strings.EqualFold(t, tag) {
+				found = true
+				if remove {
+					continue
+				}
+			}
+			kept = append(kept, t)
+		}
+		if !found && !remove {
+			kept = append(kept, tag)
+		}
+		target.Tags = kept
+	}
+	if len(target.Tags) == 0 {
+		target.Tags = nil
+	}
+	return nil
+}
+
 // AllAccounts returns a flat list of all accounts across all connections.
 func (cfg *Config) AllAccounts() []ResolvedAccount {
 	var accounts []ResolvedAccount
@@ -223,11 +455,43 @@ type ResolvedAccount struct {
 	RequiredPSUHeaders []string  `json:"required_psu_headers,omitempty"`
 }
 
-func applyEnvOverrides(cfg *Config) {
+func applyEnvOverrides(cfg *Config) error {
 	if v := os.Getenv("EBCLI_APP_ID"); v != "" {
 		cfg.AppID = v
 	}
 	if v := os.Getenv("EBCLI_PRIVATE_KEY"); v != "" {
-		cfg.PrivateKeyPath = v
+		cfg.KeyPathEnv = v
+	}
+	if v := os.Getenv("EBCLI_PRIVATE_KEY_PEM"); v != "" {
+		cfg.PrivateKeyPEM = v
+	}
+	if v := os.Getenv("EBCLI_PRIVATE_KEY_FD"); v != "" {
+		fd, err := strconv.Atoi(v)
+		if err != nil || fd <= 0 {
+			return fmt.Errorf("EBCLI_PRIVATE_KEY_FD=%s: expected a file descriptor above 0 (stdin cannot be used)", v)
+		}
+		cfg.PrivateKeyFD = fd
+	}
+	if v := os.Getenv("EBCLI_BASE_URL"); v != "" {
+		cfg.BaseURLEnv = v
+	}
+	return nil
+}
+
+// KeyPath returns the private key file: EBCLI_PRIVATE_KEY, or else
+// private_key_path.
+func (cfg *Config) KeyPath() string {
+	if cfg.KeyPathEnv != "" {
+		return cfg.KeyPathEnv
+	}
+	return cfg.PrivateKeyPath
+}
+
+// APIBaseURL returns the base URL to use for the API: EBCLI_BASE_URL, or
+// else base_url. Empty means the default.
+func (cfg *Config) APIBaseURL() string {
+	if cfg.BaseURLEnv != "" {
+		return cfg.BaseURLEnv
 	}
+	return cfg.BaseURL
 }
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CurrentVersion is the config file layout written by this version of ebcli.
+//
+//	1: a single application at the top level (no "version" field, or 0)
+//	2: named profiles under "profiles"
+const CurrentVersion = 2
+
+// migration upgrades the raw config document by one version, from the
+// version it is registered under to the next.
+type migration func(doc map[string]json.RawMessage) (map[string]json.RawMessage, error)
+
+var migrations = map[int]migration{
+	1: migrateToProfiles,
+}
+
+// fileVersion returns the layout version of a raw config document. Files
+// written before versioning, or with "version": 0, are told apart by their
+// shape.
+func fileVersion(doc map[string]json.RawMessage) (int, error) {
+	if v, ok := doc["version"]; ok {
+		var version int
+		if err := json.Unmarshal(v, &version); err != nil {
+			return 0, fmt.Errorf("invalid config version: %s", v)
+		}
+		if version != 0 {
+			return version, nil
+		}
+	}
+	if _, ok := doc["profiles"]; ok {
+		return 2, nil
+	}
+	return 1, nil
+}
+
+// migrate upgrades a raw config document to CurrentVersion. It returns the
+// upgraded JSON and the version it started from.
+func migrate(data []byte) ([]byte, int, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("parsing config: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]json.RawMessage{}
+	}
+
+	from, err := fileVersion(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	if from > CurrentVersion {
+		return nil, 0, fmt.Errorf("config version %d is newer than this ebcli supports (%d); upgrade ebcli", from, CurrentVersion)
+	}
+	if from == CurrentVersion {
+		return data, from, nil
+	}
+
+	for v := from; v < CurrentVersion; v++ {
+		step, ok := migrations[v]
+		if !ok {
+			return nil, 0, fmt.Errorf("no migration from config version %d", v)
+		}
+		if doc, err = step(doc); err != nil {
+			return nil, 0, fmt.Errorf("migrating config from version %d: %w", v, err)
+		}
+	}
+
+	doc["version"] = json.RawMessage(fmt.Sprint(CurrentVersion))
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, from, nil
+}
+
+// migrateToProfiles moves a single-application config into the "default"
+// profile. Any setting is worth keeping, so only an empty document migrates
+// to no profiles.
+func migrateToProfiles(doc map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	delete(doc, "version")
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var legacy Config
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return nil, err
+	}
+
+	profiles := map[string]json.RawMessage{}
+	current := ""
+	if len(doc) > 0 {
+		profiles[DefaultProfile] = data
+		current = DefaultProfile
+	}
+
+	profilesJSON, err := json.Marshal(profiles)
+	if err != nil {
+		return nil, err
+	}
+	currentJSON, _ := json.Marshal(current)
+	return map[string]json.RawMessage{
+		"current_profile": currentJSON,
+		"profiles":        profilesJSON,
+	}, nil
+}
+
+// BackupPath returns where the pre-migration copy of a file is kept.
+func BackupPath(path string, version int) string {
+	return fmt.Sprintf("%s.bak-v%d", path, version)
+}
+
+// WriteBackup saves the original bytes of a file before it is rewritten in a
+// newer layout, so a downgrade can restore it. The config file and the rate
+// limit cache share it.
+func WriteBackup(path string, data []byte, version int) error {
+	if err := os.WriteFile(BackupPath(path, version), data, FilePermissions); err != nil {
+		return fmt.Errorf("writing backup of %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/nicolasacchi/ebcli/internal/dates"
+)
+
+// Paths into a profile are dot-separated JSON field names, e.g. "callback_url"
+// or "connections.ing.max_access_per_day". Connections are addressed by name,
+// accounts by alias or UID, and any list element by its index.
+
+// Get returns the value at path in the profile.
+func (cfg *Config) Get(path string) (any, error) {
+	segs, err := splitPath(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := cfg.doc()
+	if err != nil {
+		return nil, err
+	}
+
+	node, t := doc, reflect.TypeOf(Config{})
+	for i, seg := range segs {
+		next, nt, err := child(node, t, seg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strings.Join(segs[:i+1], "."), err)
+		}
+		node, t = next, nt
+	}
+	return node, nil
+}
+
+// Set stores value at path in the profile. value is parsed as JSON when that
+// fits the field's type (numbers, booleans, lists) and used as a string otherwise.
+func (cfg *Config) Set(path, value string) error {
+	return cfg.edit(path, &value)
+}
+
+// Unset removes the value at path, resetting a field to its zero value or
+// deleting a list element.
+func (cfg *Config) Unset(path string) error {
+	return cfg.edit(path, nil)
+}
+
+func (cfg *Config) edit(path string, value *string) error {
+	segs, err := splitPath(path)
+	if err != nil {
+		return err
+	}
+	if segs[0] == "encrypted_connections" {
+		return fmt.Errorf("encrypted_connections is managed by: ebcli config encrypt/decrypt")
+	}
+	if segs[0] == "connections" && len(segs) == 3 && segs[2] == "name" {
+		return fmt.Errorf("connections are renamed with: ebcli connections edit <name> --rename <new>")
+	}
+
+	doc, err := cfg.doc()
+	if err != nil {
+		return err
+	}
+	dupesBefore := cfg.duplicateAliases()
+
+	var set any
+	if value != nil {
+		if err := json.Unmarshal([]byte(*value), &set); err != nil {
+			set = *value
+		}
+	}
+
+	next, err := cfg.apply(doc, segs, set, value == nil)
+	if err != nil && value != nil {
+		if _, isString := set.(string); !isString {
+			// "123" for a string field: retry as a literal string
+			next, err = cfg.apply(doc, segs, *value, false)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	for alias := range next.duplicateAliases() {
+		if !dupesBefore[alias] {
+			return fmt.Errorf("alias %q is already used by another account", alias)
+		}
+	}
+	aliases := map[string]string{} // by UID, before the edit
+	for _, conn := range cfg.Connections {
+		for _, acct := range conn.Accounts {
+			aliases[acct.UID] = acct.Alias
+		}
+	}
+	for _, conn := range next.Connections {
+		for _, acct := range conn.Accounts {
+			if before, ok := aliases[acct.UID]; ok && before == acct.Alias {
+				continue
+			}
+			if err := checkAlias(acct.Alias); err != nil {
+				return err
+			}
+		}
+	}
+
+	// The edited setting must be valid even if another one is not
+	cal := dates.Default
+	if err := next.applyCalendarKey(&cal, segs[0]); err != nil {
+		return err
+	}
+
+	*cfg = *next
+	return nil
+}
+
+// apply sets or deletes segs in a copy of doc and decodes the result into a
+// new Config that keeps cfg's runtime-only state.
+func (cfg *Config) apply(doc any, segs []string, value any, unset bool) (*Config, error) {
+	doc = deepCopy(doc)
+	doc, err := setPath(doc, reflect.TypeOf(Config{}), segs, value, unset)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var fresh plainConfig
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fresh); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("invalid value for %s: expected %s", strings.Join(segs, "."), typeErr.Type)
+		}
+		return nil, fmt.Errorf("invalid value for %s: %w", strings.Join(segs, "."), err)
+	}
+
+	next := Config(fresh)
+	next.Profile = cfg.Profile
+	next.PrivateKeyPEM = cfg.PrivateKeyPEM
+	next.PrivateKeyFD = cfg.PrivateKeyFD
+	next.KeyPathEnv = cfg.KeyPathEnv
+	next.BaseURLEnv = cfg.BaseURLEnv
+	next.EncryptedConnections = cfg.EncryptedConnections
+	next.sealKey = cfg.sealKey
+	next.sealSalt = cfg.sealSalt
+	next.sealIterations = cfg.sealIterations
+	return &next, nil
+}
+
+// plainConfig is Config without its MarshalJSON, so connections stay readable.
+type plainConfig Config
+
+// doc returns the profile as a generic JSON document.
+func (cfg *Config) doc() (any, error) {
+	data, err := json.Marshal((*plainConfig)(cfg))
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+func (cfg *Config) duplicateAliases() map[string]bool {
+	seen := map[string]bool{}
+	dupes := map[string]bool{}
+	for _, conn := range cfg.Connections {
+		for _, acct := range conn.Accounts {
+			key := strings.ToLower(acct.Alias)
+			if key == "" {
+				continue
+			}
+			if seen[key] {
+				dupes[key] = true
+			}
+			seen[key] = true
+		}
+	}
+	return dupes
+}
+
+func splitPath(path string) ([]string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("empty config path")
+	}
+	segs := strings.Split(path, ".")
+	for _, seg := range segs {
+		if seg == "" {
+			return nil, fmt.Errorf("invalid config path %q", path)
+		}
+	}
+	return segs, nil
+}
+
+// child returns the element seg of node, whose Go type is t.
+func child(node any, t reflect.Type, seg string) (any, reflect.Type, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		ft, ok := jsonFields(t)[seg]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown field")
+		}
+		obj, _ := node.(map[string]any)
+		return obj[seg], ft, nil
+	case reflect.Map:
+		obj, _ := node.(map[string]any)
+		v, ok := obj[seg]
+		if !ok {
+			return nil, nil, fmt.Errorf("not found")
+		}
+		return v, t.Elem(), nil
+	case reflect.Slice:
+		arr, _ := node.([]any)
+		i, err := findElement(arr, seg)
+		if err != nil {
+			return nil, nil, err
+		}
+		return arr[i], t.Elem(), nil
+	default:
+		return nil, nil, fmt.Errorf("not an object or list")
+	}
+}
+
+// setPath sets or deletes the element at segs below node and returns the
+// updated node.
+func setPath(node any, t reflect.Type, segs []string, value any, unset bool) (any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	seg := segs[0]
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		var elemType reflect.Type
+		if t.Kind() == reflect.Struct {
+			ft, ok := jsonFields(t)[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown field", seg)
+			}
+			elemType = ft
+		} else {
+			elemType = t.Elem()
+		}
+		obj, _ := node.(map[string]any)
+		if obj == nil {
+			obj = map[string]any{}
+		}
+		if len(segs) == 1 {
+			if unset {
+				delete(obj, seg)
+			} else {
+				obj[seg] = value
+			}
+			return obj, nil
+		}
+		if obj[seg] == nil {
+			optional := elemType.Kind() == reflect.Pointer && elemType.Elem().Kind() == reflect.Struct
+			if (elemType.Kind() != reflect.Map && !optional) || unset {
+				return nil, fmt.Errorf("%s: not set", seg)
+			}
+			obj[seg] = map[string]any{} // e.g. the first entry of groups, or redact.mask
+		}
+		v, err := setPath(obj[seg], elemType, segs[1:], value, unset)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%w", seg, err)
+		}
+		obj[seg] = v
+		return obj, nil
+	case reflect.Slice:
+		arr, _ := node.([]any)
+		i, err := findElement(arr, seg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", seg, err)
+		}
+		if len(segs) == 1 {
+			if unset {
+				return append(arr[:i], arr[i+1:]...), nil
+			}
+			arr[i] = value
+			return arr, nil
+		}
+		v, err := setPath(arr[i], t.Elem(), segs[1:], value, unset)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%w", seg, err)
+		}
+		arr[i] = v
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("%s: not an object or list", seg)
+	}
+}
+
+// findElement locates a list element by index, or by a "name", "alias" or
+// "uid" field (case-insensitive).
+func findElement(arr []any, seg string) (int, error) {
+	for i, v := range arr {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		for _, field := range []string{"name", "alias", "uid"} {
+			if s, ok := obj[field].(string); ok && s != "" && strings.EqualFold(s, seg) {
+				return i, nil
+			}
+		}
+	}
+	if i, err := strconv.Atoi(seg); err == nil {
+		if i < 0 || i >= len(arr) {
+			return 0, fmt.Errorf("index out of range (%d elements)", len(arr))
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("not found")
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = deepCopy(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = deepCopy(e)
+		}
+		return out
+	default:
+		return v
+	}
+}
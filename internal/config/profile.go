@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ProfileName resolves which profile to use. Priority: explicit name,
+// EBCLI_PROFILE env var, the file's current profile, then "default".
+func (f *File) ProfileName(name string) string {
+	if name != "" {
+		return name
+	}
+	if v := os.Getenv("EBCLI_PROFILE"); v != "" {
+		return v
+	}
+	if f.CurrentProfile != "" {
+		return f.CurrentProfile
+	}
+	return DefaultProfile
+}
+
+// ProfileNames returns all profile names, sorted.
+func (f *File) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddProfile adds a new profile. Returns an error if the name is invalid or taken.
+func (f *File) AddProfile(name string, cfg *Config) error {
+	if err := validateProfileName(name); err != nil {
+		return err
+	}
+	if _, ok := f.Profiles[name]; ok {
+		return fmt.Errorf("profile %q already exists", name)
+	}
+	if cfg.Connections == nil {
+		cfg.Connections = []Connection{}
+	}
+	cfg.Profile = name
+	f.Profiles[name] = cfg
+	if f.CurrentProfile == "" {
+		f.CurrentProfile = name
+	}
+	return nil
+}
+
+// UseProfile makes name the current profile.
+func (f *File) UseProfile(name string) error {
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	f.CurrentProfile = name
+	return nil
+}
+
+// RemoveProfile deletes a profile. The current profile cannot be removed.
+func (f *File) RemoveProfile(name string) error {
+	if _, ok := f.Profiles[name]; !ok {
+		return fmt.Errorf("profile %q not found", name)
+	}
+	if f.CurrentProfile == name {
+		return fmt.Errorf("profile %q is the current profile; switch with 'ebcli profile use' first", name)
+	}
+	delete(f.Profiles, name)
+	return nil
+}
+
+func validateProfileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("empty profile name")
+	}
+	if strings.ContainsAny(name, " \t/\\:") {
+		return fmt.Errorf("invalid profile name %q (no spaces, slashes or colons)", name)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+
+	"github.com/nicolasacchi/ebcli/internal/auth"
+)
+
+const (
+	sealKDF        = "pbkdf2-sha256"
+	sealIterations = 310000
+)
+
+// SealedConnections is the connections section encrypted with a passphrase
+// (PBKDF2-SHA256 + AES-256-GCM), so that a leaked copy of config.json does not
+// expose live bank session IDs.
+type SealedConnections struct {
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// IsSealed reports whether the connections are encrypted on disk and have
+// not been unsealed yet. Connections is empty until Unseal succeeds.
+func (cfg *Config) IsSealed() bool {
+	return cfg.EncryptedConnections != nil && cfg.sealKey == nil
+}
+
+// ConnectionsEncrypted reports whether connections will be encrypted on Save.
+func (cfg *Config) ConnectionsEncrypted() bool {
+	return cfg.sealKey != nil
+}
+
+// Unseal decrypts the connections section with the given passphrase.
+func (cfg *Config) Unseal(passphrase []byte) error {
+	sealed := cfg.EncryptedConnections
+	if sealed == nil {
+		return nil
+	}
+	if sealed.KDF != sealKDF {
+		return fmt.Errorf("unsupported connections encryption %q", sealed.KDF)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), sealed.Salt, sealed.Iterations, 32)
+	if err != nil {
+		return fmt.Errorf("deriving key: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	plain, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return fmt.Errorf("decrypting connections: %w or corrupted config", auth.ErrWrongPassphrase)
+	}
+
+	var conns []Connection
+	if err := json.Unmarshal(plain, &conns); err != nil {
+		return fmt.Errorf("parsing decrypted connections: %w", err)
+	}
+
+	cfg.Connections = conns
+	cfg.fillAccountIDs()
+	cfg.sealKey = key
+	cfg.sealSalt = sealed.Salt
+	cfg.sealIterations = sealed.Iterations
+	return nil
+}
+
+// EncryptConnections turns on encryption of the connections section with a
+// new passphrase. It takes effect on the next Save.
+func (cfg *Config) EncryptConnections(passphrase []byte) error {
+	if cfg.IsSealed() {
+		return fmt.Errorf("connections are sealed; unseal them first")
+	}
+	if len(passphrase) == 0 {
+		return fmt.Errorf("empty passphrase")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, sealIterations, 32)
+	if err != nil {
+		return fmt.Errorf("deriving key: %w", err)
+	}
+
+	cfg.sealKey = key
+	cfg.sealSalt = salt
+	cfg.sealIterations = sealIterations
+	return nil
+}
+
+// DecryptConnections turns off encryption; the next Save writes connections
+// in plaintext. The config must have been unsealed.
+func (cfg *Config) DecryptConnections() error {
+	if cfg.IsSealed() {
+		return fmt.Errorf("connections are sealed; unseal them first")
+	}
+	cfg.sealKey = nil
+	cfg.sealSalt = nil
+	cfg.EncryptedConnections = nil
+	return nil
+}
+
+// MarshalJSON writes the connections section encrypted when encryption is on.
+func (cfg *Config) MarshalJSON() ([]byte, error) {
+	type plain Config
+	if cfg.sealKey == nil {
+		return json.Marshal((*plain)(cfg))
+	}
+
+	conns, err := json.Marshal(cfg.Connections)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(cfg.sealKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := plain(*cfg)
+	out.Connections = []Connection{}
+	out.EncryptedConnections = &SealedConnections{
+		KDF:        sealKDF,
+		Iterations: cfg.sealIterations,
+		Salt:       cfg.sealSalt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, conns, nil),
+	}
+	return json.Marshal(out)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,13 +2,64 @@ package config
 
 import "time"
 
-// Config represents the top-level configuration stored at ~/.config/ebcli/config.json.
+// File is the on-disk layout of ~/.config/ebcli/config.json: a set of named
+// profiles, each holding one Enable Banking application and its connections.
+type File struct {
+	Version        int                `json:"version"` // layout version, see CurrentVersion
+	CurrentProfile string             `json:"current_profile"`
+	Profiles       map[string]*Config `json:"profiles"`
+
+	migratedFrom int // version the file was upgraded from on load; 0 if current
+}
+
+// Config is a single profile: one Enable Banking application and its connections.
 type Config struct {
+	Profile        string       `json:"-"` // profile name, set on load
 	AppID          string       `json:"app_id"`
 	PrivateKeyPath string       `json:"private_key_path"`
-	Environment    string       `json:"environment"` // "PRODUCTION" or "SANDBOX"
+	KeyCommand     string       `json:"key_command,omitempty"` // prints the PEM key on stdout
+	PrivateKeyPEM  string       `json:"-"`                     // from EBCLI_PRIVATE_KEY_PEM, never saved
+	PrivateKeyFD   int          `json:"-"`                     // from EBCLI_PRIVATE_KEY_FD, never saved; 0 = unset
+	KeyPathEnv     string       `json:"-"`                     // from EBCLI_PRIVATE_KEY, never saved; overrides KeyCommand and PrivateKeyPath
+	Environment    string       `json:"environment"`           // "PRODUCTION" or "SANDBOX"; informational, the app ID decides
 	CallbackURL    string       `json:"callback_url,omitempty"`
+	CallbackBind   string       `json:"callback_bind,omitempty"` // callback server interface; default: all
+	CallbackTLS    bool         `json:"callback_tls,omitempty"`  // serve the callback over HTTPS
+	CallbackCert   string       `json:"callback_cert,omitempty"` // default: self-signed, generated in the config dir
+	CallbackKey    string       `json:"callback_key,omitempty"`
+	CallbackPage   string       `json:"callback_page,omitempty"` // html/template shown after the redirect
+	BaseURL        string       `json:"base_url,omitempty"`      // default: api.BaseURL
+	BaseURLEnv     string       `json:"-"`                       // from EBCLI_BASE_URL, never saved; overrides BaseURL
+	Proxy          string       `json:"proxy,omitempty"`         // default: HTTPS_PROXY env
+	CABundle       string       `json:"ca_bundle,omitempty"`     // extra trusted CA certificates (PEM)
 	Connections    []Connection `json:"connections"`
+
+	// Date expressions (see internal/dates); empty = local time, Monday, January 1st
+	Timezone        string `json:"timezone,omitempty"`          // IANA name, e.g. "Europe/Helsinki"
+	WeekStart       string `json:"week_start,omitempty"`        // e.g. "sunday"
+	FiscalYearStart string `json:"fiscal_year_start,omitempty"` // MM-DD, e.g. "04-06"
+
+	// Groups are named lists of account selectors, used as --account group:<name>.
+	Groups map[string][]string `json:"groups,omitempty"`
+
+	// Redact chooses the fields --redact changes; unset lists keep the defaults.
+	Redact *Redaction `json:"redact,omitempty"`
+
+	// PassphraseCommand prints the passphrase for an encrypted private key
+	// and encrypted connections on stdout. Falls back to EBCLI_PASSPHRASE, then /dev/tty.
+	PassphraseCommand    string             `json:"passphrase_command,omitempty"`
+	EncryptedConnections *SealedConnections `json:"encrypted_connections,omitempty"`
+
+	sealKey        []byte // set once connections are unsealed or encryption is enabled
+	sealSalt       []byte
+	sealIterations int
+}
+
+// Redaction lists fields by JSON key ("iban") or path suffix ("creditor.name").
+type Redaction struct {
+	Mask         []string `json:"mask,omitempty"`         // keep the country and last 4 characters
+	Pseudonymize []string `json:"pseudonymize,omitempty"` // replace with stable pseudonyms
+	Remove       []string `json:"remove,omitempty"`       // drop
 }
 
 // Connection represents an authorized bank session.
@@ -23,14 +74,41 @@ type Connection struct {
 	MaxConsentValiditySeconds int       `json:"max_consent_validity_seconds"`
 	RequiredPSUHeaders        []string  `json:"required_psu_headers,omitempty"`
 	MaxAccessPerDay           int       `json:"max_access_per_day,omitempty"` // 0 = unlimited
+	PSUType                   string    `json:"psu_type,omitempty"`           // "personal" or "business"; empty = personal
+	AuthMethod                string    `json:"auth_method,omitempty"`        // reused by reconnect
+	WindowDays                int       `json:"window_days,omitempty"`        // longest range per transactions query; 0 = 90 days
+	MaxLookbackDays           int       `json:"max_lookback_days,omitempty"`  // history the bank provides, learned from its errors; 0 = unknown
+	FormerNames               []string  `json:"former_names,omitempty"`       // earlier names, which audit records may carry
+
+	// RetiredAccounts are accounts the bank stopped returning on reconnect.
+	RetiredAccounts []RetiredAccount `json:"retired_accounts,omitempty"`
 }
 
 // Account represents a single bank account within a connection.
 type Account struct {
-	UID                string `json:"uid"`
-	IBAN               string `json:"iban,omitempty"`
-	Alias              string `json:"alias"`
-	Currency           string `json:"currency"`
-	IdentificationHash string `json:"identification_hash"`
-	CashAccountType    string `json:"cash_account_type"`
+	ID                 string   `json:"id,omitempty"` // stable local ID, kept across reconnects
+	UID                string   `json:"uid"`
+	IBAN               string   `json:"iban,omitempty"`
+	Alias              string   `json:"alias"`
+	Currency           string   `json:"currency"`
+	IdentificationHash string   `json:"identification_hash"`
+	CashAccountType    string   `json:"cash_account_type"`
+	Usage              string   `json:"usage,omitempty"` // PRIV (private) or ORGA (business), as reported by the bank
+	Tags               []string `json:"tags,omitempty"`  // user-defined, used as --account tag:<name>
+
+	IdentificationHashes []string `json:"identification_hashes,omitempty"` // every hash seen for the account
+	PreviousUIDs         []string `json:"previous_uids,omitempty"`         // UIDs from earlier sessions, oldest first
 }
+
+// RetiredAccount is an account that is no longer part of its connection's
+// session. It keeps its ID and UIDs so it can be linked to a new account.
+type RetiredAccount struct {
+	Account
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+// PSU types, the kind of account holder a connection was authorized for.
+const (
+	PSUPersonal = "personal"
+	PSUBusiness = "business"
+)
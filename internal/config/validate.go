@@ -0,0 +1,304 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Issue severities reported by Validate.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue is a problem found in the config file.
+type Issue struct {
+	Severity string `json:"severity"`
+	Profile  string `json:"profile,omitempty"`
+	Path     string `json:"path,omitempty"` // JSON path of the offending field
+	Message  string `json:"message"`
+}
+
+// Validate checks the config file at path and reports unknown fields,
+// duplicate account aliases, missing key files and expired connections.
+// Encrypted connections are not checked until they are unsealed.
+func Validate(path string, now time.Time) ([]Issue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+
+	migrated, from, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []Issue
+	if from != CurrentVersion {
+		issues = append(issues, Issue{
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("config is in layout version %d; it is upgraded to %d on the next write (backup: %s)", from, CurrentVersion, BackupPath(path, from)),
+		})
+	}
+
+	var unknown []string
+	unknownFields(json.RawMessage(migrated), reflect.TypeOf(File{}), "", &unknown)
+	for _, p := range unknown {
+		issues = append(issues, Issue{Severity: SeverityWarning, Path: p, Message: "unknown field"})
+	}
+
+	f, err := parseFile(data)
+	if err != nil {
+		return nil, err
+	}
+	if f.CurrentProfile != "" && f.Profiles[f.CurrentProfile] == nil {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Path:     "current_profile",
+			Message:  fmt.Sprintf("current profile %q does not exist", f.CurrentProfile),
+		})
+	}
+	for _, name := range f.ProfileNames() {
+		issues = append(issues, f.Profiles[name].validate(now)...)
+	}
+	return issues, nil
+}
+
+func (cfg *Config) validate(now time.Time) []Issue {
+	var issues []Issue
+	add := func(severity, path, format string, args ...any) {
+		issues = append(issues, Issue{
+			Severity: severity,
+			Profile:  cfg.Profile,
+			Path:     "profiles." + cfg.Profile + path,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if cfg.AppID == "" {
+		add(SeverityError, ".app_id", "app_id is not set")
+	}
+
+	switch {
+	case cfg.KeyCommand != "":
+		// Checked when the key is used; running it here could prompt
+	case cfg.PrivateKeyPath == "":
+		add(SeverityWarning, ".private_key_path", "no private key configured; EBCLI_PRIVATE_KEY_PEM or EBCLI_PRIVATE_KEY_FD must be set at runtime")
+	default:
+		keyPath, err := ExpandTilde(cfg.PrivateKeyPath)
+		if err == nil {
+			_, err = os.Stat(keyPath)
+		}
+		if err != nil {
+			add(SeverityError, ".private_key_path", "private key file: %v", err)
+		}
+	}
+
+	// The other half can come from --tls-key or --tls-cert
+	switch {
+	case cfg.CallbackCert != "" && cfg.CallbackKey == "":
+		add(SeverityWarning, ".callback_cert", "callback_cert is set without callback_key; HTTPS callbacks need --tls-key")
+	case cfg.CallbackKey != "" && cfg.CallbackCert == "":
+		add(SeverityWarning, ".callback_key", "callback_key is set without callback_cert; it is only used with --tls-cert")
+	}
+	for _, f := range []struct{ field, path string }{
+		{"callback_cert", cfg.CallbackCert},
+		{"callback_key", cfg.CallbackKey},
+		{"callback_page", cfg.CallbackPage},
+	} {
+		if f.path == "" {
+			continue
+		}
+		path, err := ExpandTilde(f.path)
+		if err == nil {
+			_, err = os.Stat(path)
+		}
+		if err != nil {
+			add(SeverityError, "."+f.field, "%s: %v", f.field, err)
+		}
+	}
+
+	if _, err := cfg.Calendar(); err != nil {
+		field, _, _ := strings.Cut(err.Error(), ":")
+		add(SeverityError, "."+field, "%v", err)
+	}
+
+	groupNames := make([]string, 0, len(cfg.Groups))
+	for name := range cfg.Groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+	for _, name := range groupNames {
+		if len(cfg.Groups[name]) == 0 {
+			add(SeverityWarning, ".groups."+name, "group %q is empty", name)
+		}
+		for _, sel := range cfg.Groups[name] {
+			for _, term := range strings.Split(sel, ",") {
+				key, ref, ok := strings.Cut(strings.TrimSpace(term), ":")
+				if ok && strings.EqualFold(key, "group") && !cfg.hasGroup(strings.TrimSpace(ref)) {
+					add(SeverityError, ".groups."+name, "group %q includes unknown group %q", name, strings.TrimSpace(ref))
+				}
+			}
+		}
+	}
+
+	if cfg.EncryptedConnections != nil {
+		add(SeverityInfo, ".encrypted_connections", "connections are encrypted and were not checked")
+		return issues
+	}
+
+	seenConn := map[string]int{}
+	seenAlias := map[string]string{}
+	seenID := map[string]bool{}
+	for i, conn := range cfg.Connections {
+		connPath := fmt.Sprintf(".connections[%d]", i)
+
+		if j, ok := seenConn[strings.ToLower(conn.Name)]; ok {
+			add(SeverityError, connPath+".name", "connection name %q already used by connections[%d]", conn.Name, j)
+		} else {
+			seenConn[strings.ToLower(conn.Name)] = i
+		}
+
+		if !conn.ValidUntil.IsZero() && now.After(conn.ValidUntil) {
+			add(SeverityWarning, connPath+".valid_until", "connection %q expired on %s. Run: ebcli reconnect %s",
+				conn.Name, conn.ValidUntil.Format("2006-01-02"), conn.Name)
+		}
+
+		for j, acct := range conn.Accounts {
+			if acct.ID != "" && seenID[acct.ID] {
+				add(SeverityError, fmt.Sprintf("%s.accounts[%d].id", connPath, j), "account id %q is used by another account", acct.ID)
+			}
+			seenID[acct.ID] = true
+			for _, tag := range acct.Tags {
+				if strings.TrimSpace(tag) == "" || strings.ContainsAny(tag, " \t"+AliasReserved) {
+					add(SeverityError, fmt.Sprintf("%s.accounts[%d].tags", connPath, j), "tag %q must not be empty or contain spaces or any of %s", tag, AliasReserved)
+				}
+			}
+			if acct.Alias == "" {
+				continue
+			}
+			key := strings.ToLower(acct.Alias)
+			if other, ok := seenAlias[key]; ok {
+				add(SeverityError, fmt.Sprintf("%s.accounts[%d].alias", connPath, j), "alias %q already used by an account in %s", acct.Alias, other)
+				continue
+			}
+			seenAlias[key] = conn.Name
+		}
+		for j, r := range conn.RetiredAccounts {
+			if r.ID != "" && seenID[r.ID] {
+				add(SeverityError, fmt.Sprintf("%s.retired_accounts[%d].id", connPath, j), "account id %q is used by another account", r.ID)
+			}
+			seenID[r.ID] = true
+		}
+	}
+
+	return issues
+}
+
+func (cfg *Config) hasGroup(name string) bool {
+	for g := range cfg.Groups {
+		if strings.EqualFold(g, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownFields walks a JSON document alongside the Go type it decodes into
+// and collects the paths of object keys that no struct field claims.
+func unknownFields(data json.RawMessage, t reflect.Type, path string, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return
+		}
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := joinPath(path, k)
+			ft, ok := fields[k]
+			if !ok {
+				*out = append(*out, child)
+				continue
+			}
+			unknownFields(obj[k], ft, child, out)
+		}
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			unknownFields(obj[k], t.Elem(), joinPath(path, k), out)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return // []byte is a base64 string
+		}
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) != nil {
+			return
+		}
+		for i, v := range arr {
+			unknownFields(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of t's exported fields to their types,
+// following embedded structs the way encoding/json does.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			for k, v := range jsonFields(f.Type) {
+				fields[k] = v
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
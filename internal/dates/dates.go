@@ -0,0 +1,271 @@
+// Package dates evaluates the date expressions accepted by --from, --to and
+// --period: absolute dates, relative offsets and named calendar periods.
+//
+// Dates are calendar days, not instants. They are returned as midnight UTC so
+// that formatting them with "2006-01-02" gives the same day everywhere; the
+// Calendar's location only decides which day "today" is.
+package dates
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Period is an inclusive range of days.
+type Period struct {
+	From time.Time
+	To   time.Time
+}
+
+// Calendar holds the conventions expressions are evaluated with.
+type Calendar struct {
+	Location    *time.Location // where "today" is taken; nil = time.Local
+	WeekStart   time.Weekday   // first day of this-week and last-week
+	FiscalStart MonthDay       // first day of the fiscal year
+}
+
+// MonthDay is a day of the year, such as the start of a fiscal year.
+type MonthDay struct {
+	Month time.Month
+	Day   int
+}
+
+// Default is the calendar used when nothing is configured: local time,
+// weeks starting on Monday and fiscal years on January 1st.
+var Default = Calendar{WeekStart: time.Monday, FiscalStart: MonthDay{time.January, 1}}
+
+// Date returns the day y-m-d, normalized like time.Date.
+func Date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Today returns the current day in the calendar's location.
+func (c Calendar) Today(now time.Time) time.Time {
+	loc := c.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now = now.In(loc)
+	return Date(now.Year(), now.Month(), now.Day())
+}
+
+// Parse evaluates expr relative to now. Single days, such as "yesterday" or
+// "-3m", give a period of one day. Supported expressions:
+//
+//	2026-03-15            a day
+//	2026-03               a month
+//	2026-W14              an ISO week (always Monday to Sunday)
+//	2026-Q1               a quarter
+//	2026                  a year
+//	today, yesterday
+//	-7d, -2w, -3m, -1y    days, weeks, months or years ago
+//	this-week, last-week, this-month, last-month, this-quarter,
+//	last-quarter, this-year, last-year, this-fy, last-fy
+//	wtd, mtd, qtd, ytd, fytd  from the start of the period to today
+func (c Calendar) Parse(expr string, now time.Time) (Period, error) {
+	expr = strings.TrimSpace(strings.ToLower(expr))
+	if expr == "" {
+		return Period{}, fmt.Errorf("empty date")
+	}
+	today := c.Today(now)
+	day := func(t time.Time) (Period, error) { return Period{t, t}, nil }
+
+	switch expr {
+	case "today":
+		return day(today)
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1))
+	}
+
+	if strings.HasPrefix(expr, "-") {
+		t, err := offset(today, expr)
+		if err != nil {
+			return Period{}, err
+		}
+		return day(t)
+	}
+
+	if p, ok := c.named(expr, today); ok {
+		return p, nil
+	}
+
+	if p, ok, err := absolute(expr); ok {
+		return p, err
+	}
+	return Period{}, fmt.Errorf("invalid date %q (expected YYYY-MM-DD, YYYY-MM, YYYY-Www, YYYY-Qn, YYYY, today, yesterday, -N[dwmy] or a period such as last-month)", expr)
+}
+
+// offset evaluates -N[dwmy] relative to today.
+func offset(today time.Time, expr string) (time.Time, error) {
+	if len(expr) < 3 {
+		return time.Time{}, fmt.Errorf("invalid relative date %q (expected -N followed by d, w, m or y)", expr)
+	}
+	unit := expr[len(expr)-1]
+	n, err := strconv.Atoi(expr[1 : len(expr)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid relative date %q (expected -N followed by d, w, m or y)", expr)
+	}
+	switch unit {
+	case 'd':
+		return today.AddDate(0, 0, -n), nil
+	case 'w':
+		return today.AddDate(0, 0, -7*n), nil
+	case 'm':
+		return addMonths(today, -n), nil
+	case 'y':
+		return addMonths(today, -12*n), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid relative date %q (expected -N followed by d, w, m or y)", expr)
+}
+
+// named evaluates the this-, last- and -to-date periods.
+func (c Calendar) named(expr string, today time.Time) (Period, bool) {
+	weekStart := today.AddDate(0, 0, -((int(today.Weekday()) - int(c.WeekStart) + 7) % 7))
+	monthStart := Date(today.Year(), today.Month(), 1)
+	quarterStart := Date(today.Year(), today.Month()-(today.Month()-1)%3, 1)
+	yearStart := Date(today.Year(), time.January, 1)
+	fyStart := c.fiscalYearStart(today)
+
+	span := func(start time.Time, months, days int) Period {
+		return Period{start, start.AddDate(0, months, days-1)}
+	}
+	switch expr {
+	case "this-week":
+		return span(weekStart, 0, 7), true
+	case "last-week":
+		return span(weekStart.AddDate(0, 0, -7), 0, 7), true
+	case "this-month":
+		return span(monthStart, 1, 0), true
+	case "last-month":
+		return span(monthStart.AddDate(0, -1, 0), 1, 0), true
+	case "this-quarter":
+		return span(quarterStart, 3, 0), true
+	case "last-quarter":
+		return span(quarterStart.AddDate(0, -3, 0), 3, 0), true
+	case "this-year":
+		return span(yearStart, 12, 0), true
+	case "last-year":
+		return span(yearStart.AddDate(-1, 0, 0), 12, 0), true
+	case "this-fy":
+		next := c.fiscalYearStart(addMonths(fyStart, 12))
+		return Period{fyStart, next.AddDate(0, 0, -1)}, true
+	case "last-fy":
+		prev := c.fiscalYearStart(fyStart.AddDate(0, 0, -1))
+		return Period{prev, fyStart.AddDate(0, 0, -1)}, true
+	case "wtd":
+		return Period{weekStart, today}, true
+	case "mtd":
+		return Period{monthStart, today}, true
+	case "qtd":
+		return Period{quarterStart, today}, true
+	case "ytd":
+		return Period{yearStart, today}, true
+	case "fytd":
+		return Period{fyStart, today}, true
+	}
+	return Period{}, false
+}
+
+// fiscalYearStart returns the start of the fiscal year containing day.
+func (c Calendar) fiscalYearStart(day time.Time) time.Time {
+	m, d := c.FiscalStart.Month, c.FiscalStart.Day
+	if m == 0 {
+		m, d = time.January, 1
+	}
+	start := clampedDate(day.Year(), m, d)
+	if start.After(day) {
+		start = clampedDate(day.Year()-1, m, d)
+	}
+	return start
+}
+
+// absolute parses the YYYY-MM-DD, YYYY-MM, YYYY-Www, YYYY-Qn and YYYY forms.
+// ok is false if expr has none of these shapes.
+func absolute(expr string) (p Period, ok bool, err error) {
+	if t, err := time.Parse("2006-01-02", expr); err == nil {
+		return Period{t, t}, true, nil
+	}
+	if t, err := time.Parse("2006-01", expr); err == nil {
+		return Period{t, t.AddDate(0, 1, -1)}, true, nil
+	}
+
+	year, rest, found := strings.Cut(expr, "-")
+	y, yerr := strconv.Atoi(year)
+	if len(year) != 4 || yerr != nil {
+		return Period{}, false, nil
+	}
+	if !found {
+		start := Date(y, time.January, 1)
+		return Period{start, start.AddDate(1, 0, -1)}, true, nil
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "w"):
+		w, err := strconv.Atoi(rest[1:])
+		if err != nil || w < 1 || w > isoWeeks(y) {
+			return Period{}, true, fmt.Errorf("invalid ISO week %q (%d has weeks 1 to %d)", expr, y, isoWeeks(y))
+		}
+		start := isoWeekStart(y, w)
+		return Period{start, start.AddDate(0, 0, 6)}, true, nil
+	case strings.HasPrefix(rest, "q"):
+		q, err := strconv.Atoi(rest[1:])
+		if err != nil || q < 1 || q > 4 {
+			return Period{}, true, fmt.Errorf("invalid quarter %q (expected Q1 to Q4)", expr)
+		}
+		start := Date(y, time.Month(3*q-2), 1)
+		return Period{start, start.AddDate(0, 3, -1)}, true, nil
+	}
+	return Period{}, false, nil
+}
+
+// isoWeekStart returns the Monday of ISO week w of year y.
+func isoWeekStart(y, w int) time.Time {
+	// Week 1 is the week with January 4th in it
+	jan4 := Date(y, time.January, 4)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	return monday.AddDate(0, 0, 7*(w-1))
+}
+
+// isoWeeks returns the number of ISO weeks in year y (52 or 53).
+func isoWeeks(y int) int {
+	_, w := Date(y, time.December, 28).ISOWeek()
+	return w
+}
+
+// addMonths moves t by n months, keeping the day but clamping it to the end
+// of a shorter month (March 31st minus one month is February 28th or 29th).
+func addMonths(t time.Time, n int) time.Time {
+	first := Date(t.Year(), t.Month(), 1).AddDate(0, n, 0)
+	return clampedDate(first.Year(), first.Month(), t.Day())
+}
+
+// clampedDate is Date with d limited to the length of the month.
+func clampedDate(y int, m time.Month, d int) time.Time {
+	if last := Date(y, m+1, 0).Day(); d > last {
+		d = last
+	}
+	return Date(y, m, d)
+}
+
+// ParseWeekday parses a day name such as "monday" or "sun".
+func ParseWeekday(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || (len(s) >= 3 && strings.HasPrefix(name, s)) {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day of week %q", s)
+}
+
+// ParseMonthDay parses a day of the year as MM-DD, e.g. "04-06" for April 6th.
+func ParseMonthDay(s string) (MonthDay, error) {
+	t, err := time.Parse("01-02", strings.TrimSpace(s))
+	if err != nil {
+		return MonthDay{}, fmt.Errorf("invalid day of year %q (expected MM-DD)", s)
+	}
+	return MonthDay{t.Month(), t.Day()}, nil
+}
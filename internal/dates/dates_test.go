@@ -0,0 +1,97 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendar_Parse(t *testing.T) {
+	// Wednesday 2026-04-15, 23:30 UTC: already Thursday in Helsinki
+	now := time.Date(2026, 4, 15, 23, 30, 0, 0, time.UTC)
+	utc := Default
+	utc.Location = time.UTC
+
+	tests := []struct {
+		expr     string
+		from, to string
+	}{
+		{"2026-03-15", "2026-03-15", "2026-03-15"},
+		{"2026-02", "2026-02-01", "2026-02-28"},
+		{"2026-W14", "2026-03-30", "2026-04-05"},
+		{"2026-w01", "2025-12-29", "2026-01-04"},
+		{"2026-Q1", "2026-01-01", "2026-03-31"},
+		{"2024", "2024-01-01", "2024-12-31"},
+		{"today", "2026-04-15", "2026-04-15"},
+		{"Yesterday", "2026-04-14", "2026-04-14"},
+		{"-7d", "2026-04-08", "2026-04-08"},
+		{"-2w", "2026-04-01", "2026-04-01"},
+		{"-3m", "2026-01-15", "2026-01-15"},
+		{"-1y", "2025-04-15", "2025-04-15"},
+		{"this-week", "2026-04-13", "2026-04-19"},
+		{"last-week", "2026-04-06", "2026-04-12"},
+		{"this-month", "2026-04-01", "2026-04-30"},
+		{"last-month", "2026-03-01", "2026-03-31"},
+		{"this-quarter", "2026-04-01", "2026-06-30"},
+		{"last-quarter", "2026-01-01", "2026-03-31"},
+		{"this-year", "2026-01-01", "2026-12-31"},
+		{"last-year", "2025-01-01", "2025-12-31"},
+		{"ytd", "2026-01-01", "2026-04-15"},
+		{"mtd", "2026-04-01", "2026-04-15"},
+		{"this-fy", "2026-01-01", "2026-12-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := utc.Parse(tt.expr, now)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := p.From.Format("2006-01-02"); got != tt.from {
+				t.Errorf("From = %s, want %s", got, tt.from)
+			}
+			if got := p.To.Format("2006-01-02"); got != tt.to {
+				t.Errorf("To = %s, want %s", got, tt.to)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "-", "-xd", "-3q", "2026-W54", "2026-Q5", "someday", "2026-13"} {
+		if _, err := utc.Parse(bad, now); err == nil {
+			t.Errorf("Parse(%q): expected error", bad)
+		}
+	}
+}
+
+func TestCalendar_Settings(t *testing.T) {
+	helsinki, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Skip("no tzdata:", err)
+	}
+	now := time.Date(2026, 4, 15, 23, 30, 0, 0, time.UTC)
+	cal := Calendar{Location: helsinki, WeekStart: time.Sunday, FiscalStart: MonthDay{time.April, 6}}
+
+	tests := []struct {
+		expr     string
+		from, to string
+	}{
+		{"today", "2026-04-16", "2026-04-16"},
+		{"this-week", "2026-04-12", "2026-04-18"},
+		{"this-fy", "2026-04-06", "2027-04-05"},
+		{"last-fy", "2025-04-06", "2026-04-05"},
+		{"fytd", "2026-04-06", "2026-04-16"},
+	}
+	for _, tt := range tests {
+		p, err := cal.Parse(tt.expr, now)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if from, to := p.From.Format("2006-01-02"), p.To.Format("2006-01-02"); from != tt.from || to != tt.to {
+			t.Errorf("Parse(%q) = %s..%s, want %s..%s", tt.expr, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestAddMonths_ClampsDay(t *testing.T) {
+	if got := addMonths(Date(2024, time.March, 31), -1); !got.Equal(Date(2024, time.February, 29)) {
+		t.Errorf("March 31st - 1 month = %s, want 2024-02-29", got.Format("2006-01-02"))
+	}
+}
@@ -0,0 +1,278 @@
+// Package filter evaluates the --filter expressions, --fields projections
+// and --sort keys of the commands that list transactions and balances.
+//
+// An expression compares fields of a record with literals or other fields:
+//
+//	amount > 100 && creditor =~ "amazon"
+//	currency == "EUR" && (date >= "2026-01-01" || !booking_date)
+//
+// Operators are ==, !=, <, <=, >, >=, =~ and !~ (regular expression match,
+// case-insensitive), combined with &&, || and ! and grouped with
+// parentheses. A field on its own is true if it is set. Comparisons with an
+// unquoted number are made on decimals, so amounts such as "100.10" are
+// compared exactly rather than as floats or strings; other comparisons are on
+// text. A missing field is the empty string, and is not a number.
+package filter
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Expr is a compiled filter expression.
+type Expr struct {
+	root   node
+	fields []string
+}
+
+// Compile parses expr.
+func Compile(expr string) (*Expr, error) {
+	toks, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %s at column %d", t, t.pos+1)
+	}
+	return &Expr{root: root, fields: p.fields}, nil
+}
+
+// Match reports whether r satisfies the expression.
+func (e *Expr) Match(r Record) bool {
+	return e.root.eval(r)
+}
+
+// Fields returns the fields the expression refers to, in order of appearance.
+func (e *Expr) Fields() []string {
+	return e.fields
+}
+
+// --- Evaluation ---
+
+type node interface {
+	eval(r Record) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(r Record) bool { return n.left.eval(r) && n.right.eval(r) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(r Record) bool { return n.left.eval(r) || n.right.eval(r) }
+
+type notNode struct{ expr node }
+
+func (n notNode) eval(r Record) bool { return !n.expr.eval(r) }
+
+// setNode is a field on its own: true if it is set and not "false".
+type setNode struct{ field string }
+
+func (n setNode) eval(r Record) bool {
+	s := fieldText(r, n.field)
+	return s != "" && s != "false"
+}
+
+// operand is a field or a literal.
+type operand struct {
+	field  string // set for fields
+	text   string // literal text
+	number bool   // unquoted number literal
+}
+
+func (o operand) value(r Record) string {
+	if o.field != "" {
+		return fieldText(r, o.field)
+	}
+	return o.text
+}
+
+type compareNode struct {
+	op          string
+	left, right operand
+	re          *regexp.Regexp // for =~ and !~
+}
+
+func (n compareNode) eval(r Record) bool {
+	l, rv := n.left.value(r), n.right.value(r)
+	switch n.op {
+	case "=~":
+		return n.re.MatchString(l)
+	case "!~":
+		return !n.re.MatchString(l)
+	}
+
+	var cmp int
+	a, aok := Decimal(l)
+	b, bok := Decimal(rv)
+	switch {
+	case n.left.number || n.right.number:
+		// Numbers only compare with numbers; anything else is unequal
+		if !aok || !bok {
+			return n.op == "!="
+		}
+		cmp = a.Cmp(b)
+	case aok && bok && n.left.field != "" && n.right.field != "":
+		cmp = a.Cmp(b)
+	default:
+		cmp = strings.Compare(l, rv)
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// Decimal parses s as an exact decimal number, such as "-12.30" or "1e3".
+func Decimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.Contains(s, "/") {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+// --- Parsing ---
+
+type parser struct {
+	toks   []token
+	pos    int
+	fields []string
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) or() (node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("||") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("&&") {
+		p.next()
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.peek().is("!") {
+		p.next()
+		expr, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	if p.peek().is("(") {
+		p.next()
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); !t.is(")") {
+			return nil, fmt.Errorf("expected ) at column %d, got %s", t.pos+1, t)
+		}
+		return expr, nil
+	}
+
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.peek()
+	if op.kind != tokOp || !isComparison(op.text) {
+		if left.field == "" {
+			return nil, fmt.Errorf("expected a comparison after %s at column %d", p.toks[p.pos-1], op.pos+1)
+		}
+		return setNode{left.field}, nil
+	}
+	p.next()
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	n := compareNode{op: op.text, left: left, right: right}
+	if op.text == "=~" || op.text == "!~" {
+		if right.field != "" || right.number {
+			return nil, fmt.Errorf("%s at column %d needs a quoted regular expression", op.text, op.pos+1)
+		}
+		if n.re, err = regexp.Compile("(?i)" + right.text); err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", right.text, err)
+		}
+	}
+	return n, nil
+}
+
+func (p *parser) operand() (operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokIdent:
+		p.fields = append(p.fields, t.text)
+		return operand{field: t.text}, nil
+	case tokString:
+		return operand{text: t.text}, nil
+	case tokNumber:
+		return operand{text: t.text, number: true}, nil
+	}
+	return operand{}, fmt.Errorf("expected a field, string or number at column %d, got %s", t.pos+1, t)
+}
+
+func isComparison(op string) bool {
+	switch op {
+	case "==", "!=", "<", "<=", ">", ">=", "=~", "!~":
+		return true
+	}
+	return false
+}
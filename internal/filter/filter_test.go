@@ -0,0 +1,182 @@
+package filter
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func txnRecord(t *testing.T, js string) Record {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(js), &v); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := NewObject(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Overlay{Fields: map[string]interface{}{"amount": obj["transaction_amount"].(map[string]interface{})["amount"]}, Record: obj}
+}
+
+func TestExpr_Match(t *testing.T) {
+	r := txnRecord(t, `{
+		"booking_date": "2026-03-15",
+		"transaction_amount": {"currency": "EUR", "amount": "100.10"},
+		"creditor_name": "AMAZON EU SARL",
+		"remittance_information": ["Order 123", "Thanks"]
+	}`)
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`amount > 100`, true},
+		{`amount > 100.1`, false},
+		{`amount >= 100.10`, true},
+		{`amount == 100.1`, true},    // decimal, not text
+		{`amount == "100.1"`, false}, // text
+		{`amount > 99.999999999999999999`, true},
+		{`amount < -5`, false},
+		{`amount > 100 && creditor_name =~ "amazon"`, true},
+		{`creditor_name =~ "^amazon$"`, false},
+		{`creditor_name !~ "paypal"`, true},
+		{`transaction_amount.currency == "EUR"`, true},
+		{`transaction_amount.currency == "eur"`, false},
+		{`booking_date >= "2026-01-01" && booking_date < "2026-04-01"`, true},
+		{`remittance_information =~ 'order \d+ thanks'`, true},
+		{`debtor_name`, false},
+		{`!debtor_name`, true},
+		{`debtor_name == ""`, true},
+		{`debtor_name != "x"`, true},
+		{`debtor_name > 5`, false},
+		{`debtor_name != 5`, true},
+		{`creditor_name`, true},
+		{`amount > 1000 || (creditor_name =~ "amazon" && !debtor_name)`, true},
+		{`!(amount > 100) || amount > 1000`, false},
+		{`amount > 1 && amount > 2 || amount > 1000`, true},
+	}
+	for _, tt := range tests {
+		e, err := Compile(tt.expr)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := e.Match(r); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{``, "expected a field"},
+		{`amount = 5`, `use == to compare`},
+		{`amount >`, "expected a field, string or number"},
+		{`(amount > 5`, "expected )"},
+		{`amount > 5 amount`, "unexpected"},
+		{`"x"`, "expected a comparison"},
+		{`amount =~ 5`, "quoted regular expression"},
+		{`name =~ "("`, "invalid regular expression"},
+		{`name == "abc`, "unterminated string"},
+		{`name =~ "\d"`, "use single quotes"},
+		{`amount > 1.2.3`, "invalid number"},
+		{`amount > 5 & x`, `unexpected "&"`},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.expr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%q) = %v, want error containing %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestExpr_Fields(t *testing.T) {
+	e, err := Compile(`Amount > 5 && (creditor_name =~ "x" || !transaction_amount.currency)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(e.Fields(), ",")
+	if want := "amount,creditor_name,transaction_amount.currency"; got != want {
+		t.Errorf("Fields() = %s, want %s", got, want)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	type amount struct {
+		Amount string `json:"amount"`
+	}
+	type inner struct {
+		Amount  amount  `json:"transaction_amount"`
+		Balance *amount `json:"balance,omitempty"`
+		Skipped string  `json:"-"`
+	}
+	type outer struct {
+		Account string `json:"account"`
+		inner
+	}
+	got := Paths(outer{})
+	for _, p := range []string{"account", "transaction_amount", "transaction_amount.amount", "balance.amount"} {
+		if !got[p] {
+			t.Errorf("Paths() is missing %q", p)
+		}
+	}
+	if got["Skipped"] || got["inner"] || len(got) != 5 {
+		t.Errorf("Paths() = %v", got)
+	}
+}
+
+func TestProject(t *testing.T) {
+	r := txnRecord(t, `{"booking_date": "2026-03-15", "transaction_amount": {"currency": "EUR", "amount": "100.10"}}`)
+	data, err := json.Marshal(Project(r, []string{"transaction_amount.currency", "booking_date", "amount", "creditor_name", "transaction_amount"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"transaction_amount.currency":"EUR","booking_date":"2026-03-15","amount":"100.10","creditor_name":null,"transaction_amount":{"amount":"100.10","currency":"EUR"}}`
+	if string(data) != want {
+		t.Errorf("got  %s\nwant %s", data, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	rec := func(date, amount, name string) Record {
+		return Overlay{Fields: map[string]interface{}{"date": date, "amount": amount, "name": name}}
+	}
+	a := rec("2026-01-02", "9.5", "beta")
+	b := rec("2026-01-01", "10", "Alpha")
+	c := rec("2026-01-01", "", "alpha")
+
+	tests := []struct {
+		spec string
+		x, y Record
+		want int
+	}{
+		{"amount", a, b, -1}, // decimals, not text
+		{"-amount", a, b, 1},
+		{"amount", c, a, 1}, // missing last
+		{"-amount", c, a, 1},
+		{"name", b, a, -1}, // case-insensitive
+		{"name", b, c, 0},
+		{"date,amount", b, a, -1},
+		{"date,-name", b, c, 0},
+	}
+	for _, tt := range tests {
+		keys, err := ParseSort(tt.spec)
+		if err != nil {
+			t.Fatalf("ParseSort(%q): %v", tt.spec, err)
+		}
+		if got := Compare(tt.x, tt.y, keys); got != tt.want {
+			t.Errorf("Compare(%s) = %d, want %d", tt.spec, got, tt.want)
+		}
+	}
+
+	if _, err := ParseSort(" , "); err == nil {
+		t.Error("ParseSort accepted an empty list")
+	}
+	if _, err := ParseSort("date,-"); err == nil {
+		t.Error("ParseSort accepted an empty field")
+	}
+}
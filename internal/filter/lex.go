@@ -0,0 +1,130 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) is(op string) bool {
+	return t.kind == tokOp && t.text == op
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators, longest first so that "<=" is not read as "<".
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "=~", "!~", "<", ">", "!", "(", ")"}
+
+// lex splits expr into tokens, ending with tokEOF.
+func lex(expr string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			text, n, err := lexString(expr[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%v at column %d", err, i+1)
+			}
+			toks = append(toks, token{tokString, text, i})
+			i += n
+
+		case isDigit(c) || (c == '-' && i+1 < len(expr) && (isDigit(expr[i+1]) || expr[i+1] == '.')) || (c == '.' && i+1 < len(expr) && isDigit(expr[i+1])):
+			j := i + 1
+			for j < len(expr) && (isDigit(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			if _, ok := Decimal(expr[i:j]); !ok {
+				return nil, fmt.Errorf("invalid number %q at column %d", expr[i:j], i+1)
+			}
+			toks = append(toks, token{tokNumber, expr[i:j], i})
+			i = j
+
+		case isIdentStart(c):
+			j := i + 1
+			for j < len(expr) && (isIdentStart(expr[j]) || isDigit(expr[j]) || expr[j] == '.') {
+				j++
+			}
+			toks = append(toks, token{tokIdent, strings.ToLower(expr[i:j]), i})
+			i = j
+
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				if c == '=' {
+					return nil, fmt.Errorf("unexpected \"=\" at column %d (use == to compare)", i+1)
+				}
+				return nil, fmt.Errorf("unexpected %q at column %d", string(c), i+1)
+			}
+			toks = append(toks, token{tokOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(expr)}), nil
+}
+
+// lexString reads the quoted string at the start of s. Double-quoted strings
+// take Go escapes; single-quoted strings are taken as they are, which is
+// handier for regular expressions.
+func lexString(s string) (text string, n int, err error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			if quote == '\'' {
+				return s[1:i], i + 1, nil
+			}
+			text, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid string %s (for backslashes in regular expressions, use single quotes)", s[:i+1])
+			}
+			return text, i + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
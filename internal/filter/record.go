@@ -0,0 +1,230 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Record is something expressions are evaluated on and fields are taken
+// from, such as a transaction.
+type Record interface {
+	// Value returns the field with the given name, as decoded from JSON,
+	// and whether it is set.
+	Value(name string) (interface{}, bool)
+}
+
+// Object is a Record holding a value's JSON representation. Fields are
+// named by their JSON keys, with dots for nested objects:
+// "transaction_amount.currency".
+type Object map[string]interface{}
+
+// NewObject returns v as an Object. v must encode to a JSON object. Numbers
+// are kept as json.Number so no precision is lost.
+func NewObject(v interface{}) (Object, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj Object
+	if err := dec.Decode(&obj); err != nil {
+		return nil, fmt.Errorf("%T is not a JSON object: %w", v, err)
+	}
+	return obj, nil
+}
+
+// Value implements Record.
+func (o Object) Value(name string) (interface{}, bool) {
+	var v interface{} = map[string]interface{}(o)
+	for _, key := range strings.Split(name, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, v != nil
+}
+
+// Overlay is a Record whose Fields take precedence over the fields of the
+// underlying Record. It is how commands add shorthand fields, such as
+// "amount" for "transaction_amount.amount". A nil value in Fields is a field
+// that is not set.
+type Overlay struct {
+	Fields map[string]interface{}
+	Record Record
+}
+
+// Value implements Record.
+func (o Overlay) Value(name string) (interface{}, bool) {
+	if v, ok := o.Fields[name]; ok {
+		return v, v != nil
+	}
+	if o.Record == nil {
+		return nil, false
+	}
+	return o.Record.Value(name)
+}
+
+// fieldText returns a field as text for comparisons: numbers as written,
+// lists joined by spaces and missing fields as "".
+func fieldText(r Record, name string) string {
+	v, ok := r.Value(name)
+	if !ok {
+		return ""
+	}
+	return Text(v)
+}
+
+// Text returns a field value as text: numbers as written, lists joined by
+// spaces, objects as JSON and nil as "".
+func Text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			if s := Text(e); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, " ")
+	case []string:
+		return strings.Join(v, " ")
+	}
+	if data, err := json.Marshal(v); err == nil {
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// Paths returns the field names of v's type as Object would see them: the
+// JSON keys of its fields and, joined by dots, those of nested structs.
+func Paths(v interface{}) map[string]bool {
+	paths := map[string]bool{}
+	addPaths(paths, reflect.TypeOf(v), "", 0)
+	return paths
+}
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+func addPaths(paths map[string]bool, t reflect.Type, prefix string, depth int) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct || depth > 5 || t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			addPaths(paths, f.Type, prefix, depth)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		paths[prefix+name] = true
+		addPaths(paths, f.Type, prefix+name+".", depth+1)
+	}
+}
+
+// Row is a record projected onto a list of fields. It encodes as a JSON
+// object with the fields in the order they were asked for.
+type Row []Column
+
+// Column is one field of a Row.
+type Column struct {
+	Name  string
+	Value interface{}
+}
+
+// Project returns the given fields of r. Fields that are not set are null.
+func Project(r Record, fields []string) Row {
+	row := make(Row, len(fields))
+	for i, name := range fields {
+		v, _ := r.Value(name)
+		row[i] = Column{name, v}
+	}
+	return row
+}
+
+// MarshalJSON implements json.Marshaler.
+func (row Row) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, c := range row {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(c.Name)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler, keeping the fields in order.
+// Nested values are decoded as by NewObject.
+func (row *Row) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("row is not an object")
+	}
+	*row = Row{}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*row = append(*row, Column{key.(string), value})
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// ParseFields parses a comma-separated list of field names.
+func ParseFields(list string) []string {
+	var fields []string
+	for _, f := range strings.Split(list, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
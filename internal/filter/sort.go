@@ -0,0 +1,69 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortKey is a field to sort records by.
+type SortKey struct {
+	Field      string
+	Descending bool
+}
+
+// ParseSort parses a comma-separated list of fields to sort by, each
+// optionally prefixed with "-" for descending order: "date,-amount".
+func ParseSort(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := SortKey{Field: part}
+		if strings.HasPrefix(part, "-") {
+			key = SortKey{Field: strings.TrimSpace(part[1:]), Descending: true}
+		}
+		if key.Field == "" {
+			return nil, fmt.Errorf("empty sort field in %q", spec)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no sort field given")
+	}
+	return keys, nil
+}
+
+// Compare orders a and b by keys, returning -1, 0 or 1. Fields that are
+// both numbers compare as decimals and others as case-insensitive text;
+// records without a field come last, whatever the direction.
+func Compare(a, b Record, keys []SortKey) int {
+	for _, key := range keys {
+		x, y := fieldText(a, key.Field), fieldText(b, key.Field)
+		switch {
+		case x == "" && y == "":
+			continue
+		case x == "":
+			return 1
+		case y == "":
+			return -1
+		}
+
+		var cmp int
+		dx, okx := Decimal(x)
+		dy, oky := Decimal(y)
+		if okx && oky {
+			cmp = dx.Cmp(dy)
+		} else {
+			cmp = strings.Compare(strings.ToLower(x), strings.ToLower(y))
+		}
+		if key.Descending {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
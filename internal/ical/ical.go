@@ -0,0 +1,113 @@
+// Package ical writes iCalendar (RFC 5545) files with all-day events, for
+// calendar apps to import or subscribe to.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calendar is a set of events published as one .ics file.
+type Calendar struct {
+	Name   string // shown by calendar apps (X-WR-CALNAME)
+	Events []Event
+}
+
+// Event is an all-day event.
+type Event struct {
+	UID         string // stable across regenerations, so subscribers update instead of duplicating
+	Date        time.Time
+	Summary     string
+	Description string
+	URL         string
+	Alarms      []time.Duration // reminders before the start of Date, e.g. 7*24*time.Hour
+}
+
+// WriteTo writes the calendar in iCalendar format. stamp is the DTSTAMP of
+// every event.
+func (c *Calendar) WriteTo(w io.Writer, stamp time.Time) error {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//ebcli//consents//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+
+	for _, ev := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(ev.UID))
+		line("DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+		line("DTSTART;VALUE=DATE", ev.Date.Format("20060102"))
+		line("DTEND;VALUE=DATE", ev.Date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY", escape(ev.Summary))
+		if ev.Description != "" {
+			line("DESCRIPTION", escape(ev.Description))
+		}
+		if ev.URL != "" {
+			line("URL", ev.URL)
+		}
+		line("TRANSP", "TRANSPARENT")
+		for _, before := range ev.Alarms {
+			line("BEGIN", "VALARM")
+			line("ACTION", "DISPLAY")
+			line("DESCRIPTION", escape(ev.Summary))
+			line("TRIGGER", "-"+duration(before))
+			line("END", "VALARM")
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// escape escapes a TEXT value.
+func escape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// duration formats d as an iCalendar duration, e.g. P7D or PT12H.
+func duration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("P%dD", d/day)
+	}
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("PT%dH", d/time.Hour)
+	}
+	return fmt.Sprintf("PT%dM", d/time.Minute)
+}
+
+// writeFolded writes a content line, folded at 75 octets without splitting
+// a UTF-8 sequence, terminated by CRLF.
+func writeFolded(buf *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // continuation lines start with a space
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
@@ -0,0 +1,72 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCalendar_WriteTo(t *testing.T) {
+	cal := &Calendar{
+		Name: "ebcli consents",
+		Events: []Event{{
+			UID:         "ing@ebcli",
+			Date:        time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+			Summary:     "ING consent expires",
+			Description: "Run: ebcli reconnect --name ing; then, check balances",
+			Alarms:      []time.Duration{7 * 24 * time.Hour, 12 * time.Hour},
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := cal.WriteTo(&buf, time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:ebcli consents\r\n",
+		"DTSTAMP:20250101T080000Z\r\n",
+		"DTSTART;VALUE=DATE:20250331\r\n",
+		"DTEND;VALUE=DATE:20250401\r\n",
+		`DESCRIPTION:Run: ebcli reconnect --name ing\; then\, check balances` + "\r\n",
+		"TRIGGER:-P7D\r\n",
+		"TRIGGER:-PT12H\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "BEGIN:VALARM") != 2 {
+		t.Errorf("want 2 alarms:\n%s", out)
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	var buf bytes.Buffer
+	writeFolded(&buf, "SUMMARY:"+strings.Repeat("é", 80))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected folded lines, got %q", buf.String())
+	}
+	var joined strings.Builder
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Errorf("line %d is %d octets", i, len(l))
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d does not start with a space", i)
+			}
+			l = l[1:]
+		}
+		joined.WriteString(l)
+	}
+	if joined.String() != "SUMMARY:"+strings.Repeat("é", 80) {
+		t.Error("unfolded text differs from the input")
+	}
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"os"
+	"strings"
+)
+
+// Locale holds how amounts are written for a language and region.
+type Locale struct {
+	Decimal      string // decimal mark
+	Group        string // thousands separator
+	SymbolBefore bool   // currency symbol before the number
+}
+
+// DefaultLocale is used when the environment names no known locale:
+// 1,234.56 with the symbol first, as in English.
+var DefaultLocale = Locale{Decimal: ".", Group: ",", SymbolBefore: true}
+
+// Languages by number style; others use DefaultLocale.
+var (
+	dotGroupLanguages   = []string{"de", "it", "es", "nl", "pt", "da", "el", "id", "ro", "tr", "hr", "sl", "sr"}
+	spaceGroupLanguages = []string{"fr", "fi", "sv", "nb", "no", "nn", "cs", "sk", "pl", "ru", "uk", "hu", "lt", "lv", "et", "bg"}
+)
+
+// LocaleFromEnv returns the locale for amounts named by LC_ALL, LC_MONETARY
+// or LANG, e.g. "de_DE.UTF-8".
+func LocaleFromEnv() Locale {
+	for _, key := range []string{"LC_ALL", "LC_MONETARY", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return ParseLocale(v)
+		}
+	}
+	return DefaultLocale
+}
+
+// ParseLocale returns the locale for a POSIX locale name such as
+// "fr_FR.UTF-8" or "de_CH".
+func ParseLocale(name string) Locale {
+	name, _, _ = strings.Cut(name, ".")
+	name, _, _ = strings.Cut(name, "@")
+	lang, region, _ := strings.Cut(strings.ReplaceAll(name, "-", "_"), "_")
+	lang, region = strings.ToLower(lang), strings.ToUpper(region)
+
+	if region == "CH" || region == "LI" {
+		return Locale{Decimal: ".", Group: "'", SymbolBefore: true}
+	}
+	for _, l := range dotGroupLanguages {
+		if lang == l {
+			return Locale{Decimal: ",", Group: ".", SymbolBefore: lang == "nl"}
+		}
+	}
+	for _, l := range spaceGroupLanguages {
+		if lang == l {
+			return Locale{Decimal: ",", Group: "\u00a0"}
+		}
+	}
+	return DefaultLocale
+}
+
+// currencySymbols maps ISO 4217 codes to the symbols amounts are shown
+// with. Other currencies are shown with their code.
+var currencySymbols = map[string]string{
+	"EUR": "€", "USD": "$", "GBP": "£", "JPY": "¥", "CNY": "¥", "INR": "₹",
+	"SEK": "kr", "NOK": "kr", "DKK": "kr", "ISK": "kr",
+	"PLN": "zł", "CZK": "Kč", "HUF": "Ft", "RON": "lei", "BGN": "лв",
+}
+
+// FormatAmount writes a decimal amount such as "-1234.5" with the locale's
+// separators and the currency's symbol: "-€1,234.5" or "-1.234,5 €". The
+// digits are kept as they are, not rounded. Amounts that are not plain
+// decimals are returned with the currency code.
+func (l Locale) FormatAmount(amount, currency string) string {
+	amount = strings.TrimSpace(amount)
+	sign := ""
+	if strings.HasPrefix(amount, "-") || strings.HasPrefix(amount, "+") {
+		if amount[0] == '-' {
+			sign = "-"
+		}
+		amount = amount[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(amount, ".")
+	if !digits(whole) || (hasFrac && !digits(frac)) {
+		return strings.TrimSpace(sign + amount + " " + currency)
+	}
+
+	var b strings.Builder
+	for i, d := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(d)
+	}
+	if hasFrac {
+		b.WriteString(l.Decimal)
+		b.WriteString(frac)
+	}
+	number := b.String()
+
+	symbol, ok := currencySymbols[strings.ToUpper(currency)]
+	if !ok {
+		symbol = currency
+	}
+	switch {
+	case symbol == "":
+		return sign + number
+	case !l.SymbolBefore:
+		return sign + number + nbsp + symbol
+	case len([]rune(symbol)) > 1:
+		return sign + symbol + nbsp + number
+	default:
+		return sign + symbol + number
+	}
+}
+
+// nbsp keeps amounts, their digit groups and currency symbols on one line.
+const nbsp = "\u00a0"
+
+func digits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
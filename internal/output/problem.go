@@ -0,0 +1,74 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ErrorFormat is how errors and warnings are written to stderr.
+type ErrorFormat int
+
+const (
+	ErrorText ErrorFormat = iota // "ebcli: message", colored
+	ErrorJSON                    // a Problem per line
+)
+
+// ParseErrorFormat parses the --error-format flag.
+func ParseErrorFormat(s string) (ErrorFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "text":
+		return ErrorText, nil
+	case "json":
+		return ErrorJSON, nil
+	}
+	return ErrorText, fmt.Errorf("unknown error format %q (expected text or json)", s)
+}
+
+// SetErrorFormat sets how Error, Warn and Report write to stderr.
+func (p *Printer) SetErrorFormat(f ErrorFormat) {
+	p.errorFormat = f
+}
+
+// Problem is an error or a warning, as written by --error-format json.
+type Problem struct {
+	Level      string `json:"level"`          // "error" or "warning"
+	Kind       string `json:"kind,omitempty"` // e.g. "auth", "rate_limit"; empty for plain warnings
+	Message    string `json:"message"`
+	Account    string `json:"account,omitempty"`
+	Connection string `json:"connection,omitempty"`
+	Status     int    `json:"status,omitempty"`      // HTTP status of an API error
+	Code       string `json:"code,omitempty"`        // error code of an API error
+	RetryAfter int    `json:"retry_after,omitempty"` // seconds, where known
+	Remedy     string `json:"remedy,omitempty"`      // a command that may fix it
+	ExitCode   int    `json:"exit_code,omitempty"`   // for errors that end the command
+}
+
+// Report writes an error or warning: as a JSON line with --error-format
+// json, as an event in event mode, and as a message otherwise.
+func (p *Printer) Report(pr Problem) {
+	if p.quiet {
+		return
+	}
+	if pr.Level == "" {
+		pr.Level = "error"
+	}
+	switch {
+	case p.events:
+		data, _ := json.Marshal(pr)
+		var fields map[string]interface{}
+		json.Unmarshal(data, &fields)
+		delete(fields, "level")
+		p.Event(pr.Level, fields)
+	case p.errorFormat == ErrorJSON:
+		data, err := json.Marshal(pr)
+		if err != nil {
+			return
+		}
+		p.stderr.Write(append(data, '\n'))
+	case pr.Level == "warning":
+		p.message(warnPrefix, pr.Message)
+	default:
+		p.message(errorPrefix, pr.Message)
+	}
+}
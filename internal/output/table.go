@@ -0,0 +1,232 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Format is the kind of output commands write to stdout.
+type Format int
+
+const (
+	FormatJSON  Format = iota // JSON, shaped by Mode
+	FormatTable               // aligned columns for people, see Table
+	FormatLLM                 // compact markdown for language models (dump only)
+)
+
+// ParseFormat parses the --format flag.
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "json":
+		return FormatJSON, nil
+	case "table":
+		return FormatTable, nil
+	case "llm":
+		return FormatLLM, nil
+	}
+	return FormatJSON, fmt.Errorf("unknown format %q (expected json, table or llm)", s)
+}
+
+// Align is the alignment of a table column.
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// Style is how a table cell is colored.
+type Style int
+
+const (
+	StylePlain  Style = iota
+	StyleCredit       // money in: green
+	StyleDebit        // money out: red
+	StyleMuted        // secondary information: faint
+)
+
+// Column is a column of a Table.
+type Column struct {
+	Header string
+	Align  Align
+	Shrink bool // may be truncated to fit the terminal
+}
+
+// Cell is one value of a Table.
+type Cell struct {
+	Text  string
+	Style Style
+}
+
+// Table is output rendered as aligned columns.
+type Table struct {
+	Columns []Column
+	Rows    [][]Cell
+}
+
+// AddRow adds a row of plain cells.
+func (t *Table) AddRow(values ...string) {
+	row := make([]Cell, len(values))
+	for i, v := range values {
+		row[i] = Cell{Text: v}
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// minShrinkWidth is the narrowest a shrinking column gets.
+const minShrinkWidth = 8
+
+// Render writes the table to w. If width is positive, columns marked Shrink
+// are truncated, widest first, so that lines fit in width characters.
+func (t *Table) Render(w io.Writer, width int) error {
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = textWidth(c.Header)
+	}
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			if i < len(widths) && textWidth(cell.Text) > widths[i] {
+				widths[i] = textWidth(cell.Text)
+			}
+		}
+	}
+	if width > 0 {
+		t.fit(widths, width)
+	}
+
+	var b strings.Builder
+	line := func(cells []Cell) {
+		for i := range t.Columns {
+			var cell Cell
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			text := truncate(cell.Text, widths[i])
+			pad := strings.Repeat(" ", widths[i]-textWidth(text))
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			if t.Columns[i].Align == AlignRight {
+				b.WriteString(pad + colorize(text, cell.Style))
+			} else if i < len(t.Columns)-1 {
+				b.WriteString(colorize(text, cell.Style) + pad)
+			} else {
+				b.WriteString(colorize(text, cell.Style))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	header := make([]Cell, len(t.Columns))
+	rule := make([]Cell, len(t.Columns))
+	for i, c := range t.Columns {
+		header[i] = Cell{Text: c.Header}
+		rule[i] = Cell{Text: strings.Repeat("-", textWidth(truncate(c.Header, widths[i])))}
+	}
+	line(header)
+	line(rule)
+	for _, row := range t.Rows {
+		line(row)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// fit narrows the Shrink columns until the table fits in width, or they
+// reach minShrinkWidth.
+func (t *Table) fit(widths []int, width int) {
+	total := 2 * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > width {
+		widest := -1
+		for i, c := range t.Columns {
+			if c.Shrink && widths[i] > minShrinkWidth && (widest < 0 || widths[i] > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			return
+		}
+		widths[widest]--
+		total--
+	}
+}
+
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// truncate shortens s to width characters, ending it with "…" if cut.
+func truncate(s string, width int) string {
+	if textWidth(s) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+var styleColors = map[Style]*color.Color{
+	StyleCredit: color.New(color.FgGreen),
+	StyleDebit:  color.New(color.FgRed),
+	StyleMuted:  color.New(color.Faint),
+}
+
+func colorize(s string, style Style) string {
+	if c, ok := styleColors[style]; ok && s != "" {
+		return c.Sprint(s)
+	}
+	return s
+}
+
+// Table writes tables to stdout, separated by blank lines and truncated to
+// the terminal's width when stdout is one.
+func (p *Printer) Table(tables ...*Table) error {
+	width := 0
+	if f, ok := p.stdout.(*os.File); ok {
+		width = TerminalWidth(f)
+	}
+	for i, t := range tables {
+		if i > 0 {
+			if _, err := io.WriteString(p.stdout, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := t.Render(p.stdout, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFormat sets the output format.
+func (p *Printer) SetFormat(f Format) {
+	p.format = f
+}
+
+// Format returns the output format. Commands without a table view write
+// JSON whatever it is.
+func (p *Printer) Format() Format {
+	return p.format
+}
+
+// TerminalWidth returns the number of columns of the terminal f is, or 0 if
+// it is not a terminal. COLUMNS overrides it, for terminals too.
+func TerminalWidth(f *os.File) int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	if !isTerminal(f) {
+		return 0
+	}
+	return terminalWidth(f)
+}
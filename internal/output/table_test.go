@@ -0,0 +1,89 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestTable_Render(t *testing.T) {
+	noColor := color.NoColor
+	t.Cleanup(func() { color.NoColor = noColor })
+	color.NoColor = true
+	table := &Table{Columns: []Column{
+		{Header: "DATE"},
+		{Header: "AMOUNT", Align: AlignRight},
+		{Header: "DESCRIPTION", Shrink: true},
+	}}
+	table.AddRow("2026-03-01", "€1.50", "Coffee")
+	table.Rows = append(table.Rows, []Cell{{Text: "2026-03-02"}, {Text: "-€1,234.56", Style: StyleDebit}, {Text: "Rent for March, flat 4B"}})
+
+	var buf bytes.Buffer
+	if err := table.Render(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"DATE            AMOUNT  DESCRIPTION\n" +
+		"----            ------  -----------\n" +
+		"2026-03-01       €1.50  Coffee\n" +
+		"2026-03-02  -€1,234.56  Rent for March, flat 4B\n"
+	if buf.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := table.Render(&buf, 36); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if n := textWidth(line); n > 36 {
+			t.Errorf("line is %d wide: %q", n, line)
+		}
+	}
+	if !strings.Contains(buf.String(), "Rent for Ma…") {
+		t.Errorf("description not truncated:\n%s", buf.String())
+	}
+
+	// Columns that may not shrink are never cut, even if the line is too wide
+	buf.Reset()
+	table.Render(&buf, 10)
+	if !strings.Contains(buf.String(), "-€1,234.56  Rent fo…") {
+		t.Errorf("narrow render:\n%s", buf.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for in, want := range map[string]Format{"": FormatJSON, "json": FormatJSON, "Table": FormatTable, "llm": FormatLLM} {
+		if got, err := ParseFormat(in); err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %v, %v", in, got, err)
+		}
+	}
+	if _, err := ParseFormat("yaml"); err == nil {
+		t.Error("ParseFormat accepted yaml")
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		locale, amount, currency, want string
+	}{
+		{"en_US.UTF-8", "1234567.80", "EUR", "€1,234,567.80"},
+		{"en_GB", "-12.5", "GBP", "-£12.5"},
+		{"C", "100", "CHF", "CHF\u00a0100"},
+		{"de_DE.UTF-8", "-1234.56", "EUR", "-1.234,56\u00a0€"},
+		{"nl_NL", "1234.56", "EUR", "€1.234,56"},
+		{"fr_FR", "1234.56", "EUR", "1\u00a0234,56\u00a0€"},
+		{"sv_SE", "99", "SEK", "99\u00a0kr"},
+		{"de_CH", "1234.5", "CHF", "CHF\u00a01'234.5"},
+		{"it_IT", "0.99", "XYZ", "0,99\u00a0XYZ"},
+		{"en_US", "+5", "", "5"},
+		{"en_US", "12,50", "EUR", "12,50 EUR"}, // not a plain decimal: left alone
+	}
+	for _, tt := range tests {
+		if got := ParseLocale(tt.locale).FormatAmount(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("%s: FormatAmount(%q, %q) = %q, want %q", tt.locale, tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// ParseTemplate parses a text/template for --template, with TemplateFuncs.
+func ParseTemplate(name, src string) (*template.Template, error) {
+	return template.New(name).Funcs(TemplateFuncs(LocaleFromEnv())).Parse(src)
+}
+
+// SetTemplate makes JSON and Stream render values with tmpl instead of
+// encoding them. Templates see the values as JSON would show them: objects
+// are maps keyed by their JSON field names, so {{.booking_date}} and
+// {{.transaction_amount.amount}} work as they read in the JSON output.
+func (p *Printer) SetTemplate(tmpl *template.Template) {
+	p.template = tmpl
+}
+
+// executeTemplate renders v, redacted if r is set, with tmpl.
+func executeTemplate(tmpl *template.Template, v interface{}, r Redactor) ([]byte, error) {
+	data, err := templateData(v, r)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// templateData converts v to what templates see: the value decoded from its
+// JSON, with numbers kept as json.Number.
+func templateData(v interface{}, r Redactor) (interface{}, error) {
+	data, err := marshal(v, r)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TemplateFuncs returns the functions templates can use besides the
+// text/template builtins:
+//
+//	signed TXN             the amount of a transaction, negative for debits
+//	money AMOUNT           an amount object ({amount, currency}) or a
+//	                       transaction, formatted for the locale: €1,234.50
+//	money VALUE CURRENCY   a number and currency code, formatted the same way
+//	sum LIST               the total of signed amounts of transactions or
+//	                       amounts, exactly
+//	date LAYOUT VALUE      a date or timestamp in a Go time layout
+//	pad N VALUE            VALUE left-aligned in N characters
+//	padleft N VALUE        VALUE right-aligned in N characters
+//	trunc N VALUE          VALUE cut to N characters, ending with …
+//	upper, lower, join SEP LIST, json VALUE
+func TemplateFuncs(locale Locale) template.FuncMap {
+	return template.FuncMap{
+		"signed": func(v interface{}) string {
+			amount, _ := signedAmount(v)
+			return amount
+		},
+		"money": func(v interface{}, currency ...string) string {
+			if len(currency) > 0 {
+				return locale.FormatAmount(toText(v), currency[0])
+			}
+			amount, code := signedAmount(v)
+			return locale.FormatAmount(amount, code)
+		},
+		"sum": func(list []interface{}) (string, error) {
+			total, scale := new(big.Rat), 0
+			for _, v := range list {
+				amount, _ := signedAmount(v)
+				r, ok := new(big.Rat).SetString(amount)
+				if !ok {
+					return "", fmt.Errorf("sum: %q is not a number", amount)
+				}
+				total.Add(total, r)
+				if _, frac, found := strings.Cut(amount, "."); found && len(frac) > scale {
+					scale = len(frac)
+				}
+			}
+			return total.FloatString(scale), nil
+		},
+		"date": func(layout string, v interface{}) string {
+			s := toText(v)
+			for _, in := range []string{"2006-01-02", time.RFC3339Nano} {
+				if t, err := time.Parse(in, s); err == nil {
+					return t.Format(layout)
+				}
+			}
+			return s
+		},
+		"pad": func(n int, v interface{}) string {
+			s := toText(v)
+			return s + strings.Repeat(" ", max(0, n-utf8.RuneCountInString(s)))
+		},
+		"padleft": func(n int, v interface{}) string {
+			s := toText(v)
+			return strings.Repeat(" ", max(0, n-utf8.RuneCountInString(s))) + s
+		},
+		"trunc": func(n int, v interface{}) string {
+			return truncate(toText(v), n)
+		},
+		"upper": func(v interface{}) string { return strings.ToUpper(toText(v)) },
+		"lower": func(v interface{}) string { return strings.ToLower(toText(v)) },
+		"join": func(sep string, list []interface{}) string {
+			parts := make([]string, len(list))
+			for i, v := range list {
+				parts[i] = toText(v)
+			}
+			return strings.Join(parts, sep)
+		},
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// signedAmount returns the amount and currency of a transaction (negative
+// for debits), an amount object or a plain number.
+func signedAmount(v interface{}) (amount, currency string) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return toText(v), ""
+	}
+	obj := m
+	for _, key := range []string{"transaction_amount", "balance_amount"} {
+		if inner, ok := m[key].(map[string]interface{}); ok {
+			obj = inner
+			break
+		}
+	}
+	amount, currency = toText(obj["amount"]), toText(obj["currency"])
+	if toText(m["credit_debit_indicator"]) == "DBIT" && amount != "" && !strings.HasPrefix(amount, "-") {
+		amount = "-" + amount
+	}
+	return amount, currency
+}
+
+func toText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return fmt.Sprint(v)
+}
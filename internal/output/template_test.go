@@ -0,0 +1,72 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestPrinter_Template(t *testing.T) {
+	type amount struct {
+		Currency string `json:"currency"`
+		Amount   string `json:"amount"`
+	}
+	type txn struct {
+		Date      string   `json:"booking_date"`
+		Amount    amount   `json:"transaction_amount"`
+		Indicator string   `json:"credit_debit_indicator,omitempty"`
+		Lines     []string `json:"remittance_information,omitempty"`
+	}
+	txns := []txn{
+		{"2026-03-02", amount{"EUR", "1234.50"}, "CRDT", []string{"Salary", "March"}},
+		{"2026-03-15", amount{"EUR", "0.25"}, "DBIT", nil},
+		{"2026-03-31", amount{"EUR", "10.125"}, "DBIT", nil},
+	}
+
+	tmpl, err := parse(t, `{{range .}}{{date "02/01" .booking_date}}|{{padleft 10 (money .)}}|{{pad 6 (signed .)}}|{{trunc 5 (join " " .remittance_information)}}
+{{end}}total {{sum .}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	p := NewPrinter(&stdout, &bytes.Buffer{}, ModePretty, false)
+	p.SetTemplate(tmpl)
+	if err := p.JSON(txns); err != nil {
+		t.Fatal(err)
+	}
+	want := "" +
+		"02/03| €1,234.50|1234.50|Sala…\n" +
+		"15/03|    -€0.25|-0.25 |\n" +
+		"31/03|  -€10.125|-10.125|\n" +
+		"total 1224.125"
+	if stdout.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stdout.String(), want)
+	}
+
+	// Streams render each value on a line of its own
+	stdout.Reset()
+	tmpl, _ = parse(t, `{{.booking_date}} {{money .transaction_amount.amount "USD"}}`)
+	p.SetTemplate(tmpl)
+	s := p.Stream()
+	for _, txn := range txns[:2] {
+		if err := s.Write(txn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := "2026-03-02 $1,234.50\n2026-03-15 $0.25\n"; stdout.String() != want {
+		t.Errorf("stream got %q, want %q", stdout.String(), want)
+	}
+
+	// Execution errors are returned
+	tmpl, _ = parse(t, `{{sum .booking_date}}`)
+	p.SetTemplate(tmpl)
+	if err := p.JSON(txns[0]); err == nil {
+		t.Error("expected an error summing a string")
+	}
+}
+
+func parse(t *testing.T, src string) (*template.Template, error) {
+	t.Helper()
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	return ParseTemplate("test", src)
+}
@@ -1,10 +1,13 @@
 package output
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"text/template"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -21,10 +24,45 @@ const (
 
 // Printer manages output formatting.
 type Printer struct {
-	stdout io.Writer
-	stderr io.Writer
-	mode   Mode
-	quiet  bool
+	stdout   io.Writer
+	stderr   io.Writer
+	mode     Mode
+	quiet    bool
+	events   bool // stderr messages as JSON lines, see EnableEvents
+	format   Format
+	template *template.Template // see SetTemplate
+	redactor Redactor           // see SetRedactor
+
+	errorFormat ErrorFormat // see SetErrorFormat
+}
+
+// Redactor rewrites a JSON document before it is written, for --redact.
+type Redactor interface {
+	Redact(data []byte) ([]byte, error)
+}
+
+// SetRedactor makes everything written to stdout pass through r: JSON,
+// streams and the values templates see. Tables are built by commands, which
+// redact their values themselves.
+func (p *Printer) SetRedactor(r Redactor) {
+	p.redactor = r
+}
+
+// Redactor returns the redactor set with SetRedactor, or nil.
+func (p *Printer) Redactor() Redactor {
+	return p.redactor
+}
+
+// marshal encodes v as compact JSON, redacted if r is set.
+func marshal(v interface{}, r Redactor) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling JSON: %w", err)
+	}
+	if r != nil {
+		return r.Redact(data)
+	}
+	return data, nil
 }
 
 // NewPrinter creates a Printer.
@@ -40,19 +78,42 @@ func NewPrinter(stdout, stderr io.Writer, mode Mode, quiet bool) *Printer {
 	}
 }
 
-// JSON writes v as JSON to stdout.
+// JSON writes v as JSON to stdout, or rendered with the template if one
+// is set.
 func (p *Printer) JSON(v interface{}) error {
 	var data []byte
 	var err error
 
-	switch p.effectiveMode() {
-	case ModePretty:
-		data, err = json.MarshalIndent(v, "", "  ")
-	default:
-		data, err = json.Marshal(v)
+	if p.template != nil {
+		data, err = executeTemplate(p.template, v, p.redactor)
+		if err != nil {
+			return err
+		}
+		_, err = p.stdout.Write(data)
+		return err
 	}
-	if err != nil {
-		return fmt.Errorf("marshaling JSON: %w", err)
+
+	if p.redactor != nil {
+		if data, err = marshal(v, p.redactor); err != nil {
+			return err
+		}
+		if p.effectiveMode() == ModePretty {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, data, "", "  "); err != nil {
+				return err
+			}
+			data = buf.Bytes()
+		}
+	} else {
+		switch p.effectiveMode() {
+		case ModePretty:
+			data, err = json.MarshalIndent(v, "", "  ")
+		default:
+			data, err = json.Marshal(v)
+		}
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
 	}
 
 	data = append(data, '\n')
@@ -62,10 +123,23 @@ func (p *Printer) JSON(v interface{}) error {
 
 // RawJSON writes pre-encoded JSON bytes to stdout.
 func (p *Printer) RawJSON(data []byte) error {
-	if p.effectiveMode() == ModePretty {
+	if p.template != nil {
 		var v interface{}
 		if err := json.Unmarshal(data, &v); err == nil {
-			return p.JSON(v)
+			return p.JSON(v) // redacts
+		}
+	}
+	if p.redactor != nil {
+		redacted, err := p.redactor.Redact(data)
+		if err != nil {
+			return err
+		}
+		data = redacted
+	}
+	if p.effectiveMode() == ModePretty {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err == nil {
+			data = buf.Bytes()
 		}
 	}
 	if len(data) == 0 || data[len(data)-1] != '\n' {
@@ -75,22 +149,65 @@ func (p *Printer) RawJSON(data []byte) error {
 	return err
 }
 
+// Text writes text that is the output itself, such as dump --format llm,
+// to stdout.
+func (p *Printer) Text(text string) error {
+	_, err := io.WriteString(p.stdout, text)
+	return err
+}
+
+// Stream is a writer for results that are produced one at a time: each
+// value is written to stdout as a single line of compact JSON (NDJSON) as
+// soon as it is available, regardless of the output mode. With a template,
+// each value is rendered with it instead, on a line of its own unless the
+// template renders nothing for it.
+type Stream struct {
+	w        io.Writer
+	template *template.Template
+	redactor Redactor
+	count    int
+}
+
+// Stream returns a Stream writing to stdout.
+func (p *Printer) Stream() *Stream {
+	return &Stream{w: p.stdout, template: p.template, redactor: p.redactor}
+}
+
+// Write writes v as one line.
+func (s *Stream) Write(v interface{}) error {
+	var data []byte
+	var err error
+	if s.template != nil {
+		data, err = executeTemplate(s.template, v, s.redactor)
+	} else {
+		data, err = marshal(v, s.redactor)
+	}
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.count++
+	return nil
+}
+
+// Count returns the number of values written.
+func (s *Stream) Count() int {
+	return s.count
+}
+
 // Error writes an error message to stderr.
 func (p *Printer) Error(format string, args ...interface{}) {
-	if p.quiet {
-		return
-	}
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(p.stderr, "%s %s\n", color.RedString("ebcli:"), msg)
+	p.Report(Problem{Level: "error", Message: fmt.Sprintf(format, args...)})
 }
 
 // Warn writes a warning message to stderr.
 func (p *Printer) Warn(format string, args ...interface{}) {
-	if p.quiet {
-		return
-	}
-	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(p.stderr, "%s %s\n", color.YellowString("ebcli:"), msg)
+	p.Report(Problem{Level: "warning", Message: fmt.Sprintf(format, args...)})
 }
 
 // Info writes an informational message to stderr.
@@ -99,7 +216,56 @@ func (p *Printer) Info(format string, args ...interface{}) {
 		return
 	}
 	msg := fmt.Sprintf(format, args...)
-	fmt.Fprintf(p.stderr, "%s %s\n", color.CyanString("ebcli:"), msg)
+	if p.events {
+		p.Event("info", map[string]interface{}{"message": msg})
+		return
+	}
+	p.message(infoPrefix, msg)
+}
+
+// Prefixes of stderr messages, colored when color is enabled.
+var (
+	errorPrefix = color.RedString
+	warnPrefix  = color.YellowString
+	infoPrefix  = color.CyanString
+)
+
+func (p *Printer) message(prefix func(string, ...interface{}) string, msg string) {
+	fmt.Fprintf(p.stderr, "%s %s\n", prefix("ebcli:"), msg)
+}
+
+// EnableEvents switches stderr to one JSON object per line, for tools that
+// wrap ebcli: Info, Warn and Error become "info", "warning" and "error"
+// events, and Event writes structured progress.
+func (p *Printer) EnableEvents() {
+	p.events = true
+}
+
+// Events reports whether stderr carries JSON events. Interactive prompts
+// are skipped in this mode.
+func (p *Printer) Events() bool {
+	return p.events
+}
+
+// Event writes a progress event to stderr as a JSON line, e.g.
+// {"event":"authorization_url","time":"...","url":"..."}. Events are only
+// written in event mode, and are not affected by quiet.
+func (p *Printer) Event(name string, fields map[string]interface{}) {
+	if !p.events {
+		return
+	}
+	ev := make(map[string]interface{}, len(fields)+2)
+	for k, v := range fields {
+		ev[k] = v
+	}
+	ev["event"] = name
+	ev["time"] = time.Now().UTC().Format(time.RFC3339)
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	p.stderr.Write(append(data, '\n'))
 }
 
 // IsRaw returns true if the output mode is raw.
@@ -3,8 +3,11 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/nicolasacchi/ebcli/internal/redact"
 )
 
 func TestPrinter_JSON_Pretty(t *testing.T) {
@@ -68,6 +71,38 @@ func TestPrinter_StderrMessages(t *testing.T) {
 	}
 }
 
+func TestPrinter_Events(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewPrinter(&bytes.Buffer{}, &stderr, ModeCompact, false)
+
+	p.Event("ignored", nil)
+	if stderr.Len() > 0 {
+		t.Fatalf("Event outside event mode wrote %q", stderr.String())
+	}
+
+	p.EnableEvents()
+	p.Info("hello %s", "world")
+	p.Event("authorization_url", map[string]interface{}{"url": "https://bank.example"})
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), stderr.String())
+	}
+	var info, url map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &info); err != nil {
+		t.Fatalf("info event: %v", err)
+	}
+	if info["event"] != "info" || info["message"] != "hello world" || info["time"] == nil {
+		t.Errorf("info event = %v", info)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &url); err != nil {
+		t.Fatalf("url event: %v", err)
+	}
+	if url["event"] != "authorization_url" || url["url"] != "https://bank.example" {
+		t.Errorf("url event = %v", url)
+	}
+}
+
 func TestModeFromFlags(t *testing.T) {
 	tests := []struct {
 		pretty, compact, raw bool
@@ -87,3 +122,97 @@ func TestModeFromFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestPrinter_Stream(t *testing.T) {
+	var stdout bytes.Buffer
+	p := NewPrinter(&stdout, &bytes.Buffer{}, ModePretty, false)
+
+	s := p.Stream()
+	for i := 1; i <= 3; i++ {
+		if err := s.Write(map[string]int{"n": i}); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+		if lines := strings.Count(stdout.String(), "\n"); lines != i {
+			t.Fatalf("after %d writes stdout has %d lines", i, lines)
+		}
+	}
+
+	if got, want := stdout.String(), "{\"n\":1}\n{\"n\":2}\n{\"n\":3}\n"; got != want {
+		t.Errorf("stream = %q, want %q (compact even in pretty mode)", got, want)
+	}
+	if s.Count() != 3 {
+		t.Errorf("Count = %d, want 3", s.Count())
+	}
+}
+
+// upperRedactor upper-cases the whole document.
+type upperRedactor struct{}
+
+func (upperRedactor) Redact(data []byte) ([]byte, error) {
+	return bytes.ToUpper(data), nil
+}
+
+func TestPrinter_Redactor(t *testing.T) {
+	var stdout bytes.Buffer
+	p := NewPrinter(&stdout, &bytes.Buffer{}, ModePretty, false)
+	p.SetRedactor(upperRedactor{})
+
+	if err := p.JSON(map[string]string{"name": "acme"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RawJSON([]byte(`{"raw":"x"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Stream().Write(map[string]string{"line": "y"}); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"NAME\": \"ACME\"\n}\n{\n  \"RAW\": \"X\"\n}\n{\"LINE\":\"Y\"}\n"
+	if stdout.String() != want {
+		t.Errorf("got %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestPrinter_RawJSONRedactsOnce(t *testing.T) {
+	r, err := redact.Load(filepath.Join(t.TempDir(), "map.json"), redact.DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `{"creditor_name":"ACME Oy","creditor_account":{"iban":"DE89370400440532013000"},"amount":1.50}`
+
+	for _, mode := range []Mode{ModeCompact, ModePretty} {
+		var stdout bytes.Buffer
+		p := NewPrinter(&stdout, &bytes.Buffer{}, mode, false)
+		p.SetRedactor(r)
+		if err := p.RawJSON([]byte(in)); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(stdout.String(), "ACME") {
+			t.Errorf("mode %v: not redacted: %s", mode, stdout.String())
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(r.Reveal(stdout.String()))); err != nil {
+			t.Fatal(err)
+		}
+		if compact.String() != in {
+			t.Errorf("mode %v: revealed %s, want %s", mode, compact.String(), in)
+		}
+	}
+}
+
+func TestPrinter_ErrorFormatJSON(t *testing.T) {
+	var stderr bytes.Buffer
+	p := NewPrinter(&bytes.Buffer{}, &stderr, ModeCompact, false)
+	p.SetErrorFormat(ErrorJSON)
+
+	p.Warn("slow bank")
+	p.Report(Problem{Kind: "rate_limit", Message: "too many requests", Account: "main", RetryAfter: 30, ExitCode: 2})
+	want := `{"level":"warning","message":"slow bank"}` + "\n" +
+		`{"level":"error","kind":"rate_limit","message":"too many requests","account":"main","retry_after":30,"exit_code":2}` + "\n"
+	if stderr.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", stderr.String(), want)
+	}
+
+	if _, err := ParseErrorFormat("xml"); err == nil {
+		t.Error("ParseErrorFormat accepted xml")
+	}
+}
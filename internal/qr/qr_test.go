@@ -0,0 +1,251 @@
+package qr
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlignmentPositions(t *testing.T) {
+	// ISO/IEC 18004 Annex E
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		14: {6, 26, 46, 66},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if got := alignmentPositions(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("alignmentPositions(%d) = %v, want %v", version, got, want)
+		}
+	}
+}
+
+func TestDataCodewords(t *testing.T) {
+	// Level L data capacity from ISO/IEC 18004 table 7
+	tests := map[int]int{1: 19, 2: 34, 5: 108, 10: 274, 20: 861, 40: 2956}
+	for version, want := range tests {
+		if got := dataCodewords(version); got != want {
+			t.Errorf("dataCodewords(%d) = %d, want %d", version, got, want)
+		}
+	}
+}
+
+func TestFormatBits(t *testing.T) {
+	// Level L, mask 0 (ISO/IEC 18004 table C.1)
+	const want = 0b111011111000100
+
+	c := newCode(1)
+	c.drawFormatBits(0)
+
+	var first, second int
+	for i := 0; i <= 5; i++ {
+		first |= b2i(c.modules[i][8]) << i
+	}
+	first |= b2i(c.modules[7][8]) << 6
+	first |= b2i(c.modules[8][8]) << 7
+	first |= b2i(c.modules[8][7]) << 8
+	for i := 9; i < 15; i++ {
+		first |= b2i(c.modules[8][14-i]) << i
+	}
+	for i := 0; i < 8; i++ {
+		second |= b2i(c.modules[8][c.size-1-i]) << i
+	}
+	for i := 8; i < 15; i++ {
+		second |= b2i(c.modules[c.size-15+i][8]) << i
+	}
+
+	if first != want || second != want {
+		t.Errorf("format bits = %015b / %015b, want %015b", first, second, want)
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	// Version 7 information is 000111110010010100 (ISO/IEC 18004 table D.1)
+	const want = 0x07C94
+
+	c := newCode(7)
+	c.drawVersion()
+
+	got := 0
+	for i := 0; i < 18; i++ {
+		a, b := c.size-11+i%3, i/3
+		if c.modules[a][b] != c.modules[b][a] {
+			t.Fatalf("version copies differ at bit %d", i)
+		}
+		got |= b2i(c.modules[b][a]) << i
+	}
+	if got != want {
+		t.Errorf("version bits = %018b, want %018b", got, want)
+	}
+}
+
+func TestEncode_RoundTrip(t *testing.T) {
+	for _, text := range []string{
+		"x",
+		"https://tilisy.enablebanking.com/welcome?sessionid=73100c65-fbc1-4c9e-9e4e-5ee1aa3e0ebe",
+		strings.Repeat("https://example.com/auth?state=abc&code=", 12),
+	} {
+		c, err := Encode(text)
+		if err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		if c.Size() != c.Version()*4+17 {
+			t.Errorf("Size = %d for version %d", c.Size(), c.Version())
+		}
+		if got := decode(t, c); got != text {
+			t.Errorf("round trip = %q, want %q", got, text)
+		}
+	}
+}
+
+func TestEncode_TooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("a", 3000)); err == nil {
+		t.Error("expected error for text over 2953 bytes")
+	}
+}
+
+func TestWriteTerminal(t *testing.T) {
+	c, _ := Encode("hello")
+	var buf bytes.Buffer
+	if err := c.WriteTerminal(&buf); err != nil {
+		t.Fatalf("WriteTerminal: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if want := (c.Size() + 8 + 1) / 2; len(lines) != want {
+		t.Errorf("lines = %d, want %d", len(lines), want)
+	}
+	if n := len([]rune(lines[0])); n != c.Size()+8 {
+		t.Errorf("line width = %d, want %d", n, c.Size()+8)
+	}
+}
+
+// decode reads a symbol back: format information, unmasking, codeword
+// placement, de-interleaving, Reed-Solomon syndromes and the byte segment.
+func decode(t *testing.T, c *Code) string {
+	t.Helper()
+
+	// Format information from the copy around the top-left finder
+	format := 0
+	for i := 0; i <= 5; i++ {
+		format |= b2i(c.modules[i][8]) << i
+	}
+	format |= b2i(c.modules[7][8]) << 6
+	format |= b2i(c.modules[8][8]) << 7
+	format |= b2i(c.modules[8][7]) << 8
+	for i := 9; i < 15; i++ {
+		format |= b2i(c.modules[8][14-i]) << i
+	}
+	format ^= 0x5412
+	if level := format >> 13; level != formatBitsL {
+		t.Fatalf("format level = %d, want L", level)
+	}
+	mask := (format >> 10) & 7
+
+	// Unmask a copy and read the codewords in placement order
+	ref := newCode(c.version)
+	ref.drawFunctionPatterns()
+	for y := range ref.modules {
+		copy(ref.modules[y], c.modules[y])
+	}
+	ref.applyMask(mask)
+
+	raw := make([]byte, rawDataModules(c.version)/8)
+	i := 0
+	for right := c.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.size - 1 - vert
+				}
+				if !ref.function[y][x] && i < len(raw)*8 {
+					if ref.modules[y][x] {
+						raw[i>>3] |= 1 << (7 - i&7)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	// De-interleave and check each block's syndromes are zero
+	blocks := numBlocks[c.version]
+	eccLen := eccPerBlock[c.version]
+	numShort := blocks - len(raw)%blocks
+	shortLen := len(raw) / blocks
+	all := make([][]byte, blocks)
+	k := 0
+	for col := 0; col <= shortLen; col++ {
+		for j := range all {
+			if col == shortLen-eccLen && j < numShort {
+				continue
+			}
+			all[j] = append(all[j], raw[k])
+			k++
+		}
+	}
+	var data []byte
+	for j, block := range all {
+		for s := 0; s < eccLen; s++ {
+			var sum byte
+			x := gfPow(2, s)
+			for _, b := range block {
+				sum = gfMul(sum, x) ^ b
+			}
+			if sum != 0 {
+				t.Fatalf("block %d: syndrome %d = %#x, want 0", j, s, sum)
+			}
+		}
+		data = append(data, block[:len(block)-eccLen]...)
+	}
+
+	// Byte mode segment
+	var bits bitReader
+	bits.data = data
+	if mode := bits.read(4); mode != 0x4 {
+		t.Fatalf("mode = %#x, want byte mode", mode)
+	}
+	n := bits.read(charCountBits(c.version))
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(bits.read(8))
+	}
+	return string(out)
+}
+
+func gfPow(x byte, n int) byte {
+	r := byte(1)
+	for i := 0; i < n; i++ {
+		r = gfMul(r, x)
+	}
+	return r
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) read(n int) int {
+	v := 0
+	for i := 0; i < n; i++ {
+		v = v<<1 | int(r.data[r.pos>>3]>>(7-r.pos&7)&1)
+		r.pos++
+	}
+	return v
+}
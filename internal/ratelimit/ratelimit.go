@@ -8,13 +8,22 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
 )
 
 const (
 	CacheFileName   = "ratelimits.json"
 	FilePermissions = os.FileMode(0600)
+
+	// CacheVersion is the cache layout written by Persist.
+	//	1: a plain map of entries
+	//	2: {"entries": ..., "daily_usage": ...}
+	//	3: daily_usage keyed by "<profile>/<connection>"
+	CacheVersion = 3
 )
 
 // CacheEntry tracks rate limit state for a specific account+endpoint pair.
@@ -37,6 +46,7 @@ type DailyUsage struct {
 
 // cacheFile is the on-disk format for the rate limit cache.
 type cacheFile struct {
+	Version    int                    `json:"version"`
 	Entries    map[string]*CacheEntry `json:"entries,omitempty"`
 	DailyUsage map[string]*DailyUsage `json:"daily_usage,omitempty"`
 }
@@ -45,9 +55,12 @@ type cacheFile struct {
 type Tracker struct {
 	mu         sync.Mutex
 	entries    map[string]*CacheEntry
-	daily      map[string]*DailyUsage // keyed by connection name
+	daily      map[string]*DailyUsage // keyed by profile and connection name
 	cachePath  string
 	stderr     io.Writer
+
+	migratedFrom int    // cache layout version upgraded on load; 0 if current
+	original     []byte // cache file as read, backed up before the first rewrite
 }
 
 // NewTracker creates a rate limit tracker. Loads existing cache from disk.
@@ -166,7 +179,7 @@ func (t *Tracker) CheckDaily(connectionName string, maxPerDay int) error {
 	}
 
 	if usage.Count >= maxPerDay {
-		return fmt.Errorf("daily limit reached for %s (%d/%d today). Try again tomorrow", connectionName, usage.Count, maxPerDay)
+		return &DailyLimitError{Connection: connectionName, Used: usage.Count, Max: maxPerDay}
 	}
 
 	if usage.Count >= maxPerDay-1 {
@@ -176,6 +189,25 @@ func (t *Tracker) CheckDaily(connectionName string, maxPerDay int) error {
 	return nil
 }
 
+// DailyLimitError is returned by CheckDaily when a connection has used all
+// of today's accesses.
+type DailyLimitError struct {
+	Connection string
+	Used       int
+	Max        int
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("daily limit reached for %s (%d/%d today). Try again tomorrow", e.Connection, e.Used, e.Max)
+}
+
+// RetryAfter returns the time until the limit resets, at local midnight.
+func (e *DailyLimitError) RetryAfter() time.Duration {
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	return midnight.Sub(now)
+}
+
 // RecordDaily increments the daily access counter for a connection.
 func (t *Tracker) RecordDaily(connectionName string, maxPerDay int) {
 	t.mu.Lock()
@@ -197,6 +229,29 @@ func (t *Tracker) RecordDaily(connectionName string, maxPerDay int) {
 	usage.UpdatedAt = time.Now()
 }
 
+// RenameDaily moves today's accesses of a connection to its new name, adding
+// them to any already counted there.
+func (t *Tracker) RenameDaily(connectionName, newName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	today := time.Now().Format("2006-01-02")
+	usage, exists := t.daily[connectionName]
+	if !exists || connectionName == newName {
+		return
+	}
+	delete(t.daily, connectionName)
+	if usage.Date != today {
+		return
+	}
+	if target, ok := t.daily[newName]; ok && target.Date == today {
+		target.Count += usage.Count
+		target.UpdatedAt = time.Now()
+		return
+	}
+	t.daily[newName] = usage
+}
+
 // RemainingToday returns how many accesses remain today. Returns -1 for unlimited.
 func (t *Tracker) RemainingToday(connectionName string, maxPerDay int) int {
 	if maxPerDay <= 0 {
@@ -260,6 +315,7 @@ func (t *Tracker) Persist() error {
 	}
 
 	cf := cacheFile{
+		Version:    CacheVersion,
 		Entries:    t.entries,
 		DailyUsage: t.daily,
 	}
@@ -273,6 +329,13 @@ func (t *Tracker) Persist() error {
 		return err
 	}
 
+	if t.migratedFrom != 0 {
+		if err := config.WriteBackup(t.cachePath, t.original, t.migratedFrom); err != nil {
+			return err
+		}
+		t.migratedFrom = 0
+	}
+
 	return os.WriteFile(t.cachePath, data, FilePermissions)
 }
 
@@ -282,20 +345,68 @@ func (t *Tracker) load() {
 		return
 	}
 
-	// Try new format first (has "entries" or "daily_usage" keys)
-	var cf cacheFile
-	if err := json.Unmarshal(data, &cf); err == nil && (cf.Entries != nil || cf.DailyUsage != nil) {
-		if cf.Entries != nil {
-			t.entries = cf.Entries
-		}
-		if cf.DailyUsage != nil {
-			t.daily = cf.DailyUsage
-		}
+	cf, from, err := parseCache(data)
+	if err != nil {
+		// The cache is disposable; start over rather than fail the command
 		return
 	}
+	if cf.Entries != nil {
+		t.entries = cf.Entries
+	}
+	if cf.DailyUsage != nil {
+		t.daily = cf.DailyUsage
+	}
+	if from != CacheVersion {
+		t.migratedFrom = from
+		t.original = data
+	}
+}
 
-	// Fall back to old format (plain map of entries)
-	json.Unmarshal(data, &t.entries)
+// parseCache decodes the cache file in any known layout, upgrading it to
+// CacheVersion. It returns the version the data was written in.
+func parseCache(data []byte) (*cacheFile, int, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, 0, err
+	}
+
+	from := 1
+	if v, ok := probe["version"]; ok {
+		if err := json.Unmarshal(v, &from); err != nil {
+			return nil, 0, err
+		}
+	} else if _, ok := probe["entries"]; ok {
+		from = 2
+	} else if _, ok := probe["daily_usage"]; ok {
+		from = 2
+	}
+	if from > CacheVersion {
+		return nil, 0, fmt.Errorf("cache version %d is newer than supported (%d)", from, CacheVersion)
+	}
+
+	cf := &cacheFile{Version: CacheVersion}
+	switch from {
+	case 1:
+		// Plain map of entries
+		if err := json.Unmarshal(data, &cf.Entries); err != nil {
+			return nil, 0, err
+		}
+	default:
+		if err := json.Unmarshal(data, cf); err != nil {
+			return nil, 0, err
+		}
+	}
+	if from < 3 {
+		// Daily usage of the default profile was keyed by the bare
+		// connection name
+		for k, d := range cf.DailyUsage {
+			if !strings.Contains(k, "/") {
+				delete(cf.DailyUsage, k)
+				cf.DailyUsage[config.DefaultProfile+"/"+k] = d
+			}
+		}
+	}
+	return cf, from, nil
 }
 
 func key(accountUID, endpoint string) string {
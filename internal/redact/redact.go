@@ -0,0 +1,267 @@
+// Package redact removes personal data from JSON output for --redact: it
+// masks account numbers, replaces names with stable pseudonyms and drops
+// fields entirely. A mapping file next to the config keeps the salt and what
+// each pseudonym stands for, so redacted text can be restored locally.
+package redact
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+// Rules name the fields to redact, by JSON key ("iban") or by the end of
+// their path ("creditor.name"). Only string values are masked or
+// pseudonymized; removed fields go whatever their value.
+type Rules struct {
+	Mask         []string // keep the country and the last 4 characters
+	Pseudonymize []string // replace with a stable pseudonym, e.g. party_3fa2c1d0
+	Remove       []string // drop from the output
+}
+
+// DefaultRules are used for the lists a profile does not set.
+var DefaultRules = Rules{
+	Mask: []string{"iban", "bban", "identification"},
+	Pseudonymize: []string{
+		"creditor_name", "debtor_name", "creditor.name", "debtor.name",
+		"ultimate_creditor", "ultimate_debtor",
+		"creditor", "debtor", "counterparty", // --fields aliases
+	},
+	Remove: []string{"owner_name"},
+}
+
+// pseudonymPrefix starts every pseudonym, so they stand out in text.
+const pseudonymPrefix = "party_"
+
+// Redactor redacts JSON by its Rules and records the pseudonyms and masked
+// values it writes.
+type Redactor struct {
+	rules Rules
+	path  string
+	m     mapping
+	dirty bool
+}
+
+// mapping is the layout of the mapping file.
+type mapping struct {
+	Salt   string              `json:"salt"`             // hex
+	Names  map[string]string   `json:"names"`            // pseudonym -> name as first seen
+	Masked map[string][]string `json:"masked,omitempty"` // masked value -> the values masked to it
+}
+
+// Load returns a Redactor using the mapping file at path, which is created
+// with a new random salt on Save if it does not exist.
+func Load(path string, rules Rules) (*Redactor, error) {
+	r := &Redactor{rules: rules, path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &r.m); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	if r.m.Salt == "" {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		r.m.Salt, r.dirty = hex.EncodeToString(salt), true
+	}
+	if r.m.Names == nil {
+		r.m.Names = map[string]string{}
+	}
+	if r.m.Masked == nil {
+		r.m.Masked = map[string][]string{}
+	}
+	return r, nil
+}
+
+// Save writes the mapping file if pseudonyms or masked values were added.
+func (r *Redactor) Save() error {
+	if !r.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := config.WriteFileAtomic(r.path, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// Redact returns the JSON document data with the fields of the rules
+// redacted, compact and with object keys in their original order.
+func (r *Redactor) Redact(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := parse(dec)
+	if err != nil {
+		return nil, fmt.Errorf("redacting: %w", err)
+	}
+	return json.Marshal(r.walk(v, nil))
+}
+
+func (r *Redactor) walk(v interface{}, path []string) interface{} {
+	switch v := v.(type) {
+	case object:
+		out := make(object, 0, len(v))
+		for _, m := range v {
+			p := append(path[:len(path):len(path)], m.key)
+			if matches(r.rules.Remove, p) {
+				continue
+			}
+			if s, ok := m.value.(string); ok {
+				switch {
+				case matches(r.rules.Mask, p):
+					m.value = r.Mask(s)
+				case matches(r.rules.Pseudonymize, p):
+					m.value = r.Pseudonym(s)
+				}
+			} else {
+				m.value = r.walk(m.value, p)
+			}
+			out = append(out, m)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = r.walk(e, path)
+		}
+		return out
+	}
+	return v
+}
+
+// matches reports whether a rule names the field at path.
+func matches(rules []string, path []string) bool {
+	for _, rule := range rules {
+		segs := strings.Split(strings.ToLower(rule), ".")
+		if len(segs) > len(path) {
+			continue
+		}
+		tail := path[len(path)-len(segs):]
+		match := true
+		for i := range segs {
+			match = match && segs[i] == tail[i]
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// Mask keeps the first 2 characters of s (an IBAN's country) and the last
+// 4, replacing the others with "*". Values too short to keep anything are
+// masked entirely.
+func (r *Redactor) Mask(s string) string {
+	compact := strings.ReplaceAll(s, " ", "")
+	if compact == "" {
+		return s
+	}
+	masked := mask(compact)
+	for _, seen := range r.m.Masked[masked] {
+		if seen == compact {
+			return masked
+		}
+	}
+	r.m.Masked[masked] = append(r.m.Masked[masked], compact)
+	r.dirty = true
+	return masked
+}
+
+func mask(compact string) string {
+	runes := []rune(compact)
+	n := len(runes)
+	if n <= 6 {
+		return strings.Repeat("*", n)
+	}
+	return string(runes[:2]) + strings.Repeat("*", n-6) + string(runes[n-4:])
+}
+
+// ibanPattern matches IBANs in text, compact or in groups of four, in either
+// case. Groups must have four characters but the last, so that the words
+// after a grouped IBAN are not taken as part of it.
+var ibanPattern = regexp.MustCompile(`(?i)\b[A-Z]{2}[0-9]{2}(?:[A-Z0-9]{11,30}|(?: [A-Z0-9]{4}){2,7}(?: [A-Z0-9]{1,4})?)\b`)
+
+// MaskIBANs masks the IBANs in text as Mask does, without recording them,
+// for text that is not JSON output such as debug logs.
+func MaskIBANs(text string) string {
+	return ibanPattern.ReplaceAllStringFunc(text, func(iban string) string {
+		return mask(strings.ReplaceAll(iban, " ", ""))
+	})
+}
+
+// Pseudonym returns the pseudonym of name: the same for every spelling that
+// differs only in case and spacing, and different for each salt. Pseudonyms
+// have 8 hex digits, more if those already stand for another name.
+func (r *Redactor) Pseudonym(name string) string {
+	key := pseudonymKey(name)
+	if key == "" {
+		return name
+	}
+	mac := hmac.New(sha256.New, []byte(r.m.Salt))
+	mac.Write([]byte(key))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	n := 8
+	for ; n < len(sum); n += 4 {
+		other, ok := r.m.Names[pseudonymPrefix+sum[:n]]
+		if !ok || pseudonymKey(other) == key {
+			break
+		}
+	}
+	p := pseudonymPrefix + sum[:n]
+	if _, ok := r.m.Names[p]; !ok {
+		r.m.Names[p] = strings.TrimSpace(name)
+		r.dirty = true
+	}
+	return p
+}
+
+// pseudonymKey is name with the differences Pseudonym ignores removed.
+func pseudonymKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Reveal replaces the pseudonyms and masked values in text with what they
+// stand for. Masked values shared by several originals are left alone.
+func (r *Redactor) Reveal(text string) string {
+	type pair struct{ from, to string }
+	var pairs []pair
+	for p, name := range r.m.Names {
+		pairs = append(pairs, pair{p, name})
+	}
+	for masked, originals := range r.m.Masked {
+		if len(originals) == 1 && strings.Contains(masked, "*") {
+			pairs = append(pairs, pair{masked, originals[0]})
+		}
+	}
+	// Longest first, so no value is replaced by a prefix of it
+	sort.Slice(pairs, func(i, j int) bool {
+		if len(pairs[i].from) != len(pairs[j].from) {
+			return len(pairs[i].from) > len(pairs[j].from)
+		}
+		return pairs[i].from < pairs[j].from
+	})
+	oldnew := make([]string, 0, 2*len(pairs))
+	for _, p := range pairs {
+		oldnew = append(oldnew, p.from, p.to)
+	}
+	return strings.NewReplacer(oldnew...).Replace(text)
+}
@@ -0,0 +1,133 @@
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	r, err := Load(filepath.Join(t.TempDir(), "map.json"), DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := `{"iban":"FI21 1234 5600 0007 85","owner_name":"Jane Doe","transactions":[` +
+		`{"creditor_name":"ACME Oy","creditor_account":{"iban":"DE89370400440532013000"},"amount":1.50},` +
+		`{"creditor_name":"acme  oy","debtor":{"name":"Bob"},"remittance_information":["Invoice 7"]}]}`
+	out, err := r.Redact([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acme := r.Pseudonym("ACME Oy")
+	want := `{"iban":"FI************0785","transactions":[` +
+		`{"creditor_name":"` + acme + `","creditor_account":{"iban":"DE****************3000"},"amount":1.50},` +
+		`{"creditor_name":"` + acme + `","debtor":{"name":"` + r.Pseudonym("Bob") + `"},"remittance_information":["Invoice 7"]}]}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+	if !strings.HasPrefix(acme, "party_") || len(acme) != len("party_")+8 {
+		t.Errorf("pseudonym %q", acme)
+	}
+}
+
+func TestRedact_Rules(t *testing.T) {
+	r, err := Load(filepath.Join(t.TempDir(), "map.json"), Rules{Remove: []string{"transactions.remittance_information"}, Mask: []string{"account.iban"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := r.Redact([]byte(`{"iban":"FI2112345600000785","account":{"iban":"FI2112345600000785"},"transactions":[{"creditor_name":"ACME","remittance_information":["x"]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"iban":"FI2112345600000785","account":{"iban":"FI************0785"},"transactions":[{"creditor_name":"ACME"}]}`
+	if string(out) != want {
+		t.Errorf("got  %s\nwant %s", out, want)
+	}
+}
+
+func TestPseudonym_StableAcrossRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	r, err := Load(path, DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := r.Pseudonym("ACME Oy")
+	masked := r.Mask("FI2112345600000785")
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("map file: %v, %v", info, err)
+	}
+
+	again, err := Load(path, DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := again.Pseudonym("ACME Oy"); got != first {
+		t.Errorf("pseudonym changed across runs: %q, %q", first, got)
+	}
+	other, err := Load(filepath.Join(t.TempDir(), "map.json"), DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Pseudonym("ACME Oy") == first {
+		t.Error("pseudonym is the same with another salt")
+	}
+
+	text := "Most goes to " + first + " from " + masked + "."
+	if got, want := again.Reveal(text), "Most goes to ACME Oy from FI2112345600000785."; got != want {
+		t.Errorf("Reveal = %q, want %q", got, want)
+	}
+}
+
+func TestPseudonym_Collision(t *testing.T) {
+	r, err := Load(filepath.Join(t.TempDir(), "map.json"), DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := r.Pseudonym("ACME Oy")
+	r.m.Names[short] = "Globex" // as if Globex's pseudonym had the same 8 digits
+
+	long := r.Pseudonym("ACME Oy")
+	if !strings.HasPrefix(long, short) || len(long) <= len(short) {
+		t.Fatalf("pseudonym after a collision = %q, want a longer %q", long, short)
+	}
+	if again := r.Pseudonym("acme  oy"); again != long {
+		t.Errorf("pseudonym changed: %q, %q", long, again)
+	}
+	if got, want := r.Reveal(short+" paid "+long), "Globex paid ACME Oy"; got != want {
+		t.Errorf("Reveal = %q, want %q", got, want)
+	}
+}
+
+func TestReveal_AmbiguousMask(t *testing.T) {
+	r, err := Load(filepath.Join(t.TempDir(), "map.json"), DefaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := r.Mask("FI2112345600000785")
+	if b := r.Mask("FI9912345600000785"); a != b {
+		t.Fatalf("masks differ: %q, %q", a, b)
+	}
+	if got := r.Reveal(a); got != a {
+		t.Errorf("ambiguous mask revealed as %q", got)
+	}
+}
+
+func TestMaskIBANs(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"iban":"FI2112345600000785"}`, `{"iban":"FI************0785"}`},
+		{"paid to DE89 3704 0044 0532 0130 00 today", "paid to DE****************3000 today"},
+		{"iban=fi2112345600000785", "iban=fi************0785"},
+		{"to fi21 1234 5600 0007 85 on", "to fi************0785 on"},
+		{"/accounts/3fa2-c1d0/balances?date_from=2026-01-01", "/accounts/3fa2-c1d0/balances?date_from=2026-01-01"},
+		{"ref RF18 and FI1111", "ref RF18 and FI1111"},
+	}
+	for _, tt := range tests {
+		if got := MaskIBANs(tt.in); got != tt.want {
+			t.Errorf("MaskIBANs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -15,7 +15,8 @@ type Result struct {
 }
 
 // Resolve finds an account across all connections in the config.
-// The query can be an alias, UID, or IBAN (all case-insensitive).
+// The query can be an alias, ID, UID (current or from an earlier session),
+// or IBAN (all case-insensitive).
 func Resolve(cfg *config.Config, query string) (*Result, error) {
 	query = strings.TrimSpace(query)
 	if query == "" {
@@ -38,6 +39,10 @@ func Resolve(cfg *config.Config, query string) (*Result, error) {
 
 	switch len(matches) {
 	case 0:
+		if retired, err := ResolveRetired(cfg, query); err == nil {
+			return nil, fmt.Errorf("account %q was retired from %s on %s; if the bank now lists it under a new account, run: ebcli accounts link %s <account>",
+				query, retired.Connection.Name, retired.RetiredAt.Format("2006-01-02"), retired.Account.ID)
+		}
 		return nil, fmt.Errorf("no account found matching %q", query)
 	case 1:
 		return &matches[0], nil
@@ -46,6 +51,39 @@ func Resolve(cfg *config.Config, query string) (*Result, error) {
 	}
 }
 
+// RetiredResult is a retired account and the connection it belonged to.
+type RetiredResult struct {
+	config.RetiredAccount
+	Connection config.Connection
+}
+
+// ResolveRetired finds an account that is no longer part of its connection's
+// session, by ID, alias, UID (current or previous) or IBAN.
+func ResolveRetired(cfg *config.Config, query string) (*RetiredResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("empty account identifier")
+	}
+
+	var matches []RetiredResult
+	for _, conn := range cfg.Connections {
+		for _, acct := range conn.RetiredAccounts {
+			if matchesAccount(acct.Account, query) {
+				matches = append(matches, RetiredResult{RetiredAccount: acct, Connection: conn})
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no retired account found matching %q", query)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("ambiguous identifier %q matches %d retired accounts; use its ID", query, len(matches))
+	}
+}
+
 // ResolveAll returns all accounts from the config as resolved results.
 func ResolveAll(cfg *config.Config) []Result {
 	var results []Result
@@ -79,6 +117,11 @@ func matchesAccount(acct config.Account, query string) bool {
 		return true
 	}
 
+	// Local ID and UIDs from earlier sessions
+	if strings.EqualFold(acct.ID, query) || acct.HasUID(query) {
+		return true
+	}
+
 	// IBAN match (case-insensitive)
 	if acct.IBAN != "" && strings.EqualFold(acct.IBAN, query) {
 		return true
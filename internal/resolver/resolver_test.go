@@ -1,6 +1,7 @@
 package resolver
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/nicolasacchi/ebcli/internal/config"
@@ -122,3 +123,97 @@ func TestResolveAll_Empty(t *testing.T) {
 		t.Fatalf("ResolveAll on empty config = %d, want 0", len(results))
 	}
 }
+
+func TestResolve_ByIDAndPreviousUID(t *testing.T) {
+	cfg := testConfig()
+	cfg.Connections[1].Accounts[0].ID = "5f3a9c21b7d0"
+	cfg.Connections[1].Accounts[0].PreviousUIDs = []string{"old-revolut-uid"}
+
+	for _, query := range []string{"5f3a9c21b7d0", "old-revolut-uid"} {
+		result, err := Resolve(cfg, query)
+		if err != nil {
+			t.Fatalf("Resolve(%q): %v", query, err)
+		}
+		if result.Account.Alias != "revolut-eur" {
+			t.Errorf("Resolve(%q) alias = %q, want revolut-eur", query, result.Account.Alias)
+		}
+	}
+}
+
+func TestResolve_Retired(t *testing.T) {
+	cfg := testConfig()
+	cfg.Connections[0].RetiredAccounts = []config.RetiredAccount{{
+		Account: config.Account{ID: "0a1b2c3d4e5f", UID: "retired-uid", Alias: "nordea-old"},
+	}}
+
+	_, err := Resolve(cfg, "nordea-old")
+	if err == nil || !strings.Contains(err.Error(), "ebcli accounts link 0a1b2c3d4e5f") {
+		t.Errorf("Resolve retired alias: err = %v, want a link hint", err)
+	}
+
+	retired, err := ResolveRetired(cfg, "retired-uid")
+	if err != nil {
+		t.Fatalf("ResolveRetired: %v", err)
+	}
+	if retired.Account.ID != "0a1b2c3d4e5f" || retired.Connection.Name != "nordea" {
+		t.Errorf("ResolveRetired = %s in %s, want 0a1b2c3d4e5f in nordea", retired.Account.ID, retired.Connection.Name)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	cfg := testConfig()
+	cfg.Connections[0].Accounts[0].Tags = []string{"household"}
+	cfg.Connections[0].Accounts[1].CashAccountType = "SVGS"
+	cfg.Connections[1].Accounts[0].Tags = []string{"Household", "travel"}
+	cfg.Connections[1].Accounts[0].CashAccountType = "CACC"
+	cfg.Groups = map[string][]string{
+		"savings": {"type:svgs"},
+		"all":     {"group:savings", "tag:house*"},
+		"loop":    {"group:loop"},
+	}
+
+	tests := []struct {
+		selector string
+		want     []string // aliases, in config order
+		wantErr  string
+	}{
+		{"nordea-eur", []string{"nordea-eur"}, ""},
+		{"revolut-eur, nordea-eur", []string{"nordea-eur", "revolut-eur"}, ""},
+		{"tag:HOUSEHOLD", []string{"nordea-eur", "revolut-eur"}, ""},
+		{"connection:nordea", []string{"nordea-eur", "nordea-eur-2"}, ""},
+		{"currency:eur,type:cacc", []string{"nordea-eur", "nordea-eur-2", "revolut-eur"}, ""},
+		{"currency:eur+type:cacc", []string{"revolut-eur"}, ""},
+		{"connection:nordea+tag:household, type:svgs", []string{"nordea-eur", "nordea-eur-2"}, ""},
+		{"connection:revolut+type:svgs", nil, `no account matches "connection:revolut+type:svgs"`},
+		{"nordea-*", []string{"nordea-eur", "nordea-eur-2"}, ""},
+		{"FI9*", []string{"nordea-eur-2"}, ""},
+		{"group:ALL", []string{"nordea-eur", "nordea-eur-2", "revolut-eur"}, ""},
+		{"tag:none", nil, `no account matches "tag:none"`},
+		{"nordea-eur,missing", nil, `no account found matching "missing"`},
+		{"color:red", nil, "unknown selector"},
+		{"group:nope", nil, `no group "nope"`},
+		{"group:loop", nil, "includes itself"},
+		{" , ", nil, "empty account identifier"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			results, err := Select(cfg, tt.selector)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Select: %v", err)
+			}
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Account.Alias)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("Select = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package resolver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/nicolasacchi/ebcli/internal/config"
+)
+
+// Selectors
+//
+// A selector picks any number of accounts. It is a comma-separated list of
+// terms, and the result is every account matched by any term. A term is one
+// or more conditions joined by "+", all of which must match:
+//
+//	ing-eur                a single account, as accepted by Resolve
+//	ing-*                  aliases or IBANs matching a glob
+//	tag:household          accounts with a tag
+//	group:bills            the selectors of a group defined in the config
+//	connection:ing         accounts of a connection
+//	currency:EUR           accounts in a currency
+//	type:CACC              accounts of a cash account type
+//	currency:EUR+type:CACC EUR checking accounts
+//
+// Values after a prefix are case-insensitive and may be globs too.
+
+// selectorKeys are the condition prefixes, in the order they are documented.
+var selectorKeys = []string{"tag", "group", "connection", "currency", "type"}
+
+// accountSet is a set of accounts, by their address in the config.
+type accountSet map[*config.Account]bool
+
+// Select returns the accounts matched by selector, in config order. Each term
+// must match at least one account.
+func Select(cfg *config.Config, selector string) ([]Result, error) {
+	selected, err := selectAll(cfg, selector, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for i := range cfg.Connections {
+		conn := cfg.Connections[i]
+		for j := range cfg.Connections[i].Accounts {
+			if selected[&cfg.Connections[i].Accounts[j]] {
+				results = append(results, Result{
+					Account:            conn.Accounts[j],
+					Connection:         conn,
+					RequiredPSUHeaders: conn.RequiredPSUHeaders,
+				})
+			}
+		}
+	}
+	return results, nil
+}
+
+// selectAll returns the union of the terms of selector. groups holds the
+// groups being expanded, to catch groups that include themselves.
+func selectAll(cfg *config.Config, selector string, groups []string) (accountSet, error) {
+	selected := accountSet{}
+	terms := 0
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		terms++
+
+		var matched accountSet
+		for _, cond := range strings.Split(term, "+") {
+			set, err := selectCondition(cfg, strings.TrimSpace(cond), groups)
+			if err != nil {
+				return nil, err
+			}
+			if matched == nil {
+				matched = set
+				continue
+			}
+			for acct := range matched {
+				if !set[acct] {
+					delete(matched, acct)
+				}
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no account matches %q", term)
+		}
+		for acct := range matched {
+			selected[acct] = true
+		}
+	}
+	if terms == 0 {
+		return nil, fmt.Errorf("empty account identifier")
+	}
+	return selected, nil
+}
+
+// selectCondition returns the accounts matched by a single condition.
+func selectCondition(cfg *config.Config, cond string, groups []string) (accountSet, error) {
+	if cond == "" {
+		return nil, fmt.Errorf("empty condition in account selector")
+	}
+	key, value, hasKey := strings.Cut(cond, ":")
+	if !hasKey {
+		return selectPlain(cfg, cond)
+	}
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, fmt.Errorf("empty value in %q", cond)
+	}
+	if key == "group" {
+		return selectGroup(cfg, value, groups)
+	}
+
+	field, ok := accountFields[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown selector %q (use %s: or an alias, UID or IBAN)", key+":", strings.Join(selectorKeys, ":, "))
+	}
+	set := accountSet{}
+	for i := range cfg.Connections {
+		conn := &cfg.Connections[i]
+		for j := range conn.Accounts {
+			acct := &conn.Accounts[j]
+			for _, v := range field(conn, acct) {
+				if matchGlob(value, v) {
+					set[acct] = true
+					break
+				}
+			}
+		}
+	}
+	return set, nil
+}
+
+// accountFields returns the values a prefixed condition is matched against.
+var accountFields = map[string]func(conn *config.Connection, acct *config.Account) []string{
+	"tag":        func(_ *config.Connection, a *config.Account) []string { return a.Tags },
+	"connection": func(c *config.Connection, _ *config.Account) []string { return []string{c.Name} },
+	"currency":   func(_ *config.Connection, a *config.Account) []string { return []string{a.Currency} },
+	"type":       func(_ *config.Connection, a *config.Account) []string { return []string{a.CashAccountType} },
+}
+
+// selectPlain returns the accounts matched by a condition without a prefix:
+// a glob over aliases and IBANs, or a single account identifier.
+func selectPlain(cfg *config.Config, cond string) (accountSet, error) {
+	if !isGlob(cond) {
+		result, err := Resolve(cfg, cond)
+		if err != nil {
+			return nil, err
+		}
+		for i := range cfg.Connections {
+			conn := &cfg.Connections[i]
+			for j := range conn.Accounts {
+				if conn.Name == result.Connection.Name && conn.Accounts[j].UID == result.Account.UID {
+					return accountSet{&conn.Accounts[j]: true}, nil
+				}
+			}
+		}
+		return accountSet{}, nil
+	}
+
+	set := accountSet{}
+	for i := range cfg.Connections {
+		for j := range cfg.Connections[i].Accounts {
+			acct := &cfg.Connections[i].Accounts[j]
+			if matchGlob(cond, acct.Alias) || matchGlob(cond, acct.IBAN) {
+				set[acct] = true
+			}
+		}
+	}
+	return set, nil
+}
+
+func selectGroup(cfg *config.Config, name string, groups []string) (accountSet, error) {
+	var members []string
+	found := false
+	for groupName, m := range cfg.Groups {
+		if strings.EqualFold(groupName, name) {
+			name, members, found = groupName, m, true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no group %q in the config", name)
+	}
+	for _, g := range groups {
+		if g == name {
+			return nil, fmt.Errorf("group %q includes itself", name)
+		}
+	}
+	set, err := selectAll(cfg, strings.Join(members, ","), append(groups, name))
+	if err != nil {
+		return nil, fmt.Errorf("group %s: %w", name, err)
+	}
+	return set, nil
+}
+
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// matchGlob reports whether s matches pattern, case-insensitively.
+func matchGlob(pattern, s string) bool {
+	if s == "" {
+		return false
+	}
+	if !isGlob(pattern) {
+		return strings.EqualFold(pattern, s)
+	}
+	ok, err := path.Match(strings.ToLower(pattern), strings.ToLower(s))
+	return err == nil && ok
+}